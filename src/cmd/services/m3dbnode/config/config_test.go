@@ -386,6 +386,7 @@ db:
     newFileMode: null
     newDirectoryMode: null
     mmap: null
+    filenameTimeFormat: null
   commitlog:
     flushMaxBytes: 524288
     flushEvery: 1s
@@ -23,6 +23,8 @@ package config
 import (
 	"fmt"
 	"os"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
 )
 
 const (
@@ -75,6 +77,10 @@ type FilesystemConfiguration struct {
 
 	// Mmap is the mmap options which features are primarily platform dependent
 	Mmap *MmapConfiguration `yaml:"mmap"`
+
+	// FilenameTimeFormat is the format used to encode block start times in
+	// the names of data file set files, either unix_nanos or human_readable.
+	FilenameTimeFormat *fs.FilenameTimeFormat `yaml:"filenameTimeFormat"`
 }
 
 // MmapConfiguration is the mmap configuration.
@@ -141,6 +147,15 @@ func (p FilesystemConfiguration) ParseNewDirectoryMode() (os.FileMode, error) {
 	return os.ModeDir | os.FileMode(v), nil
 }
 
+// FilenameTimeFormatOrDefault returns the configured filename time format
+// or the default if none is specified.
+func (p FilesystemConfiguration) FilenameTimeFormatOrDefault() fs.FilenameTimeFormat {
+	if p.FilenameTimeFormat == nil {
+		return fs.DefaultFilenameTimeFormat
+	}
+	return *p.FilenameTimeFormat
+}
+
 // MmapConfiguration returns the effective mmap configuration.
 func (p FilesystemConfiguration) MmapConfiguration() MmapConfiguration {
 	if p.Mmap == nil {
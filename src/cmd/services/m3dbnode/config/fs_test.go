@@ -24,8 +24,11 @@ import (
 	"os"
 	"testing"
 
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
 )
 
 func TestFilesystemConfigurationParseNewFileMode(t *testing.T) {
@@ -47,3 +50,11 @@ func TestFilesystemConfigurationParseNewDirectoryMode(t *testing.T) {
 
 	assert.Equal(t, os.FileMode(0775)|os.ModeDir, v)
 }
+
+func TestFilesystemConfigurationFilenameTimeFormat(t *testing.T) {
+	var cfg FilesystemConfiguration
+	assert.Equal(t, fs.DefaultFilenameTimeFormat, cfg.FilenameTimeFormatOrDefault())
+
+	require.NoError(t, yaml.Unmarshal([]byte("filenameTimeFormat: human_readable"), &cfg))
+	assert.Equal(t, fs.HumanReadableFilenameTimeFormat, cfg.FilenameTimeFormatOrDefault())
+}
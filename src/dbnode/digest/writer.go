@@ -32,21 +32,40 @@ type FdWithDigestWriter interface {
 	io.Writer
 }
 
+// FileWriterFn returns the writer that buffered writes are flushed to for a file.
+type FileWriterFn func(fd *os.File) io.Writer
+
 type fdWithDigestWriter struct {
 	FdWithDigest
-	writer *bufio.Writer
+	writer       *bufio.Writer
+	fileWriterFn FileWriterFn
 }
 
 // NewFdWithDigestWriter creates a new FdWithDigestWriter.
 func NewFdWithDigestWriter(bufferSize int) FdWithDigestWriter {
+	return NewFdWithDigestWriterWithFileWriterFn(bufferSize, nil)
+}
+
+// NewFdWithDigestWriterWithFileWriterFn creates a new FdWithDigestWriter that
+// flushes buffered writes to the writer returned by fn for the underlying file,
+// or directly to the file if fn is nil.
+func NewFdWithDigestWriterWithFileWriterFn(
+	bufferSize int,
+	fn FileWriterFn,
+) FdWithDigestWriter {
 	return &fdWithDigestWriter{
 		FdWithDigest: newFdWithDigest(),
 		writer:       bufio.NewWriterSize(nil, bufferSize),
+		fileWriterFn: fn,
 	}
 }
 
 func (w *fdWithDigestWriter) Reset(fd *os.File) {
 	w.FdWithDigest.Reset(fd)
+	if w.fileWriterFn != nil && fd != nil {
+		w.writer.Reset(w.fileWriterFn(fd))
+		return
+	}
 	w.writer.Reset(fd)
 }
 
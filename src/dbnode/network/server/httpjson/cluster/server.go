@@ -21,7 +21,6 @@
 package cluster
 
 import (
-	"net"
 	"net/http"
 
 	"github.com/m3db/m3/src/dbnode/client"
@@ -58,6 +57,23 @@ func NewServer(
 	}
 }
 
+// NewServerValidated creates a cluster HTTP network service, returning an
+// error if the address is malformed for the network set in the server options
+func NewServerValidated(
+	client client.Client,
+	address string,
+	contextPool context.Pool,
+	opts httpjson.ServerOptions,
+) (ns.NetworkService, error) {
+	if opts == nil {
+		opts = httpjson.NewServerOptions()
+	}
+	if err := httpjson.ValidateAddress(address, opts); err != nil {
+		return nil, err
+	}
+	return NewServer(client, address, contextPool, opts), nil
+}
+
 func (s *server) ListenAndServe() (ns.Close, error) {
 	service := ttcluster.NewService(s.client)
 
@@ -66,16 +82,12 @@ func (s *server) ListenAndServe() (ns.Close, error) {
 		return nil, err
 	}
 
-	listener, err := net.Listen("tcp", s.address)
+	listener, err := httpjson.Listen(s.address, s.opts)
 	if err != nil {
 		return nil, err
 	}
 
-	server := http.Server{
-		Handler:      mux,
-		ReadTimeout:  s.opts.ReadTimeout(),
-		WriteTimeout: s.opts.WriteTimeout(),
-	}
+	server := httpjson.NewHTTPServer(mux, s.opts)
 
 	go func() {
 		server.Serve(listener)
@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package httpjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// toSnakeCase converts a camelCase or PascalCase field name to snake_case,
+// keeping runs of upper case letters such as acronyms together.
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var buff bytes.Buffer
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+					(unicode.IsUpper(prev) && nextIsLower) {
+					buff.WriteRune('_')
+				}
+			}
+			r = unicode.ToLower(r)
+		}
+		buff.WriteRune(r)
+	}
+	return buff.String()
+}
+
+// fromSnakeCase removes the underscores from a snake_case field name so that
+// it matches the corresponding camelCase field, since field names are
+// matched case insensitively when decoding.
+func fromSnakeCase(name string) string {
+	return strings.Replace(name, "_", "", -1)
+}
+
+// transformJSONKeys applies fn to the keys of all objects within a decoded
+// JSON value, including the keys of objects decoded from maps.
+func transformJSONKeys(value interface{}, fn func(string) string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			result[fn(key)] = transformJSONKeys(elem, fn)
+		}
+		return result
+	case []interface{}:
+		for i := range v {
+			v[i] = transformJSONKeys(v[i], fn)
+		}
+		return v
+	}
+	return value
+}
+
+// transformJSON decodes the JSON read from r, applies fn to the keys of all
+// objects and returns the re-encoded JSON.
+func transformJSON(r io.Reader, fn func(string) string) ([]byte, error) {
+	decoder := json.NewDecoder(r)
+	// Preserve numbers exactly rather than converting them to floats
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	buff := bytes.NewBuffer(nil)
+	if err := json.NewEncoder(buff).Encode(transformJSONKeys(value, fn)); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package httpjson
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToSnakeCase(t *testing.T) {
+	inputs := []struct {
+		name     string
+		expected string
+	}{
+		{"name", "name"},
+		{"rangeStart", "range_start"},
+		{"RangeStart", "range_start"},
+		{"requestID", "request_id"},
+		{"HTTPServer", "http_server"},
+		{"shard2Start", "shard2_start"},
+		{"already_snake", "already_snake"},
+	}
+	for _, input := range inputs {
+		require.Equal(t, input.expected, toSnakeCase(input.name))
+	}
+}
+
+func TestTransformJSON(t *testing.T) {
+	data, err := transformJSON(strings.NewReader(
+		`{"rangeStart":1234567890123456789,"elements":[{"seriesID":"foo"}]}`),
+		toSnakeCase)
+	require.NoError(t, err)
+	require.JSONEq(t,
+		`{"range_start":1234567890123456789,"elements":[{"series_id":"foo"}]}`,
+		string(data))
+
+	data, err = transformJSON(strings.NewReader(`{"range_start":1,"rangeEnd":2}`),
+		fromSnakeCase)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"rangestart":1,"rangeEnd":2}`, string(data))
+}
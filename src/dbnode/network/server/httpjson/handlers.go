@@ -25,33 +25,85 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 
 	xerrors "github.com/m3db/m3x/errors"
+	xlog "github.com/m3db/m3x/log"
 
 	apachethrift "github.com/apache/thrift/lib/go/thrift"
+	"github.com/pborman/uuid"
+	"github.com/uber/tchannel-go"
 	"github.com/uber/tchannel-go/thrift"
+	"golang.org/x/net/context"
 )
 
+const (
+	// RequestIDHeader is the header used to correlate a request with the
+	// service call it results in, it is generated if not set by the caller
+	RequestIDHeader = "X-Request-ID"
+
+	// MethodsPath is the path of the route listing the mounted service
+	// methods, it is not mounted if it conflicts with a service method
+	MethodsPath = "/methods"
+
+	// jsonContentType is the media type required of request bodies when
+	// JSON content type enforcement is enabled
+	jsonContentType = "application/json"
+
+	// inFlightRetryAfterSeconds is the Retry-After returned to callers when
+	// the max in flight requests limit is reached
+	inFlightRetryAfterSeconds = "1"
+
+	// warningHeaderCode is the miscellaneous warning code used for the
+	// Warning headers of partial results that are not wrapped
+	warningHeaderCode = "199"
+)
+
+// PartialResult is implemented by service method results that can succeed
+// partially, e.g. when only some shards are available. Any warnings it
+// returns are included in the response alongside the result, as the
+// warnings field of the envelope if successful results are wrapped or as
+// Warning headers otherwise.
+type PartialResult interface {
+	Warnings() []string
+}
+
 var (
 	errRequestMustBeGet   = xerrors.NewInvalidParamsError(errors.New("request without request params must be GET"))
 	errRequestMustBePost  = xerrors.NewInvalidParamsError(errors.New("request with request params must be POST"))
 	errInvalidRequestBody = xerrors.NewInvalidParamsError(errors.New("request contains an invalid request body"))
+	errUnsupportedContent = xerrors.NewInvalidParamsError(errors.New("request content type must be application/json"))
 	errEncodeResponseBody = errors.New("failed to encode response body")
+	errTooManyInFlight    = errors.New("too many requests in flight")
 )
 
 type respSuccess struct {
 }
 
+type respSuccessResult struct {
+	Data     interface{} `json:"data"`
+	Warnings []string    `json:"warnings,omitempty"`
+}
+
 type respErrorResult struct {
 	Error respError `json:"error"`
 }
 
 type respError struct {
 	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type respMethod struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	HasResult bool   `json:"hasResult"`
 }
 
 // RegisterHandlers will register handlers on the HTTP serve mux for a given service and options
@@ -60,6 +112,34 @@ func RegisterHandlers(mux *http.ServeMux, service interface{}, opts ServerOption
 	t := v.Type()
 	contextFn := opts.ContextFn()
 	postResponseFn := opts.PostResponseFn()
+	wrapSuccess := opts.WrapSuccess()
+	strictDecoding := opts.StrictDecoding()
+	snakeCase := opts.SnakeCaseFields()
+	requireJSON := opts.RequireJSONContentType()
+	errWriter := errorWriter{
+		redactData: opts.RedactErrorData(),
+		logger:     opts.Logger(),
+	}
+	registered := make(map[string]struct{})
+	methods := make([]respMethod, 0, t.NumMethod())
+
+	// NB: The in flight limit is shared by all service methods so that a
+	// flood of any request cannot overwhelm the service
+	var inFlight chan struct{}
+	if maxInFlight := opts.MaxInFlight(); maxInFlight > 0 {
+		inFlight = make(chan struct{}, maxInFlight)
+	}
+
+	// NB: The result cache is shared by all cacheable service methods so
+	// that its size bounds the memory used by all cached responses
+	var cache *resultCache
+	if size, ttl := opts.ResultCacheSize(), opts.ResultCacheTTL(); size > 0 && ttl > 0 {
+		cache = newResultCache(size, ttl, opts.ClockOptions().NowFn())
+	}
+	cacheable := make(map[string]struct{}, len(opts.CacheableMethods()))
+	for _, name := range opts.CacheableMethods() {
+		cacheable[strings.ToLower(name)] = struct{}{}
+	}
 	for i := 0; i < t.NumMethod(); i++ {
 		method := t.Method(i)
 
@@ -115,19 +195,55 @@ func RegisterHandlers(mux *http.ServeMux, service interface{}, opts ServerOption
 		}
 
 		name := strings.ToLower(method.Name)
-		mux.HandleFunc(fmt.Sprintf("/%s", name), func(w http.ResponseWriter, r *http.Request) {
+		path := fmt.Sprintf("/%s", name)
+		registered[path] = struct{}{}
+
+		// Only responses with a result are cached, writes are never cacheable
+		var methodCache *resultCache
+		if _, ok := cacheable[name]; ok && method.Type.NumOut() == 2 {
+			methodCache = cache
+		}
+		methods = append(methods, respMethod{
+			Name:      name,
+			Path:      path,
+			HasResult: method.Type.NumOut() == 2,
+		})
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "application/json")
 
+			requestID := r.Header.Get(RequestIDHeader)
+			if requestID == "" {
+				requestID = uuid.NewRandom().String()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+
 			// Always close the request body
 			defer r.Body.Close()
 
+			if inFlight != nil {
+				select {
+				case inFlight <- struct{}{}:
+					defer func() { <-inFlight }()
+				default:
+					w.Header().Set("Retry-After", inFlightRetryAfterSeconds)
+					errWriter.writeErrorWithStatus(w, errTooManyInFlight, http.StatusServiceUnavailable)
+					return
+				}
+			}
+
 			httpMethod := strings.ToUpper(r.Method)
 			if reqIn == nil && httpMethod != "GET" {
-				writeError(w, errRequestMustBeGet)
+				w.Header().Set("Allow", "GET")
+				errWriter.writeErrorWithStatus(w, errRequestMustBeGet, http.StatusMethodNotAllowed)
 				return
 			}
 			if reqIn != nil && httpMethod != "POST" {
-				writeError(w, errRequestMustBePost)
+				w.Header().Set("Allow", "POST")
+				errWriter.writeErrorWithStatus(w, errRequestMustBePost, http.StatusMethodNotAllowed)
+				return
+			}
+			if reqIn != nil && requireJSON && !isJSONContentType(r.Header.Get("Content-Type")) {
+				errWriter.writeErrorWithStatus(w, errUnsupportedContent, http.StatusUnsupportedMediaType)
 				return
 			}
 
@@ -137,24 +253,71 @@ func RegisterHandlers(mux *http.ServeMux, service interface{}, opts ServerOption
 					headers[key] = values[0]
 				}
 			}
+			headers[RequestIDHeader] = requestID
+
+			newCallContext := func() thrift.Context {
+				callContext, _ := thrift.NewContext(opts.RequestTimeout())
+				if contextFn != nil {
+					// Allow derivation of context if context fn is set
+					callContext = contextFn(callContext, method.Name, headers)
+				}
+				// Always set headers finally
+				return thrift.WithHeaders(callContext, headers)
+			}
+
+			var (
+				body     io.Reader = r.Body
+				cacheKey resultCacheKey
+			)
+			if methodCache != nil {
+				raw, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					errWriter.writeError(w, errInvalidRequestBody)
+					return
+				}
+				// Serve identical requests from the cache without calling
+				// the service method until the cached response expires
+				cacheKey = newResultCacheKey(name, raw)
+				if entry, ok := methodCache.get(cacheKey); ok {
+					// Still call the post response fn so that cache hits
+					// are observed the same as calls to the service method
+					if postResponseFn != nil {
+						postResponseFn(newCallContext(), method.Name, entry.response)
+					}
+					entry.write(w)
+					return
+				}
+				body = bytes.NewReader(raw)
+			}
 
 			var in interface{}
 			if reqIn != nil {
 				in = reflect.New(reqIn.Elem()).Interface()
-				if err := json.NewDecoder(r.Body).Decode(in); err != nil {
-					writeError(w, errInvalidRequestBody)
+				if snakeCase {
+					normalized, err := transformJSON(body, fromSnakeCase)
+					if err != nil {
+						errWriter.writeError(w, errInvalidRequestBody)
+						return
+					}
+					body = bytes.NewReader(normalized)
+				}
+				decoder := json.NewDecoder(body)
+				if strictDecoding {
+					decoder.DisallowUnknownFields()
+				}
+				if err := decoder.Decode(in); err != nil {
+					if strictDecoding {
+						// Include the decode error to hint at the offending field
+						errWriter.writeError(w, xerrors.NewInvalidParamsError(
+							fmt.Errorf("%s: %v", errInvalidRequestBody.Error(), err)))
+						return
+					}
+					errWriter.writeError(w, errInvalidRequestBody)
 					return
 				}
 			}
 
-			// Prepare the call context
-			callContext, _ := thrift.NewContext(opts.RequestTimeout())
-			if contextFn != nil {
-				// Allow derivation of context if context fn is set
-				callContext = contextFn(callContext, method.Name, headers)
-			}
-			// Always set headers finally
-			callContext = thrift.WithHeaders(callContext, headers)
+			callContext := newCallContext()
 
 			var (
 				svc = reflect.ValueOf(service)
@@ -177,43 +340,134 @@ func RegisterHandlers(mux *http.ServeMux, service interface{}, opts ServerOption
 
 				// Deal with error case
 				if !ret[0].IsNil() {
-					writeError(w, ret[0].Interface())
+					errWriter.writeError(w, ret[0].Interface())
 					return
 				}
-				json.NewEncoder(w).Encode(&respSuccess{})
+				var result interface{} = &respSuccess{}
+				if wrapSuccess {
+					result = &respSuccessResult{Data: result}
+				}
+				json.NewEncoder(w).Encode(result)
 				return
 			}
 
+			var response apachethrift.TStruct
+			if result, ok := ret[0].Interface().(apachethrift.TStruct); ok {
+				response = result
+			}
+
 			// Ensure we always call the post response fn if set
 			if postResponseFn != nil {
 				defer func() {
-					var response apachethrift.TStruct
-					if result, ok := ret[0].Interface().(apachethrift.TStruct); ok {
-						response = result
-					}
 					postResponseFn(callContext, method.Name, response)
 				}()
 			}
 
 			// Deal with error case
 			if !ret[1].IsNil() {
-				writeError(w, ret[1].Interface())
+				errWriter.writeError(w, ret[1].Interface())
 				return
 			}
 
+			result := ret[0].Interface()
+			var warnings []string
+			if partial, ok := result.(PartialResult); ok && !ret[0].IsNil() {
+				warnings = partial.Warnings()
+			}
+			if wrapSuccess {
+				result = &respSuccessResult{Data: result, Warnings: warnings}
+			} else {
+				for _, warning := range warnings {
+					w.Header().Add("Warning",
+						fmt.Sprintf("%s - %s", warningHeaderCode, strconv.Quote(warning)))
+				}
+			}
+
 			buff := bytes.NewBuffer(nil)
-			if err := json.NewEncoder(buff).Encode(ret[0].Interface()); err != nil {
-				writeError(w, errEncodeResponseBody)
+			if err := json.NewEncoder(buff).Encode(result); err != nil {
+				errWriter.writeError(w, errEncodeResponseBody)
 				return
 			}
 
-			w.Write(buff.Bytes())
+			data := buff.Bytes()
+			if snakeCase {
+				transformed, err := transformJSON(buff, toSnakeCase)
+				if err != nil {
+					errWriter.writeError(w, errEncodeResponseBody)
+					return
+				}
+				data = transformed
+			}
+
+			if methodCache != nil {
+				methodCache.set(cacheKey, data, w.Header()["Warning"], response)
+			}
+			w.Write(data)
 		})
 	}
+
+	if _, ok := registered[MethodsPath]; !ok {
+		registered[MethodsPath] = struct{}{}
+		mux.HandleFunc(MethodsPath, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if strings.ToUpper(r.Method) != "GET" {
+				w.Header().Set("Allow", "GET")
+				errWriter.writeErrorWithStatus(w, errRequestMustBeGet, http.StatusMethodNotAllowed)
+				return
+			}
+			var result interface{} = methods
+			if wrapSuccess {
+				result = &respSuccessResult{Data: result}
+			}
+			json.NewEncoder(w).Encode(result)
+		})
+	}
+
+	for path := range opts.ExtraHandlers() {
+		if _, ok := registered[path]; ok {
+			return fmt.Errorf("extra handler path %s conflicts with a service method", path)
+		}
+	}
+	for path, handler := range opts.ExtraHandlers() {
+		mux.HandleFunc(path, handler)
+	}
 	return nil
 }
 
-func writeError(w http.ResponseWriter, errValue interface{}) {
+// isJSONContentType returns whether the Content-Type header value is JSON,
+// ignoring any parameters such as the charset.
+func isJSONContentType(value string) bool {
+	mediaType, _, err := mime.ParseMediaType(value)
+	return err == nil && mediaType == jsonContentType
+}
+
+// errorWriter writes error responses, optionally redacting the error data
+// from the response and logging the full error when a logger is set.
+type errorWriter struct {
+	redactData bool
+	logger     xlog.Logger
+}
+
+func (e errorWriter) writeError(w http.ResponseWriter, errValue interface{}) {
+	status := http.StatusInternalServerError
+	if value, ok := errValue.(error); ok {
+		if xerrors.IsInvalidParams(value) {
+			status = http.StatusBadRequest
+		} else if isTimeoutError(value) {
+			status = http.StatusGatewayTimeout
+		}
+	}
+	e.writeErrorWithStatus(w, errValue, status)
+}
+
+// isTimeoutError returns whether the error is the result of the request
+// timeout elapsing before the service method returned.
+func isTimeoutError(err error) bool {
+	return err == context.DeadlineExceeded ||
+		tchannel.GetSystemErrorCode(err) == tchannel.ErrCodeTimeout
+}
+
+func (e errorWriter) writeErrorWithStatus(w http.ResponseWriter, errValue interface{}, status int) {
 	result := respErrorResult{respError{}}
 	if value, ok := errValue.(error); ok {
 		result.Error.Message = value.Error()
@@ -225,17 +479,32 @@ func writeError(w http.ResponseWriter, errValue interface{}) {
 	buff := bytes.NewBuffer(nil)
 	if err := json.NewEncoder(buff).Encode(&result); err != nil {
 		// Not a JSON returnable error
-		w.WriteHeader(http.StatusInternalServerError)
 		result.Error.Message = fmt.Sprintf("%v", errValue)
 		result.Error.Data = nil
+		e.log(http.StatusInternalServerError, result.Error.Message)
+		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(&result)
 		return
 	}
 
-	if value, ok := errValue.(error); ok && xerrors.IsInvalidParams(value) {
-		w.WriteHeader(http.StatusBadRequest)
-	} else {
-		w.WriteHeader(http.StatusInternalServerError)
+	e.log(status, strings.TrimSpace(buff.String()))
+	if e.redactData {
+		// Only the message is returned, the data may leak internal details
+		result.Error.Data = nil
+		buff.Reset()
+		json.NewEncoder(buff).Encode(&result)
 	}
+
+	w.WriteHeader(status)
 	w.Write(buff.Bytes())
 }
+
+func (e errorWriter) log(status int, errorValue string) {
+	if e.logger == nil {
+		return
+	}
+	e.logger.WithFields(
+		xlog.NewField("status", status),
+		xlog.NewField("error", errorValue),
+	).Error("request failed")
+}
@@ -0,0 +1,548 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package httpjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+
+	xlog "github.com/m3db/m3x/log"
+
+	apachethrift "github.com/apache/thrift/lib/go/thrift"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/tchannel-go/thrift"
+	"golang.org/x/net/context"
+)
+
+type testRequest struct {
+	Name string `json:"name"`
+}
+
+type testResult struct {
+	Greeting string `json:"greeting"`
+}
+
+type testService struct{}
+
+func (s *testService) Health(ctx thrift.Context) (*testResult, error) {
+	return &testResult{Greeting: "ok"}, nil
+}
+
+func (s *testService) Greet(ctx thrift.Context, req *testRequest) (*testResult, error) {
+	return &testResult{Greeting: "hello " + req.Name}, nil
+}
+
+func (s *testService) Ping(ctx thrift.Context) error {
+	return nil
+}
+
+func (s *testService) RequestID(ctx thrift.Context) (*testResult, error) {
+	return &testResult{Greeting: ctx.Headers()[RequestIDHeader]}, nil
+}
+
+func (s *testService) Slow(ctx thrift.Context) (*testResult, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Minute):
+		return &testResult{}, nil
+	}
+}
+
+type testBlockingService struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *testBlockingService) Block(ctx thrift.Context) (*testResult, error) {
+	s.started <- struct{}{}
+	<-s.release
+	return &testResult{}, nil
+}
+
+type testCamelRequest struct {
+	FirstName string `json:"firstName"`
+}
+
+type testCamelResult struct {
+	GreetingMessage string `json:"greetingMessage"`
+}
+
+type testCamelService struct{}
+
+func (s *testCamelService) Greet(ctx thrift.Context, req *testCamelRequest) (*testCamelResult, error) {
+	return &testCamelResult{GreetingMessage: "hello " + req.FirstName}, nil
+}
+
+type testPartialResult struct {
+	Values   []string `json:"values"`
+	warnings []string
+}
+
+func (r *testPartialResult) Warnings() []string {
+	return r.warnings
+}
+
+type testPartialService struct{}
+
+func (s *testPartialService) Fetch(ctx thrift.Context) (*testPartialResult, error) {
+	return &testPartialResult{
+		Values:   []string{"foo"},
+		warnings: []string{"shard 1 unavailable", "shard 2 unavailable"},
+	}, nil
+}
+
+func (s *testPartialService) FetchAll(ctx thrift.Context) (*testPartialResult, error) {
+	return &testPartialResult{Values: []string{"foo", "bar"}}, nil
+}
+
+type testCountingService struct {
+	calls map[string]int
+}
+
+func (s *testCountingService) Greet(ctx thrift.Context, req *testRequest) (*testResult, error) {
+	s.calls["greet"]++
+	return &testResult{Greeting: fmt.Sprintf("hello %s %d", req.Name, s.calls["greet"])}, nil
+}
+
+func (s *testCountingService) Wave(ctx thrift.Context, req *testRequest) (*testResult, error) {
+	s.calls["wave"]++
+	return &testResult{Greeting: fmt.Sprintf("wave %s %d", req.Name, s.calls["wave"])}, nil
+}
+
+type testInternalError struct {
+	Host string `json:"host"`
+}
+
+func (e *testInternalError) Error() string {
+	return "internal failure"
+}
+
+type testErrorService struct{}
+
+func (s *testErrorService) Fail(ctx thrift.Context) (*testResult, error) {
+	return nil, &testInternalError{Host: "10.0.0.1"}
+}
+
+func newTestMux(t *testing.T, opts ServerOptions) *http.ServeMux {
+	mux := http.NewServeMux()
+	require.NoError(t, RegisterHandlers(mux, &testService{}, opts))
+	return mux
+}
+
+func serveTestRequest(
+	mux *http.ServeMux,
+	method string,
+	path string,
+	body string,
+) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRegisterHandlersServiceMethod(t *testing.T) {
+	mux := newTestMux(t, NewServerOptions())
+
+	resp := serveTestRequest(mux, "POST", "/greet", `{"name":"foo"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var result testResult
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	require.Equal(t, "hello foo", result.Greeting)
+}
+
+func TestRegisterHandlersExtraHandlers(t *testing.T) {
+	opts := NewServerOptions().SetExtraHandlers(map[string]http.HandlerFunc{
+		"/admin": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("admin"))
+		},
+	})
+	mux := newTestMux(t, opts)
+
+	resp := serveTestRequest(mux, "GET", "/admin", "")
+	require.Equal(t, http.StatusAccepted, resp.Code)
+	require.Equal(t, "admin", resp.Body.String())
+
+	// Ensure service methods are still registered
+	resp = serveTestRequest(mux, "GET", "/health", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestRegisterHandlersExtraHandlersConflict(t *testing.T) {
+	opts := NewServerOptions().SetExtraHandlers(map[string]http.HandlerFunc{
+		"/health": func(w http.ResponseWriter, r *http.Request) {},
+	})
+	mux := http.NewServeMux()
+	require.Error(t, RegisterHandlers(mux, &testService{}, opts))
+}
+
+func TestRegisterHandlersWrapSuccess(t *testing.T) {
+	unwrapped := serveTestRequest(newTestMux(t, NewServerOptions()),
+		"POST", "/greet", `{"name":"foo"}`)
+	require.Equal(t, http.StatusOK, unwrapped.Code)
+	require.JSONEq(t, `{"greeting":"hello foo"}`, unwrapped.Body.String())
+
+	wrapped := serveTestRequest(newTestMux(t, NewServerOptions().SetWrapSuccess(true)),
+		"POST", "/greet", `{"name":"foo"}`)
+	require.Equal(t, http.StatusOK, wrapped.Code)
+	require.JSONEq(t, `{"data":{"greeting":"hello foo"}}`, wrapped.Body.String())
+}
+
+func TestRegisterHandlersRequestIDPropagated(t *testing.T) {
+	mux := newTestMux(t, NewServerOptions())
+
+	req := httptest.NewRequest("GET", "/requestid", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	resp := httptest.NewRecorder()
+	mux.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "abc-123", resp.Header().Get(RequestIDHeader))
+
+	var result testResult
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	require.Equal(t, "abc-123", result.Greeting)
+}
+
+func TestRegisterHandlersRequestIDGenerated(t *testing.T) {
+	mux := newTestMux(t, NewServerOptions())
+
+	resp := serveTestRequest(mux, "GET", "/requestid", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	requestID := resp.Header().Get(RequestIDHeader)
+	require.NotEmpty(t, requestID)
+
+	var result testResult
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	require.Equal(t, requestID, result.Greeting)
+}
+
+func TestRegisterHandlersMethodNotAllowed(t *testing.T) {
+	mux := newTestMux(t, NewServerOptions())
+
+	resp := serveTestRequest(mux, "GET", "/greet", "")
+	require.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+	require.Equal(t, "POST", resp.Header().Get("Allow"))
+
+	var result respErrorResult
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	require.Equal(t, errRequestMustBePost.Error(), result.Error.Message)
+
+	resp = serveTestRequest(mux, "POST", "/health", "")
+	require.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+	require.Equal(t, "GET", resp.Header().Get("Allow"))
+}
+
+func TestRegisterHandlersStrictDecoding(t *testing.T) {
+	body := `{"name":"foo","nmae":"bar"}`
+
+	resp := serveTestRequest(newTestMux(t, NewServerOptions()), "POST", "/greet", body)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = serveTestRequest(newTestMux(t, NewServerOptions().SetStrictDecoding(true)),
+		"POST", "/greet", body)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+
+	var result respErrorResult
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+	require.Contains(t, result.Error.Message, errInvalidRequestBody.Error())
+	require.Contains(t, result.Error.Message, "nmae")
+}
+
+func TestRegisterHandlersRequireJSONContentType(t *testing.T) {
+	serveWithContentType := func(mux *http.ServeMux, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/greet", strings.NewReader(`{"name":"foo"}`))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	// Lenient by default
+	mux := newTestMux(t, NewServerOptions())
+	resp := serveWithContentType(mux, "application/x-www-form-urlencoded")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	mux = newTestMux(t, NewServerOptions().SetRequireJSONContentType(true))
+	for _, contentType := range []string{"application/x-www-form-urlencoded", ""} {
+		resp = serveWithContentType(mux, contentType)
+		require.Equal(t, http.StatusUnsupportedMediaType, resp.Code)
+
+		var result respErrorResult
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+		require.Equal(t, errUnsupportedContent.Error(), result.Error.Message)
+	}
+
+	for _, contentType := range []string{"application/json", "application/json; charset=utf-8"} {
+		resp = serveWithContentType(mux, contentType)
+		require.Equal(t, http.StatusOK, resp.Code)
+	}
+
+	// Requests without a body are unaffected
+	resp = serveTestRequest(mux, "GET", "/health", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestRegisterHandlersRequestTimeout(t *testing.T) {
+	opts := NewServerOptions().SetRequestTimeout(10 * time.Millisecond)
+	resp := serveTestRequest(newTestMux(t, opts), "GET", "/slow", "")
+	require.Equal(t, http.StatusGatewayTimeout, resp.Code)
+}
+
+func TestRegisterHandlersMaxInFlight(t *testing.T) {
+	const maxInFlight = 2
+	svc := &testBlockingService{
+		started: make(chan struct{}, 16),
+		release: make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	opts := NewServerOptions().SetMaxInFlight(maxInFlight)
+	require.NoError(t, RegisterHandlers(mux, svc, opts))
+
+	var wg sync.WaitGroup
+	codes := make(chan int, maxInFlight)
+	for i := 0; i < maxInFlight; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- serveTestRequest(mux, "GET", "/block", "").Code
+		}()
+	}
+	for i := 0; i < maxInFlight; i++ {
+		<-svc.started
+	}
+
+	// Requests beyond the limit are rejected while the others are in flight
+	for i := 0; i < 5; i++ {
+		resp := serveTestRequest(mux, "GET", "/block", "")
+		require.Equal(t, http.StatusServiceUnavailable, resp.Code)
+		require.Equal(t, inFlightRetryAfterSeconds, resp.Header().Get("Retry-After"))
+	}
+
+	close(svc.release)
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		require.Equal(t, http.StatusOK, code)
+	}
+
+	// Requests are accepted again once the in flight requests complete
+	resp := serveTestRequest(mux, "GET", "/block", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestRegisterHandlersMethods(t *testing.T) {
+	mux := newTestMux(t, NewServerOptions())
+
+	resp := serveTestRequest(mux, "GET", MethodsPath, "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var methods []respMethod
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &methods))
+	require.Equal(t, []respMethod{
+		{Name: "greet", Path: "/greet", HasResult: true},
+		{Name: "health", Path: "/health", HasResult: true},
+		{Name: "ping", Path: "/ping", HasResult: false},
+		{Name: "requestid", Path: "/requestid", HasResult: true},
+		{Name: "slow", Path: "/slow", HasResult: true},
+	}, methods)
+
+	// Every listed method is mounted
+	for _, method := range methods {
+		_, pattern := mux.Handler(httptest.NewRequest("GET", method.Path, nil))
+		require.Equal(t, method.Path, pattern)
+	}
+
+	resp = serveTestRequest(mux, "POST", MethodsPath, "")
+	require.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+}
+
+func TestRegisterHandlersSnakeCaseFields(t *testing.T) {
+	mux := http.NewServeMux()
+	opts := NewServerOptions().SetSnakeCaseFields(true)
+	require.NoError(t, RegisterHandlers(mux, &testCamelService{}, opts))
+
+	// Requests are accepted with either snake_case or camelCase fields
+	for _, body := range []string{
+		`{"first_name":"foo"}`,
+		`{"firstName":"foo"}`,
+	} {
+		resp := serveTestRequest(mux, "POST", "/greet", body)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.JSONEq(t, `{"greeting_message":"hello foo"}`, resp.Body.String())
+	}
+
+	// Without the option the field names are left as is
+	mux = http.NewServeMux()
+	require.NoError(t, RegisterHandlers(mux, &testCamelService{}, NewServerOptions()))
+	resp := serveTestRequest(mux, "POST", "/greet", `{"firstName":"foo"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.JSONEq(t, `{"greetingMessage":"hello foo"}`, resp.Body.String())
+}
+
+func TestRegisterHandlersPartialResultWarnings(t *testing.T) {
+	mux := http.NewServeMux()
+	opts := NewServerOptions().SetWrapSuccess(true)
+	require.NoError(t, RegisterHandlers(mux, &testPartialService{}, opts))
+
+	resp := serveTestRequest(mux, "GET", "/fetch", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.JSONEq(t, `{
+		"data":{"values":["foo"]},
+		"warnings":["shard 1 unavailable","shard 2 unavailable"]
+	}`, resp.Body.String())
+
+	// The warnings field is omitted when there are no warnings
+	resp = serveTestRequest(mux, "GET", "/fetchall", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.JSONEq(t, `{"data":{"values":["foo","bar"]}}`, resp.Body.String())
+
+	// Unwrapped results carry the warnings as Warning headers
+	mux = http.NewServeMux()
+	require.NoError(t, RegisterHandlers(mux, &testPartialService{}, NewServerOptions()))
+	resp = serveTestRequest(mux, "GET", "/fetch", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.JSONEq(t, `{"values":["foo"]}`, resp.Body.String())
+	require.Equal(t, []string{
+		`199 - "shard 1 unavailable"`,
+		`199 - "shard 2 unavailable"`,
+	}, resp.Header()["Warning"])
+}
+
+func TestRegisterHandlersResultCache(t *testing.T) {
+	service := &testCountingService{calls: make(map[string]int)}
+	opts := NewServerOptions().
+		SetResultCacheSize(16).
+		SetResultCacheTTL(time.Minute).
+		SetCacheableMethods([]string{"Greet"})
+	mux := http.NewServeMux()
+	require.NoError(t, RegisterHandlers(mux, service, opts))
+
+	resp := serveTestRequest(mux, "POST", "/greet", `{"name":"foo"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.JSONEq(t, `{"greeting":"hello foo 1"}`, resp.Body.String())
+
+	// An identical request is served from the cache
+	resp = serveTestRequest(mux, "POST", "/greet", `{"name":"foo"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"greeting":"hello foo 1"}`, resp.Body.String())
+	require.Equal(t, 1, service.calls["greet"])
+
+	// A different request body calls the service method
+	resp = serveTestRequest(mux, "POST", "/greet", `{"name":"bar"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.JSONEq(t, `{"greeting":"hello bar 2"}`, resp.Body.String())
+	require.Equal(t, 2, service.calls["greet"])
+
+	// Methods that are not cacheable always call the service method
+	for i := 1; i <= 2; i++ {
+		resp = serveTestRequest(mux, "POST", "/wave", `{"name":"foo"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.JSONEq(t, fmt.Sprintf(`{"greeting":"wave foo %d"}`, i), resp.Body.String())
+	}
+	require.Equal(t, 2, service.calls["wave"])
+}
+
+func TestRegisterHandlersResultCacheUsesClockAndPostResponseFn(t *testing.T) {
+	var (
+		now       = time.Unix(1000, 0)
+		contexts  int
+		responses []string
+	)
+	service := &testCountingService{calls: make(map[string]int)}
+	opts := NewServerOptions().
+		SetResultCacheSize(16).
+		SetResultCacheTTL(time.Minute).
+		SetCacheableMethods([]string{"Greet"}).
+		SetClockOptions(clock.NewOptions().SetNowFn(func() time.Time {
+			return now
+		})).
+		SetContextFn(func(ctx context.Context, method string, headers map[string]string) thrift.Context {
+			contexts++
+			return thrift.Wrap(ctx)
+		}).
+		SetPostResponseFn(func(ctx context.Context, method string, response apachethrift.TStruct) {
+			responses = append(responses, method)
+		})
+	mux := http.NewServeMux()
+	require.NoError(t, RegisterHandlers(mux, service, opts))
+
+	// Cache hits still call the context fn and the post response fn
+	for i := 0; i < 2; i++ {
+		resp := serveTestRequest(mux, "POST", "/greet", `{"name":"foo"}`)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.JSONEq(t, `{"greeting":"hello foo 1"}`, resp.Body.String())
+	}
+	require.Equal(t, 1, service.calls["greet"])
+	require.Equal(t, 2, contexts)
+	require.Equal(t, []string{"Greet", "Greet"}, responses)
+
+	// The cached response expires by the options clock
+	now = now.Add(time.Minute)
+	resp := serveTestRequest(mux, "POST", "/greet", `{"name":"foo"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.JSONEq(t, `{"greeting":"hello foo 2"}`, resp.Body.String())
+	require.Equal(t, 2, service.calls["greet"])
+	require.Equal(t, 3, contexts)
+	require.Equal(t, []string{"Greet", "Greet", "Greet"}, responses)
+}
+
+func TestRegisterHandlersRedactErrorData(t *testing.T) {
+	mux := http.NewServeMux()
+	require.NoError(t, RegisterHandlers(mux, &testErrorService{}, NewServerOptions()))
+
+	resp := serveTestRequest(mux, "GET", "/fail", "")
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+	require.JSONEq(t,
+		`{"error":{"message":"internal failure","data":{"host":"10.0.0.1"}}}`,
+		resp.Body.String())
+
+	var logged bytes.Buffer
+	opts := NewServerOptions().
+		SetRedactErrorData(true).
+		SetLogger(xlog.NewLogger(&logged))
+	mux = http.NewServeMux()
+	require.NoError(t, RegisterHandlers(mux, &testErrorService{}, opts))
+
+	resp = serveTestRequest(mux, "GET", "/fail", "")
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+	require.JSONEq(t, `{"error":{"message":"internal failure"}}`, resp.Body.String())
+
+	// The logger still records the full error
+	require.Contains(t, logged.String(), "internal failure")
+	require.Contains(t, logged.String(), "10.0.0.1")
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package httpjson
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"golang.org/x/net/netutil"
+)
+
+// Listen creates a listener for the address on the network set in the server
+// options that accepts at most the maximum number of concurrent connections
+// set in the server options, further connections are queued until an existing
+// connection is closed. For the unix network the address is the path of the
+// socket, which is removed when the listener is closed.
+func Listen(address string, opts ServerOptions) (net.Listener, error) {
+	if err := ValidateAddress(address, opts); err != nil {
+		return nil, err
+	}
+	network := opts.Network()
+	if network == "unix" {
+		if err := removeStaleSocket(address); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	if maxConns := opts.MaxConcurrentConns(); maxConns > 0 {
+		listener = netutil.LimitListener(listener, maxConns)
+	}
+	return listener, nil
+}
+
+// ValidateAddress returns an error if the address is malformed for the network
+// set in the server options, a host and port for the tcp networks and a socket
+// path for the unix network. This allows a malformed address to be reported
+// when a server is created rather than once it starts listening.
+func ValidateAddress(address string, opts ServerOptions) error {
+	network := opts.Network()
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		_, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return fmt.Errorf("invalid listen address %q: %v", address, err)
+		}
+		if _, err := net.LookupPort(network, port); err != nil {
+			return fmt.Errorf("invalid listen address %q: %v", address, err)
+		}
+	case "unix":
+		if address == "" {
+			return fmt.Errorf("invalid listen address %q: empty socket path", address)
+		}
+	default:
+		return fmt.Errorf("unsupported listen network: %s", network)
+	}
+	return nil
+}
+
+// removeStaleSocket removes a socket file left behind at the path by a
+// process that did not close its listener, sockets still being listened on
+// and any other files are left in place so that listening fails rather than
+// clobbering them.
+func removeStaleSocket(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return nil
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		// The socket is in use by a listener that is still open
+		return conn.Close()
+	}
+	return os.Remove(path)
+}
+
+// NewHTTPServer creates a HTTP server for the handler configured with the
+// timeouts set in the server options.
+func NewHTTPServer(handler http.Handler, opts ServerOptions) *http.Server {
+	return &http.Server{
+		Handler:      handler,
+		ReadTimeout:  opts.ReadTimeout(),
+		WriteTimeout: opts.WriteTimeout(),
+		IdleTimeout:  opts.IdleTimeout(),
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package httpjson
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenMaxConcurrentConnsQueuesExcessConns(t *testing.T) {
+	listener, err := Listen("127.0.0.1:0", NewServerOptions().SetMaxConcurrentConns(1))
+	require.NoError(t, err)
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	first, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer first.Close()
+
+	second, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer second.Close()
+
+	firstAccepted := <-accepted
+
+	// Ensure the second connection is not accepted while the first is open
+	select {
+	case <-accepted:
+		require.FailNow(t, "accepted connection beyond the limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Ensure the second connection is accepted once the first is closed
+	require.NoError(t, firstAccepted.Close())
+	select {
+	case conn := <-accepted:
+		require.NoError(t, conn.Close())
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "queued connection was not accepted")
+	}
+}
+
+func TestNewHTTPServerIdleTimeout(t *testing.T) {
+	opts := NewServerOptions().SetIdleTimeout(time.Minute)
+	server := NewHTTPServer(nil, opts)
+	require.Equal(t, time.Minute, server.IdleTimeout)
+	require.Equal(t, opts.ReadTimeout(), server.ReadTimeout)
+	require.Equal(t, opts.WriteTimeout(), server.WriteTimeout)
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpjson")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "admin.sock")
+	opts := NewServerOptions().SetNetwork("unix")
+	listener, err := Listen(path, opts)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	require.NoError(t, RegisterHandlers(mux, &testService{}, opts))
+	server := NewHTTPServer(mux, opts)
+	go server.Serve(listener)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+	resp, err := client.Get("http://unix/health")
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.JSONEq(t, `{"greeting":"ok"}`, string(body))
+
+	// Closing the listener removes the socket file
+	require.NoError(t, listener.Close())
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestListenUnixSocketRemovesStaleSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpjson")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Leave a socket file behind as a crashed process would
+	path := filepath.Join(dir, "admin.sock")
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	require.NoError(t, err)
+	stale.SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	opts := NewServerOptions().SetNetwork("unix")
+	listener, err := Listen(path, opts)
+	require.NoError(t, err)
+
+	// A socket still being listened on is not removed
+	_, err = Listen(path, opts)
+	require.Error(t, err)
+	require.NoError(t, listener.Close())
+}
+
+func TestListenUnsupportedNetwork(t *testing.T) {
+	_, err := Listen("127.0.0.1:0", NewServerOptions().SetNetwork("udp"))
+	require.Error(t, err)
+}
+
+func TestValidateAddress(t *testing.T) {
+	opts := NewServerOptions()
+	for _, address := range []string{"127.0.0.1:9000", ":9000", "localhost:0", "[::1]:9000"} {
+		require.NoError(t, ValidateAddress(address, opts), address)
+	}
+
+	for _, address := range []string{"", "127.0.0.1", "127.0.0.1:abc", "127.0.0.1:70000", "::1:9000"} {
+		require.Error(t, ValidateAddress(address, opts), address)
+
+		// Listening on a malformed address fails the same way
+		_, err := Listen(address, opts)
+		require.Equal(t, ValidateAddress(address, opts), err, address)
+	}
+
+	unixOpts := opts.SetNetwork("unix")
+	require.NoError(t, ValidateAddress("/tmp/m3db.sock", unixOpts))
+	require.Error(t, ValidateAddress("", unixOpts))
+
+	require.Error(t, ValidateAddress("127.0.0.1:9000", opts.SetNetwork("udp")))
+}
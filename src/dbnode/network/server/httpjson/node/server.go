@@ -21,7 +21,6 @@
 package node
 
 import (
-	"net"
 	"net/http"
 
 	ns "github.com/m3db/m3/src/dbnode/network/server"
@@ -64,22 +63,36 @@ func NewServer(
 	}
 }
 
+// NewServerValidated creates a node HTTP network service, returning an error
+// if the address is malformed for the network set in the server options
+func NewServerValidated(
+	db storage.Database,
+	address string,
+	contextPool context.Pool,
+	opts httpjson.ServerOptions,
+	ttopts tchannelthrift.Options,
+) (ns.NetworkService, error) {
+	if opts == nil {
+		opts = httpjson.NewServerOptions()
+	}
+	if err := httpjson.ValidateAddress(address, opts); err != nil {
+		return nil, err
+	}
+	return NewServer(db, address, contextPool, opts, ttopts), nil
+}
+
 func (s *server) ListenAndServe() (ns.Close, error) {
 	mux := http.NewServeMux()
 	if err := httpjson.RegisterHandlers(mux, ttnode.NewService(s.db, s.ttopts), s.opts); err != nil {
 		return nil, err
 	}
 
-	listener, err := net.Listen("tcp", s.address)
+	listener, err := httpjson.Listen(s.address, s.opts)
 	if err != nil {
 		return nil, err
 	}
 
-	server := http.Server{
-		Handler:      mux,
-		ReadTimeout:  s.opts.ReadTimeout(),
-		WriteTimeout: s.opts.WriteTimeout(),
-	}
+	server := httpjson.NewHTTPServer(mux, s.opts)
 
 	go func() {
 		server.Serve(listener)
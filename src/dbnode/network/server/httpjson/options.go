@@ -21,8 +21,13 @@
 package httpjson
 
 import (
+	"net/http"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/clock"
+
+	xlog "github.com/m3db/m3x/log"
+
 	apachethrift "github.com/apache/thrift/lib/go/thrift"
 	"github.com/uber/tchannel-go/thrift"
 	"golang.org/x/net/context"
@@ -32,6 +37,12 @@ const (
 	defaultReadTimeout    = 10 * time.Second
 	defaultWriteTimeout   = 10 * time.Second
 	defaultRequestTimeout = 60 * time.Second
+	defaultIdleTimeout    = 0
+	defaultMaxConns       = 0
+	defaultMaxInFlight    = 0
+	defaultCacheSize      = 0
+	defaultCacheTTL       = time.Second
+	defaultNetwork        = "tcp"
 )
 
 // ContextFn is a function that sets the context for all service
@@ -61,6 +72,27 @@ type ServerOptions interface {
 	// RequestTimeout returns the request timeout
 	RequestTimeout() time.Duration
 
+	// SetIdleTimeout sets the keep-alive idle timeout and returns a new ServerOptions
+	SetIdleTimeout(value time.Duration) ServerOptions
+
+	// IdleTimeout returns the keep-alive idle timeout
+	IdleTimeout() time.Duration
+
+	// SetMaxConcurrentConns sets the maximum number of concurrent connections,
+	// zero for no limit, and returns a new ServerOptions
+	SetMaxConcurrentConns(value int) ServerOptions
+
+	// MaxConcurrentConns returns the maximum number of concurrent connections
+	MaxConcurrentConns() int
+
+	// SetMaxInFlight sets the maximum number of service method requests
+	// executing concurrently, zero for no limit, and returns a new ServerOptions
+	SetMaxInFlight(value int) ServerOptions
+
+	// MaxInFlight returns the maximum number of service method requests
+	// executing concurrently
+	MaxInFlight() int
+
 	// SetContextFn sets the context fn and returns a new ServerOptions
 	SetContextFn(value ContextFn) ServerOptions
 
@@ -72,14 +104,121 @@ type ServerOptions interface {
 
 	// PostResponseFn returns the post response fn
 	PostResponseFn() PostResponseFn
+
+	// SetExtraHandlers sets additional handlers keyed by path to register
+	// alongside the service method handlers and returns a new ServerOptions
+	SetExtraHandlers(value map[string]http.HandlerFunc) ServerOptions
+
+	// ExtraHandlers returns the additional handlers keyed by path
+	ExtraHandlers() map[string]http.HandlerFunc
+
+	// SetWrapSuccess sets whether successful results are wrapped in a data
+	// envelope, symmetric to the error envelope, and returns a new ServerOptions
+	SetWrapSuccess(value bool) ServerOptions
+
+	// WrapSuccess returns whether successful results are wrapped in a data envelope
+	WrapSuccess() bool
+
+	// SetStrictDecoding sets whether request bodies with fields unknown to the
+	// request type are rejected and returns a new ServerOptions
+	SetStrictDecoding(value bool) ServerOptions
+
+	// StrictDecoding returns whether request bodies are strictly decoded
+	StrictDecoding() bool
+
+	// SetSnakeCaseFields sets whether the field names of service method results
+	// are converted to snake_case, request bodies are accepted with either
+	// snake_case or camelCase field names, and returns a new ServerOptions
+	SetSnakeCaseFields(value bool) ServerOptions
+
+	// SnakeCaseFields returns whether result field names are converted to snake_case
+	SnakeCaseFields() bool
+
+	// SetRequireJSONContentType sets whether requests with a body must have a
+	// Content-Type of application/json and returns a new ServerOptions
+	SetRequireJSONContentType(value bool) ServerOptions
+
+	// RequireJSONContentType returns whether requests with a body must have a
+	// Content-Type of application/json
+	RequireJSONContentType() bool
+
+	// SetResultCacheSize sets the maximum number of responses of cacheable
+	// methods to cache, zero to disable the cache, and returns a new ServerOptions
+	SetResultCacheSize(value int) ServerOptions
+
+	// ResultCacheSize returns the maximum number of responses of cacheable
+	// methods to cache
+	ResultCacheSize() int
+
+	// SetResultCacheTTL sets how long cached responses are served for
+	// and returns a new ServerOptions
+	SetResultCacheTTL(value time.Duration) ServerOptions
+
+	// ResultCacheTTL returns how long cached responses are served for
+	ResultCacheTTL() time.Duration
+
+	// SetCacheableMethods sets the names of the read only service methods whose
+	// successful responses are cached, keyed by the request body, and returns
+	// a new ServerOptions. Requests served from the cache skip the service
+	// method but still call the context fn and the post response fn, the
+	// latter with the cached result
+	SetCacheableMethods(value []string) ServerOptions
+
+	// CacheableMethods returns the names of the read only service methods
+	// whose successful responses are cached
+	CacheableMethods() []string
+
+	// SetRedactErrorData sets whether the data of errors is omitted from error
+	// responses, leaving only the message, and returns a new ServerOptions
+	SetRedactErrorData(value bool) ServerOptions
+
+	// RedactErrorData returns whether the data of errors is omitted from
+	// error responses
+	RedactErrorData() bool
+
+	// SetLogger sets the logger that records the full error of failed
+	// requests, nil to not log them, and returns a new ServerOptions
+	SetLogger(value xlog.Logger) ServerOptions
+
+	// Logger returns the logger that records the full error of failed requests
+	Logger() xlog.Logger
+
+	// SetClockOptions sets the clock options and returns a new ServerOptions
+	SetClockOptions(value clock.Options) ServerOptions
+
+	// ClockOptions returns the clock options
+	ClockOptions() clock.Options
+
+	// SetNetwork sets the network to listen on, either tcp or unix in which
+	// case the listen address is the path of a unix domain socket, and returns
+	// a new ServerOptions
+	SetNetwork(value string) ServerOptions
+
+	// Network returns the network to listen on
+	Network() string
 }
 
 type serverOptions struct {
 	readTimeout    time.Duration
 	writeTimeout   time.Duration
 	requestTimeout time.Duration
+	idleTimeout    time.Duration
+	maxConns       int
+	maxInFlight    int
 	contextFn      ContextFn
 	postResponseFn PostResponseFn
+	extraHandlers  map[string]http.HandlerFunc
+	wrapSuccess    bool
+	strictDecoding bool
+	snakeCase      bool
+	requireJSON    bool
+	cacheSize      int
+	cacheTTL       time.Duration
+	cacheMethods   []string
+	redactErrData  bool
+	logger         xlog.Logger
+	clockOpts      clock.Options
+	network        string
 }
 
 // NewServerOptions creates a new set of server options with defaults
@@ -88,6 +227,13 @@ func NewServerOptions() ServerOptions {
 		readTimeout:    defaultReadTimeout,
 		writeTimeout:   defaultWriteTimeout,
 		requestTimeout: defaultRequestTimeout,
+		idleTimeout:    defaultIdleTimeout,
+		maxConns:       defaultMaxConns,
+		maxInFlight:    defaultMaxInFlight,
+		cacheSize:      defaultCacheSize,
+		cacheTTL:       defaultCacheTTL,
+		clockOpts:      clock.NewOptions(),
+		network:        defaultNetwork,
 	}
 }
 
@@ -121,6 +267,36 @@ func (o *serverOptions) RequestTimeout() time.Duration {
 	return o.requestTimeout
 }
 
+func (o *serverOptions) SetIdleTimeout(value time.Duration) ServerOptions {
+	opts := *o
+	opts.idleTimeout = value
+	return &opts
+}
+
+func (o *serverOptions) IdleTimeout() time.Duration {
+	return o.idleTimeout
+}
+
+func (o *serverOptions) SetMaxConcurrentConns(value int) ServerOptions {
+	opts := *o
+	opts.maxConns = value
+	return &opts
+}
+
+func (o *serverOptions) MaxConcurrentConns() int {
+	return o.maxConns
+}
+
+func (o *serverOptions) SetMaxInFlight(value int) ServerOptions {
+	opts := *o
+	opts.maxInFlight = value
+	return &opts
+}
+
+func (o *serverOptions) MaxInFlight() int {
+	return o.maxInFlight
+}
+
 func (o *serverOptions) SetContextFn(value ContextFn) ServerOptions {
 	opts := *o
 	opts.contextFn = value
@@ -140,3 +316,123 @@ func (o *serverOptions) SetPostResponseFn(value PostResponseFn) ServerOptions {
 func (o *serverOptions) PostResponseFn() PostResponseFn {
 	return o.postResponseFn
 }
+
+func (o *serverOptions) SetExtraHandlers(value map[string]http.HandlerFunc) ServerOptions {
+	opts := *o
+	opts.extraHandlers = value
+	return &opts
+}
+
+func (o *serverOptions) ExtraHandlers() map[string]http.HandlerFunc {
+	return o.extraHandlers
+}
+
+func (o *serverOptions) SetWrapSuccess(value bool) ServerOptions {
+	opts := *o
+	opts.wrapSuccess = value
+	return &opts
+}
+
+func (o *serverOptions) WrapSuccess() bool {
+	return o.wrapSuccess
+}
+
+func (o *serverOptions) SetStrictDecoding(value bool) ServerOptions {
+	opts := *o
+	opts.strictDecoding = value
+	return &opts
+}
+
+func (o *serverOptions) StrictDecoding() bool {
+	return o.strictDecoding
+}
+
+func (o *serverOptions) SetSnakeCaseFields(value bool) ServerOptions {
+	opts := *o
+	opts.snakeCase = value
+	return &opts
+}
+
+func (o *serverOptions) SnakeCaseFields() bool {
+	return o.snakeCase
+}
+
+func (o *serverOptions) SetRequireJSONContentType(value bool) ServerOptions {
+	opts := *o
+	opts.requireJSON = value
+	return &opts
+}
+
+func (o *serverOptions) RequireJSONContentType() bool {
+	return o.requireJSON
+}
+
+func (o *serverOptions) SetResultCacheSize(value int) ServerOptions {
+	opts := *o
+	opts.cacheSize = value
+	return &opts
+}
+
+func (o *serverOptions) ResultCacheSize() int {
+	return o.cacheSize
+}
+
+func (o *serverOptions) SetResultCacheTTL(value time.Duration) ServerOptions {
+	opts := *o
+	opts.cacheTTL = value
+	return &opts
+}
+
+func (o *serverOptions) ResultCacheTTL() time.Duration {
+	return o.cacheTTL
+}
+
+func (o *serverOptions) SetCacheableMethods(value []string) ServerOptions {
+	opts := *o
+	opts.cacheMethods = value
+	return &opts
+}
+
+func (o *serverOptions) CacheableMethods() []string {
+	return o.cacheMethods
+}
+
+func (o *serverOptions) SetRedactErrorData(value bool) ServerOptions {
+	opts := *o
+	opts.redactErrData = value
+	return &opts
+}
+
+func (o *serverOptions) RedactErrorData() bool {
+	return o.redactErrData
+}
+
+func (o *serverOptions) SetLogger(value xlog.Logger) ServerOptions {
+	opts := *o
+	opts.logger = value
+	return &opts
+}
+
+func (o *serverOptions) Logger() xlog.Logger {
+	return o.logger
+}
+
+func (o *serverOptions) SetClockOptions(value clock.Options) ServerOptions {
+	opts := *o
+	opts.clockOpts = value
+	return &opts
+}
+
+func (o *serverOptions) ClockOptions() clock.Options {
+	return o.clockOpts
+}
+
+func (o *serverOptions) SetNetwork(value string) ServerOptions {
+	opts := *o
+	opts.network = value
+	return &opts
+}
+
+func (o *serverOptions) Network() string {
+	return o.network
+}
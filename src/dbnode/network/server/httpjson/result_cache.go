@@ -0,0 +1,128 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package httpjson
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+
+	apachethrift "github.com/apache/thrift/lib/go/thrift"
+)
+
+type resultCacheKey struct {
+	method string
+	body   [sha256.Size]byte
+}
+
+func newResultCacheKey(method string, body []byte) resultCacheKey {
+	return resultCacheKey{method: method, body: sha256.Sum256(body)}
+}
+
+// resultCacheEntry is an encoded successful response, the Warning
+// headers that accompanied it and the result passed to the post
+// response fn.
+type resultCacheEntry struct {
+	key       resultCacheKey
+	data      []byte
+	warnings  []string
+	response  apachethrift.TStruct
+	expiresAt time.Time
+}
+
+func (e *resultCacheEntry) write(w http.ResponseWriter) {
+	for _, warning := range e.warnings {
+		w.Header().Add("Warning", warning)
+	}
+	w.Write(e.data)
+}
+
+// resultCache is a size bounded LRU cache of encoded responses that
+// expire a fixed TTL after they are cached.
+type resultCache struct {
+	sync.Mutex
+
+	size    int
+	ttl     time.Duration
+	nowFn   clock.NowFn
+	entries map[resultCacheKey]*list.Element
+	lru     *list.List
+}
+
+func newResultCache(size int, ttl time.Duration, nowFn clock.NowFn) *resultCache {
+	return &resultCache{
+		size:    size,
+		ttl:     ttl,
+		nowFn:   nowFn,
+		entries: make(map[resultCacheKey]*list.Element, size),
+		lru:     list.New(),
+	}
+}
+
+func (c *resultCache) get(key resultCacheKey) (*resultCacheEntry, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*resultCacheEntry)
+	if !c.nowFn().Before(entry.expiresAt) {
+		c.removeWithLock(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry, true
+}
+
+func (c *resultCache) set(
+	key resultCacheKey,
+	data []byte,
+	warnings []string,
+	response apachethrift.TStruct,
+) {
+	c.Lock()
+	defer c.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeWithLock(elem)
+	}
+	for c.lru.Len() >= c.size {
+		c.removeWithLock(c.lru.Back())
+	}
+	c.entries[key] = c.lru.PushFront(&resultCacheEntry{
+		key:       key,
+		data:      data,
+		warnings:  warnings,
+		response:  response,
+		expiresAt: c.nowFn().Add(c.ttl),
+	})
+}
+
+func (c *resultCache) removeWithLock(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*resultCacheEntry)
+	delete(c.entries, entry.key)
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package httpjson
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newResultCache(2, time.Minute, time.Now)
+
+	foo := newResultCacheKey("fetch", []byte("foo"))
+	bar := newResultCacheKey("fetch", []byte("bar"))
+	baz := newResultCacheKey("fetch", []byte("baz"))
+	cache.set(foo, []byte("1"), nil, nil)
+	cache.set(bar, []byte("2"), nil, nil)
+
+	// Touch foo so that bar is the least recently used
+	_, ok := cache.get(foo)
+	require.True(t, ok)
+
+	cache.set(baz, []byte("3"), nil, nil)
+	_, ok = cache.get(bar)
+	require.False(t, ok)
+	_, ok = cache.get(foo)
+	require.True(t, ok)
+	_, ok = cache.get(baz)
+	require.True(t, ok)
+}
+
+func TestResultCacheExpires(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cache := newResultCache(2, time.Minute, func() time.Time { return now })
+
+	key := newResultCacheKey("fetch", []byte("foo"))
+	cache.set(key, []byte("1"), []string{`199 - "partial"`}, nil)
+
+	now = now.Add(time.Minute - time.Nanosecond)
+	entry, ok := cache.get(key)
+	require.True(t, ok)
+
+	recorder := httptest.NewRecorder()
+	entry.write(recorder)
+	require.Equal(t, "1", recorder.Body.String())
+	require.Equal(t, []string{`199 - "partial"`}, recorder.Header()["Warning"])
+
+	now = now.Add(time.Nanosecond)
+	_, ok = cache.get(key)
+	require.False(t, ok)
+	require.Equal(t, 0, cache.lru.Len())
+}
+
+func TestResultCacheKeyIncludesMethod(t *testing.T) {
+	require.Equal(t,
+		newResultCacheKey("fetch", []byte("foo")),
+		newResultCacheKey("fetch", []byte("foo")))
+	require.NotEqual(t,
+		newResultCacheKey("fetch", []byte("foo")),
+		newResultCacheKey("fetchall", []byte("foo")))
+	require.NotEqual(t,
+		newResultCacheKey("fetch", []byte("foo")),
+		newResultCacheKey("fetch", []byte("bar")))
+}
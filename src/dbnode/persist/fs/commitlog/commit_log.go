@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3x/context"
 	xlog "github.com/m3db/m3x/log"
@@ -320,7 +321,7 @@ func (l *commitLog) openWriter(now time.Time) error {
 	}
 
 	blockSize := l.opts.BlockSize()
-	start := now.Truncate(blockSize)
+	start := retention.BlockStartForBlockSize(blockSize, now)
 
 	if err := l.writer.Open(start, blockSize); err != nil {
 		return err
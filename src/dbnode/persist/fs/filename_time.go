@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// humanReadableFilenameTimeLayout is the layout of block start times in
+	// file names written with the human readable file name time format, the
+	// date and time are separated by a 'T' so that the time can never be
+	// mistaken for a unix nanoseconds timestamp.
+	humanReadableFilenameTimeLayout = "20060102T150405"
+
+	humanReadableFilenameTimeSeparator = "T"
+)
+
+var (
+	errFilenameTimeFormatUnspecified = errors.New("filename time format unspecified")
+)
+
+// FilenameTimeFormat is the format used to encode block start times in the
+// names of data file set files.
+type FilenameTimeFormat uint
+
+const (
+	// UnixNanosFilenameTimeFormat encodes block start times as the number of
+	// nanoseconds elapsed since the unix epoch.
+	UnixNanosFilenameTimeFormat FilenameTimeFormat = iota
+	// HumanReadableFilenameTimeFormat encodes block start times in UTC as
+	// YYYYMMDDTHHMMSS, block start times are truncated to second precision.
+	HumanReadableFilenameTimeFormat
+
+	// DefaultFilenameTimeFormat is the default filename time format.
+	DefaultFilenameTimeFormat = UnixNanosFilenameTimeFormat
+)
+
+// ValidFilenameTimeFormats returns the valid filename time formats.
+func ValidFilenameTimeFormats() []FilenameTimeFormat {
+	return []FilenameTimeFormat{UnixNanosFilenameTimeFormat, HumanReadableFilenameTimeFormat}
+}
+
+func (f FilenameTimeFormat) String() string {
+	switch f {
+	case UnixNanosFilenameTimeFormat:
+		return "unix_nanos"
+	case HumanReadableFilenameTimeFormat:
+		return "human_readable"
+	}
+	return "unknown"
+}
+
+// ValidateFilenameTimeFormat validates a filename time format.
+func ValidateFilenameTimeFormat(v FilenameTimeFormat) error {
+	for _, valid := range ValidFilenameTimeFormats() {
+		if valid == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid FilenameTimeFormat '%d' valid types are: %v",
+		uint(v), ValidFilenameTimeFormats())
+}
+
+// ParseFilenameTimeFormat parses a FilenameTimeFormat from a string.
+func ParseFilenameTimeFormat(str string) (FilenameTimeFormat, error) {
+	var r FilenameTimeFormat
+	if str == "" {
+		return r, errFilenameTimeFormatUnspecified
+	}
+	for _, valid := range ValidFilenameTimeFormats() {
+		if str == valid.String() {
+			r = valid
+			return r, nil
+		}
+	}
+	return r, fmt.Errorf("invalid FilenameTimeFormat '%s' valid types are: %v",
+		str, ValidFilenameTimeFormats())
+}
+
+// UnmarshalYAML unmarshals a FilenameTimeFormat into a valid type from string.
+func (f *FilenameTimeFormat) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	r, err := ParseFilenameTimeFormat(str)
+	if err != nil {
+		return err
+	}
+	*f = r
+	return nil
+}
+
+func (f FilenameTimeFormat) formatTime(t time.Time) string {
+	switch f {
+	case HumanReadableFilenameTimeFormat:
+		return t.UTC().Format(humanReadableFilenameTimeLayout)
+	default:
+		return strconv.FormatInt(t.UnixNano(), 10)
+	}
+}
+
+// parseFilenameTime parses a block start time encoded in a file name with
+// any of the valid filename time formats.
+func parseFilenameTime(str string) (time.Time, error) {
+	if strings.Contains(str, humanReadableFilenameTimeSeparator) {
+		t, err := time.ParseInLocation(humanReadableFilenameTimeLayout, str, time.UTC)
+		if err != nil {
+			return timeZero, err
+		}
+		// Return the time in the local location for parity with the unix
+		// nanoseconds format
+		return time.Unix(0, t.UnixNano()), nil
+	}
+	nanoseconds, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return timeZero, err
+	}
+	return time.Unix(0, nanoseconds), nil
+}
+
+// filenameTimeFormatFromPath returns the filename time format used to encode the
+// block start time in the name of the given file set file.
+func filenameTimeFormatFromPath(filePath string) FilenameTimeFormat {
+	components := strings.Split(filepath.Base(filePath), separator)
+	if len(components) > 1 && strings.Contains(components[1], humanReadableFilenameTimeSeparator) {
+		return HumanReadableFilenameTimeFormat
+	}
+	return UnixNanosFilenameTimeFormat
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilenameTimeFormatRoundTrip(t *testing.T) {
+	blockStart := time.Date(2018, time.October, 16, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		format   FilenameTimeFormat
+		expected string
+	}{
+		{UnixNanosFilenameTimeFormat, "fileset-1539693000000000000-data.db"},
+		{HumanReadableFilenameTimeFormat, "fileset-20181016T123000-data.db"},
+	}
+	for _, test := range tests {
+		fname := filesetFileForTimeAndFormat(blockStart, test.format, dataFileSuffix)
+		require.Equal(t, test.expected, fname)
+		require.Equal(t, test.format, filenameTimeFormatFromPath(fname))
+
+		parsed, err := TimeFromFileName(fname)
+		require.NoError(t, err)
+		require.True(t, blockStart.Equal(parsed))
+	}
+}
+
+func TestFilenameTimeFormatSortsAcrossFormats(t *testing.T) {
+	var (
+		first  = time.Date(2018, time.October, 16, 10, 0, 0, 0, time.UTC)
+		second = first.Add(2 * time.Hour)
+		files  = []string{
+			filesetFileForTimeAndFormat(second, UnixNanosFilenameTimeFormat, dataFileSuffix),
+			filesetFileForTimeAndFormat(first, HumanReadableFilenameTimeFormat, dataFileSuffix),
+		}
+		expected = []string{files[1], files[0]}
+	)
+	sort.Sort(byTimeAscending(files))
+	require.Equal(t, expected, files)
+
+	before, err := FilesBefore(files, second)
+	require.NoError(t, err)
+	require.Equal(t, expected[:1], before)
+}
+
+func TestParseFilenameTimeFormat(t *testing.T) {
+	for _, format := range ValidFilenameTimeFormats() {
+		parsed, err := ParseFilenameTimeFormat(format.String())
+		require.NoError(t, err)
+		require.Equal(t, format, parsed)
+		require.NoError(t, ValidateFilenameTimeFormat(parsed))
+	}
+
+	_, err := ParseFilenameTimeFormat("unknown")
+	require.Error(t, err)
+	require.Error(t, ValidateFilenameTimeFormat(FilenameTimeFormat(100)))
+}
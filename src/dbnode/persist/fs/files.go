@@ -52,6 +52,8 @@ const (
 
 	commitLogComponentPosition    = 2
 	indexFileSetComponentPosition = 2
+
+	dataFileSetComponentsWithoutVolumeIndex = 3
 )
 
 var (
@@ -119,7 +121,7 @@ func (f FileSetFilesSlice) Filepaths() []string {
 }
 
 // LatestVolumeForBlock returns the latest (highest index) FileSetFile in the
-// slice for a given block start, only applicable for index and snapshot file set files.
+// slice for a given block start.
 func (f FileSetFilesSlice) LatestVolumeForBlock(blockStart time.Time) (FileSetFile, bool) {
 	// Make sure we're already sorted
 	f.sortByTimeAndVolumeIndexAscending()
@@ -285,17 +287,33 @@ func (a fileSetFilesByTimeAndVolumeIndexAscending) Less(i, j int) bool {
 	return ti.Equal(tj) && ii < ij
 }
 
+// dataFileSetFilesByTimeAndVolumeIndexAscending sorts data file sets files by their block
+// start times and volume index in ascending order, files without a volume index in their
+// names are treated as volume zero.
+type dataFileSetFilesByTimeAndVolumeIndexAscending []string
+
+func (a dataFileSetFilesByTimeAndVolumeIndexAscending) Len() int      { return len(a) }
+func (a dataFileSetFilesByTimeAndVolumeIndexAscending) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a dataFileSetFilesByTimeAndVolumeIndexAscending) Less(i, j int) bool {
+	ti, ii, _ := TimeAndVolumeIndexFromDataFileSetFilename(a[i])
+	tj, ij, _ := TimeAndVolumeIndexFromDataFileSetFilename(a[j])
+	if ti.Before(tj) {
+		return true
+	}
+	return ti.Equal(tj) && ii < ij
+}
+
 func componentsAndTimeFromFileName(fname string) ([]string, time.Time, error) {
 	components := strings.Split(filepath.Base(fname), separator)
 	if len(components) < 3 {
 		return nil, timeZero, fmt.Errorf("unexpected file name %s", fname)
 	}
 	str := strings.Replace(components[1], fileSuffix, "", 1)
-	nanoseconds, err := strconv.ParseInt(str, 10, 64)
+	t, err := parseFilenameTime(str)
 	if err != nil {
 		return nil, timeZero, err
 	}
-	return components, time.Unix(0, nanoseconds), nil
+	return components, t, nil
 }
 
 // TimeFromFileName extracts the block start time from file name.
@@ -314,6 +332,20 @@ func TimeAndVolumeIndexFromFileSetFilename(fname string) (time.Time, int, error)
 	return timeAndIndexFromFileName(fname, indexFileSetComponentPosition)
 }
 
+// TimeAndVolumeIndexFromDataFileSetFilename extracts the block start and volume index from
+// the file name of a data file set file, volume zero data file set files have no volume
+// index in their names.
+func TimeAndVolumeIndexFromDataFileSetFilename(fname string) (time.Time, int, error) {
+	components, t, err := componentsAndTimeFromFileName(fname)
+	if err != nil {
+		return timeZero, 0, err
+	}
+	if len(components) == dataFileSetComponentsWithoutVolumeIndex {
+		return t, 0, nil
+	}
+	return timeAndIndexFromFileName(fname, indexFileSetComponentPosition)
+}
+
 func timeAndIndexFromFileName(fname string, componentPosition int) (time.Time, int, error) {
 	components, t, err := componentsAndTimeFromFileName(fname)
 	if err != nil {
@@ -352,12 +384,16 @@ func snapshotTime(
 }
 
 func readSnapshotInfoFile(filePathPrefix string, id FileSetFileIdentifier, readerBufferSize int) ([]byte, error) {
-	var (
-		shardDir           = ShardSnapshotsDirPath(filePathPrefix, id.Namespace, id.Shard)
-		checkpointFilePath = filesetPathFromTimeAndIndex(shardDir, id.BlockStart, id.VolumeIndex, checkpointFileSuffix)
+	shardDir := ShardSnapshotsDirPath(filePathPrefix, id.Namespace, id.Shard)
+	pathFn := func(format FilenameTimeFormat, suffix string) string {
+		return filesetPathFromTimeIndexAndFormat(shardDir, id.BlockStart, id.VolumeIndex, format, suffix)
+	}
 
-		digestFilePath = filesetPathFromTimeAndIndex(shardDir, id.BlockStart, id.VolumeIndex, digestFileSuffix)
-		infoFilePath   = filesetPathFromTimeAndIndex(shardDir, id.BlockStart, id.VolumeIndex, infoFileSuffix)
+	var (
+		format             = filenameTimeFormatOf(pathFn)
+		checkpointFilePath = pathFn(format, checkpointFileSuffix)
+		digestFilePath     = pathFn(format, digestFileSuffix)
+		infoFilePath       = pathFn(format, infoFileSuffix)
 	)
 
 	checkpointFd, err := os.Open(checkpointFilePath)
@@ -416,58 +452,20 @@ func forEachInfoFile(
 		return
 	}
 
-	var dir string
-	switch args.fileSetType {
-	case persist.FileSetFlushType:
-		switch args.contentType {
-		case persist.FileSetDataContentType:
-			dir = ShardDataDirPath(args.filePathPrefix, args.namespace, args.shard)
-		case persist.FileSetIndexContentType:
-			dir = NamespaceIndexDataDirPath(args.filePathPrefix, args.namespace)
-		default:
-			return
-		}
-	case persist.FileSetSnapshotType:
-		switch args.contentType {
-		case persist.FileSetDataContentType:
-			dir = ShardSnapshotsDirPath(args.filePathPrefix, args.namespace, args.shard)
-		case persist.FileSetIndexContentType:
-			dir = NamespaceIndexSnapshotDirPath(args.filePathPrefix, args.namespace)
-		default:
-			return
-		}
-	default:
-		return
-	}
-
 	var indexDigests index.IndexDigests
 	digestBuf := digest.NewBuffer()
 	for i := range matched {
-		t := matched[i].ID.BlockStart
-		volume := matched[i].ID.VolumeIndex
+		if len(matched[i].AbsoluteFilepaths) != 1 {
+			continue
+		}
 
+		// Derive the checkpoint and digest file paths from the matched info file
+		// so that they are found regardless of the filename time format used
 		var (
-			checkpointFilePath string
-			digestsFilePath    string
-			infoFilePath       string
+			infoFilePath       = matched[i].AbsoluteFilepaths[0]
+			checkpointFilePath = filesetSiblingFilePath(infoFilePath, infoFileSuffix, checkpointFileSuffix)
+			digestsFilePath    = filesetSiblingFilePath(infoFilePath, infoFileSuffix, digestFileSuffix)
 		)
-		switch args.fileSetType {
-		case persist.FileSetFlushType:
-			switch args.contentType {
-			case persist.FileSetDataContentType:
-				checkpointFilePath = filesetPathFromTime(dir, t, checkpointFileSuffix)
-				digestsFilePath = filesetPathFromTime(dir, t, digestFileSuffix)
-				infoFilePath = filesetPathFromTime(dir, t, infoFileSuffix)
-			case persist.FileSetIndexContentType:
-				checkpointFilePath = filesetPathFromTimeAndIndex(dir, t, volume, checkpointFileSuffix)
-				digestsFilePath = filesetPathFromTimeAndIndex(dir, t, volume, digestFileSuffix)
-				infoFilePath = filesetPathFromTimeAndIndex(dir, t, volume, infoFileSuffix)
-			}
-		case persist.FileSetSnapshotType:
-			checkpointFilePath = filesetPathFromTimeAndIndex(dir, t, volume, checkpointFileSuffix)
-			digestsFilePath = filesetPathFromTimeAndIndex(dir, t, volume, digestFileSuffix)
-			infoFilePath = filesetPathFromTimeAndIndex(dir, t, volume, infoFileSuffix)
-		}
 		if !FileExists(checkpointFilePath) {
 			continue
 		}
@@ -506,16 +504,14 @@ func forEachInfoFile(
 		if err != nil {
 			continue
 		}
-		if len(matched[i].AbsoluteFilepaths) != 1 {
-			continue
-		}
 
-		fn(matched[i].AbsoluteFilepaths[0], matched[i].ID, infoData)
+		fn(infoFilePath, matched[i].ID, infoData)
 	}
 }
 
 // ReadInfoFileResult is the result of reading an info file
 type ReadInfoFileResult struct {
+	ID   FileSetFileIdentifier
 	Info schema.IndexInfo
 	Err  ReadInfoFileResultError
 }
@@ -543,7 +539,8 @@ func (r readInfoFileResultError) Filepath() string {
 }
 
 // ReadInfoFiles reads all the valid info entries. Even if ReadInfoFiles returns an error,
-// there may be some valid entries in the returned slice.
+// there may be some valid entries in the returned slice. Only the latest volume of
+// each block is read since a block flushed again supersedes its earlier volumes.
 func ReadInfoFiles(
 	filePathPrefix string,
 	namespace ident.ID,
@@ -565,13 +562,21 @@ func ReadInfoFiles(
 		func(filepath string, id FileSetFileIdentifier, data []byte) {
 			decoder.Reset(msgpack.NewDecoderStream(data))
 			info, err := decoder.DecodeIndexInfo()
-			infoFileResults = append(infoFileResults, ReadInfoFileResult{
+			result := ReadInfoFileResult{
+				ID:   id,
 				Info: info,
 				Err: readInfoFileResultError{
 					err:      err,
 					filepath: filepath,
 				},
-			})
+			}
+			// Volumes are visited in ascending order for each block
+			last := len(infoFileResults) - 1
+			if last >= 0 && infoFileResults[last].ID.BlockStart.Equal(id.BlockStart) {
+				infoFileResults[last] = result
+				return
+			}
+			infoFileResults = append(infoFileResults, result)
 		})
 	return infoFileResults
 }
@@ -639,41 +644,22 @@ func IndexSnapshotFiles(filePathPrefix string, namespace ident.ID) (FileSetFiles
 	})
 }
 
-// FileSetAt returns a FileSetFile for the given namespace/shard/blockStart combination if it exists.
+// FileSetAt returns the latest complete volume of the FileSetFile for the given
+// namespace/shard/blockStart combination if it exists.
 func FileSetAt(filePathPrefix string, namespace ident.ID, shard uint32, blockStart time.Time) (FileSetFile, bool, error) {
-	matched, err := filesetFiles(filesetFilesSelector{
+	matched, err := dataFileSetFilesAt(filesetFilesSelector{
 		fileSetType:    persist.FileSetFlushType,
 		contentType:    persist.FileSetDataContentType,
 		filePathPrefix: filePathPrefix,
 		namespace:      namespace,
 		shard:          shard,
-		pattern:        filesetFileForTime(blockStart, anyLowerCaseCharsPattern),
-	})
+	}, blockStart, anyLowerCaseCharsNumbersPattern)
 	if err != nil {
 		return FileSetFile{}, false, err
 	}
 
-	matched.sortByTimeAscending()
-	for i, fileset := range matched {
-		if fileset.ID.BlockStart.Equal(blockStart) {
-			nextIdx := i + 1
-			if nextIdx < len(matched) && matched[nextIdx].ID.BlockStart.Equal(blockStart) {
-				// Should never happen
-				return FileSetFile{}, false, fmt.Errorf(
-					"found multiple fileset files for blockStart: %d",
-					blockStart.Unix(),
-				)
-			}
-
-			if !fileset.HasCheckpointFile() {
-				continue
-			}
-
-			return fileset, true, nil
-		}
-	}
-
-	return FileSetFile{}, false, nil
+	fileset, ok := matched.LatestVolumeForBlock(blockStart)
+	return fileset, ok, nil
 }
 
 // IndexFileSetsAt returns all FileSetFile(s) for the given namespace/blockStart combination.
@@ -733,6 +719,43 @@ func DataFileSetsBefore(filePathPrefix string, namespace ident.ID, shard uint32,
 	return FilesBefore(matched.Filepaths(), t)
 }
 
+// DataFileSetsSuperseded returns all the flush data fileset files of volumes earlier
+// than the latest complete volume of their block, a block flushed again is written to
+// a new volume that supersedes its earlier volumes.
+func DataFileSetsSuperseded(filePathPrefix string, namespace ident.ID, shard uint32) ([]string, error) {
+	matched, err := filesetFiles(filesetFilesSelector{
+		fileSetType:    persist.FileSetFlushType,
+		contentType:    persist.FileSetDataContentType,
+		filePathPrefix: filePathPrefix,
+		namespace:      namespace,
+		shard:          shard,
+		pattern:        filesetFilePattern,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	latestVolumes := make(map[int64]int, len(matched))
+	for _, fileset := range matched {
+		if !fileset.HasCheckpointFile() {
+			continue
+		}
+		blockStart := fileset.ID.BlockStart.UnixNano()
+		if volume, ok := latestVolumes[blockStart]; !ok || fileset.ID.VolumeIndex > volume {
+			latestVolumes[blockStart] = fileset.ID.VolumeIndex
+		}
+	}
+
+	var superseded []string
+	for _, fileset := range matched {
+		volume, ok := latestVolumes[fileset.ID.BlockStart.UnixNano()]
+		if ok && fileset.ID.VolumeIndex < volume {
+			superseded = append(superseded, fileset.AbsoluteFilepaths...)
+		}
+	}
+	return superseded, nil
+}
+
 // IndexFileSetsBefore returns all the flush index fileset files whose timestamps are earlier than a given time.
 func IndexFileSetsBefore(filePathPrefix string, namespace ident.ID, t time.Time) ([]string, error) {
 	matched, err := filesetFiles(filesetFilesSelector{
@@ -778,6 +801,25 @@ func SortedCommitLogFiles(commitLogsDir string) ([]string, error) {
 
 type toSortableFn func(files []string) sort.Interface
 
+// dataFileSetFilesAt returns the data file set files for the given block start
+// matching the suffix pattern, written with any of the valid filename time formats.
+func dataFileSetFilesAt(
+	args filesetFilesSelector,
+	blockStart time.Time,
+	suffixPattern string,
+) (FileSetFilesSlice, error) {
+	var result FileSetFilesSlice
+	for _, format := range ValidFilenameTimeFormats() {
+		args.pattern = filesetFileForTimeAndFormat(blockStart, format, suffixPattern)
+		matched, err := filesetFiles(args)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, matched...)
+	}
+	return result, nil
+}
+
 func findFiles(fileDir string, pattern string, fn toSortableFn) ([]string, error) {
 	matched, err := filepath.Glob(path.Join(fileDir, pattern))
 	if err != nil {
@@ -832,7 +874,7 @@ func filesetFiles(args filesetFilesSelector) (FileSetFilesSlice, error) {
 		case persist.FileSetDataContentType:
 			dir := ShardDataDirPath(args.filePathPrefix, args.namespace, args.shard)
 			byTimeAsc, err = findFiles(dir, args.pattern, func(files []string) sort.Interface {
-				return byTimeAscending(files)
+				return dataFileSetFilesByTimeAndVolumeIndexAscending(files)
 			})
 		case persist.FileSetIndexContentType:
 			dir := NamespaceIndexDataDirPath(args.filePathPrefix, args.namespace)
@@ -882,7 +924,7 @@ func filesetFiles(args filesetFilesSelector) (FileSetFilesSlice, error) {
 		case persist.FileSetFlushType:
 			switch args.contentType {
 			case persist.FileSetDataContentType:
-				currentFileBlockStart, err = TimeFromFileName(file)
+				currentFileBlockStart, volumeIndex, err = TimeAndVolumeIndexFromDataFileSetFilename(file)
 			case persist.FileSetIndexContentType:
 				currentFileBlockStart, volumeIndex, err = TimeAndVolumeIndexFromFileSetFilename(file)
 			default:
@@ -1037,6 +1079,26 @@ func DataFileSetExistsAt(prefix string, namespace ident.ID, shard uint32, blockS
 	return ok, nil
 }
 
+// dataFileSetVolumeExistsAt determines whether a complete data fileset volume exists
+// for the given namespace, shard, block start and volume index.
+func dataFileSetVolumeExistsAt(
+	prefix string,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	volumeIndex int,
+) bool {
+	shardDir := ShardDataDirPath(prefix, namespace, shard)
+	for _, format := range ValidFilenameTimeFormats() {
+		checkpointFilePath := dataFileSetPathFromTimeIndexAndFormat(shardDir, blockStart,
+			volumeIndex, format, checkpointFileSuffix)
+		if FileExists(checkpointFilePath) {
+			return true
+		}
+	}
+	return false
+}
+
 // SnapshotFileSetExistsAt determines whether snapshot fileset files exist for the given namespace, shard, and block start time.
 func SnapshotFileSetExistsAt(prefix string, namespace ident.ID, shard uint32, blockStart time.Time) (bool, error) {
 	snapshotFiles, err := SnapshotFiles(prefix, namespace, shard)
@@ -1080,6 +1142,29 @@ func NextSnapshotFileSetVolumeIndex(filePathPrefix string, namespace ident.ID, s
 	return latestFile.ID.VolumeIndex + 1, nil
 }
 
+// NextDataFileSetVolumeIndex returns the next data file set volume index for a given
+// namespace/shard/blockStart combination, so that re-flushing a block writes a new
+// volume rather than overwriting a volume that may be being read.
+func NextDataFileSetVolumeIndex(filePathPrefix string, namespace ident.ID, shard uint32, blockStart time.Time) (int, error) {
+	files, err := dataFileSetFilesAt(filesetFilesSelector{
+		fileSetType:    persist.FileSetFlushType,
+		contentType:    persist.FileSetDataContentType,
+		filePathPrefix: filePathPrefix,
+		namespace:      namespace,
+		shard:          shard,
+	}, blockStart, anyLowerCaseCharsNumbersPattern)
+	if err != nil {
+		return -1, err
+	}
+
+	latestFile, ok := files.LatestVolumeForBlock(blockStart)
+	if !ok {
+		return 0, nil
+	}
+
+	return latestFile.ID.VolumeIndex + 1, nil
+}
+
 // NextIndexFileSetVolumeIndex returns the next index file set index for a given
 // namespace/blockStart combination.
 func NextIndexFileSetVolumeIndex(filePathPrefix string, namespace ident.ID, blockStart time.Time) (int, error) {
@@ -1133,15 +1218,75 @@ func OpenWritable(filePath string, perm os.FileMode) (*os.File, error) {
 }
 
 func filesetFileForTime(t time.Time, suffix string) string {
-	return fmt.Sprintf("%s%s%d%s%s%s", filesetFilePrefix, separator, t.UnixNano(), separator, suffix, fileSuffix)
+	return filesetFileForTimeAndFormat(t, DefaultFilenameTimeFormat, suffix)
+}
+
+func filesetFileForTimeAndFormat(t time.Time, format FilenameTimeFormat, suffix string) string {
+	return fmt.Sprintf("%s%s%s%s%s%s", filesetFilePrefix, separator, format.formatTime(t), separator, suffix, fileSuffix)
 }
 
 func filesetPathFromTime(prefix string, t time.Time, suffix string) string {
-	return path.Join(prefix, filesetFileForTime(t, suffix))
+	return filesetPathFromTimeAndFormat(prefix, t, DefaultFilenameTimeFormat, suffix)
+}
+
+func filesetPathFromTimeAndFormat(prefix string, t time.Time, format FilenameTimeFormat, suffix string) string {
+	return path.Join(prefix, filesetFileForTimeAndFormat(t, format, suffix))
 }
 
 func filesetPathFromTimeAndIndex(prefix string, t time.Time, index int, suffix string) string {
-	return path.Join(prefix, filesetFileForTime(t, fmt.Sprintf("%d%s%s", index, separator, suffix)))
+	return filesetPathFromTimeIndexAndFormat(prefix, t, index, DefaultFilenameTimeFormat, suffix)
+}
+
+func filesetPathFromTimeIndexAndFormat(
+	prefix string,
+	t time.Time,
+	index int,
+	format FilenameTimeFormat,
+	suffix string,
+) string {
+	return path.Join(prefix, filesetFileForTimeAndFormat(t, format, fmt.Sprintf("%d%s%s", index, separator, suffix)))
+}
+
+// dataFileSetPathFromTimeAndIndex returns the path of a data file set file, volume
+// zero omits the volume index from the file name to remain compatible with data
+// file sets written before volumes were introduced.
+func dataFileSetPathFromTimeAndIndex(prefix string, t time.Time, index int, suffix string) string {
+	return dataFileSetPathFromTimeIndexAndFormat(prefix, t, index, DefaultFilenameTimeFormat, suffix)
+}
+
+func dataFileSetPathFromTimeIndexAndFormat(
+	prefix string,
+	t time.Time,
+	index int,
+	format FilenameTimeFormat,
+	suffix string,
+) string {
+	if index == 0 {
+		return filesetPathFromTimeAndFormat(prefix, t, format, suffix)
+	}
+	return filesetPathFromTimeIndexAndFormat(prefix, t, index, format, suffix)
+}
+
+// filesetSiblingFilePath returns the path of the file belonging to the same
+// file set as the given file set file path with the given suffix.
+func filesetSiblingFilePath(filePath string, suffix string, siblingSuffix string) string {
+	return strings.TrimSuffix(filePath, suffix+fileSuffix) + siblingSuffix + fileSuffix
+}
+
+// filesetPathFn returns the path of the file with the given suffix of a file set
+// when written with the given filename time format.
+type filesetPathFn func(format FilenameTimeFormat, suffix string) string
+
+// filenameTimeFormatOf returns the filename time format a file set was written
+// with based on which of its checkpoint files exists, the default filename time
+// format is returned if the file set does not exist.
+func filenameTimeFormatOf(pathFn filesetPathFn) FilenameTimeFormat {
+	for _, format := range ValidFilenameTimeFormats() {
+		if FileExists(pathFn(format, checkpointFileSuffix)) {
+			return format
+		}
+	}
+	return DefaultFilenameTimeFormat
 }
 
 func filesetIndexSegmentFileSuffixFromTime(
@@ -108,6 +108,30 @@ func (mr *MockDataFileSetWriterMockRecorder) WriteAll(arg0, arg1, arg2, arg3 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteAll", reflect.TypeOf((*MockDataFileSetWriter)(nil).WriteAll), arg0, arg1, arg2, arg3)
 }
 
+// WriteAllWithTimestamps mocks base method
+func (m *MockDataFileSetWriter) WriteAllWithTimestamps(arg0 ident.ID, arg1 ident.Tags, arg2 []checked.Bytes, arg3 uint32, arg4 time.Time, arg5 time.Time) error {
+	ret := m.ctrl.Call(m, "WriteAllWithTimestamps", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteAllWithTimestamps indicates an expected call of WriteAllWithTimestamps
+func (mr *MockDataFileSetWriterMockRecorder) WriteAllWithTimestamps(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteAllWithTimestamps", reflect.TypeOf((*MockDataFileSetWriter)(nil).WriteAllWithTimestamps), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// Delete mocks base method
+func (m *MockDataFileSetWriter) Delete(arg0 ident.ID) error {
+	ret := m.ctrl.Call(m, "Delete", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockDataFileSetWriterMockRecorder) Delete(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockDataFileSetWriter)(nil).Delete), arg0)
+}
+
 // MockDataFileSetReader is a mock of DataFileSetReader interface
 type MockDataFileSetReader struct {
 	ctrl     *gomock.Controller
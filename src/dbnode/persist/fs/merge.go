@@ -0,0 +1,191 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"sort"
+
+	"github.com/m3db/m3/src/dbnode/persist/schema"
+	"github.com/m3db/m3x/checked"
+	"github.com/m3db/m3x/ident"
+)
+
+var (
+	errMergeUnsupportedReader = errors.New("merge source must be a data fileset reader created by NewReader")
+	errMergeReaderNotOpen     = errors.New("merge source reader is not open")
+	errMergeEntryOutOfBounds  = errors.New("merge source index entry exceeds data file size")
+)
+
+// MergeBlocks merges the entries of the open source readers and writes the
+// combined entries to the open destination writer. The sources are merged
+// by ID in a k-way merge, when the same ID exists in more than one source
+// the entry from the source that appears later in srcs wins, if that entry is
+// a tombstone the series is dropped from the result. Data is read
+// directly from the memory mapped data files of the sources so no source is
+// loaded in full. The caller is responsible for opening and closing both
+// the sources and the destination.
+func MergeBlocks(dst DataFileSetWriter, srcs ...DataFileSetReader) error {
+	cursors := make(mergeCursorHeap, 0, len(srcs))
+	for i, src := range srcs {
+		r, ok := src.(*reader)
+		if !ok {
+			return errMergeUnsupportedReader
+		}
+		if !r.open {
+			return errMergeReaderNotOpen
+		}
+
+		// NB: Sort a copy since the reader relies on its entries remaining
+		// in offset order for sequential reads. Tombstones are merged too so
+		// that a deletion in a later source drops the series entirely.
+		entries := make([]schema.IndexEntry, 0, len(r.indexEntriesByOffsetAsc)+len(r.tombstones))
+		entries = append(entries, r.indexEntriesByOffsetAsc...)
+		entries = append(entries, r.tombstones...)
+		sort.Sort(indexEntriesByIDAsc(entries))
+		if len(entries) > 0 {
+			cursors = append(cursors, &mergeCursor{
+				srcIdx:  i,
+				reader:  r,
+				entries: entries,
+			})
+		}
+	}
+	heap.Init(&cursors)
+
+	var popped []*mergeCursor
+	for cursors.Len() > 0 {
+		// Pop every cursor positioned at the lowest ID, the cursor of the
+		// latest source wins since ties are ordered by source.
+		popped = append(popped[:0], heap.Pop(&cursors).(*mergeCursor))
+		id := popped[0].entry().ID
+		for cursors.Len() > 0 && bytes.Equal(cursors[0].entry().ID, id) {
+			popped = append(popped, heap.Pop(&cursors).(*mergeCursor))
+		}
+
+		winner := popped[len(popped)-1]
+		if entry := winner.entry(); !entry.Deleted {
+			if err := mergeWriteEntry(dst, winner.reader, entry); err != nil {
+				return err
+			}
+		}
+
+		for _, cursor := range popped {
+			cursor.idx++
+			if cursor.idx < len(cursor.entries) {
+				heap.Push(&cursors, cursor)
+			}
+		}
+	}
+
+	return nil
+}
+
+func mergeWriteEntry(
+	dst DataFileSetWriter,
+	r *reader,
+	entry schema.IndexEntry,
+) error {
+	end := entry.Offset + entry.Size
+	if entry.Offset < 0 || end > int64(len(r.dataMmap)) {
+		return errMergeEntryOutOfBounds
+	}
+
+	// NB: The writer holds onto IDs and tags until it is closed, copy them
+	// so that the sources may be closed independently of the writer.
+	id := ident.BytesID(append([]byte(nil), entry.ID...))
+	tags, err := mergeDecodeTags(r, entry.EncodedTags)
+	if err != nil {
+		return err
+	}
+
+	data := checked.NewBytes(r.dataMmap[entry.Offset:end], nil)
+	data.IncRef()
+	defer data.DecRef()
+
+	return dst.Write(id, tags, data, uint32(entry.Checksum))
+}
+
+func mergeDecodeTags(r *reader, encodedTags []byte) (ident.Tags, error) {
+	if len(encodedTags) == 0 {
+		return ident.Tags{}, nil
+	}
+
+	encoded := checked.NewBytes(encodedTags, nil)
+	encoded.IncRef()
+	defer encoded.DecRef()
+
+	decoder := r.tagDecoderPool.Get()
+	defer decoder.Close()
+	decoder.Reset(encoded)
+
+	var tags ident.Tags
+	for decoder.Next() {
+		curr := decoder.Current()
+		tags.Append(ident.Tag{
+			Name:  ident.BytesID(append([]byte(nil), curr.Name.Bytes()...)),
+			Value: ident.BytesID(append([]byte(nil), curr.Value.Bytes()...)),
+		})
+	}
+	return tags, decoder.Err()
+}
+
+type mergeCursor struct {
+	srcIdx  int
+	reader  *reader
+	entries []schema.IndexEntry
+	idx     int
+}
+
+func (c *mergeCursor) entry() schema.IndexEntry {
+	return c.entries[c.idx]
+}
+
+type mergeCursorHeap []*mergeCursor
+
+func (h mergeCursorHeap) Len() int      { return len(h) }
+func (h mergeCursorHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h mergeCursorHeap) Less(i, j int) bool {
+	if cmp := bytes.Compare(h[i].entry().ID, h[j].entry().ID); cmp != 0 {
+		return cmp < 0
+	}
+	return h[i].srcIdx < h[j].srcIdx
+}
+
+func (h *mergeCursorHeap) Push(x interface{}) {
+	*h = append(*h, x.(*mergeCursor))
+}
+
+func (h *mergeCursorHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+type indexEntriesByIDAsc []schema.IndexEntry
+
+func (e indexEntriesByIDAsc) Len() int           { return len(e) }
+func (e indexEntriesByIDAsc) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e indexEntriesByIDAsc) Less(i, j int) bool { return bytes.Compare(e[i].ID, e[j].ID) < 0 }
@@ -0,0 +1,174 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func openTestMergeReader(t *testing.T, filePathPrefix string, volume int) DataFileSetReader {
+	r := newTestReader(t, filePathPrefix)
+	require.NoError(t, r.Open(DataReaderOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:   testNs1ID,
+			Shard:       0,
+			BlockStart:  testWriterStart,
+			VolumeIndex: volume,
+		},
+		FileSetType: persist.FileSetFlushType,
+	}))
+	return r
+}
+
+func TestMergeBlocks(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	older := []testEntry{
+		{"foo", map[string]string{"a": "1"}, []byte{1, 2, 3}},
+		{"bar", map[string]string{"b": "2"}, []byte{4, 5, 6}},
+		{"baz", nil, []byte{7, 8}},
+	}
+	newer := []testEntry{
+		{"qux", nil, []byte{9}},
+		{"bar", map[string]string{"b": "3"}, []byte{10, 11}},
+	}
+
+	w := newTestWriter(t, dir)
+	writeTestDataWithVolume(t, w, 0, testWriterStart, 0, older, persist.FileSetFlushType)
+	writeTestDataWithVolume(t, w, 0, testWriterStart, 1, newer, persist.FileSetFlushType)
+
+	srcs := []DataFileSetReader{
+		openTestMergeReader(t, dir, 0),
+		openTestMergeReader(t, dir, 1),
+	}
+	require.NoError(t, w.Open(DataWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:   testNs1ID,
+			Shard:       0,
+			BlockStart:  testWriterStart,
+			VolumeIndex: 2,
+		},
+		BlockSize:   testBlockSize,
+		FileSetType: persist.FileSetFlushType,
+	}))
+	require.NoError(t, MergeBlocks(w, srcs...))
+	for _, src := range srcs {
+		require.NoError(t, src.Close())
+	}
+	require.NoError(t, w.Close())
+
+	// Disjoint keys are all kept and the later source wins for overlapping keys
+	expected := []testEntry{newer[1], older[2], older[0], newer[0]}
+
+	r := openTestMergeReader(t, dir, 2)
+	defer r.Close()
+	require.Equal(t, len(expected), r.Entries())
+	for _, entry := range expected {
+		id, tags, data, checksum, err := r.Read()
+		require.NoError(t, err)
+		data.IncRef()
+
+		require.Equal(t, entry.id, id.String())
+		tagMatcher := ident.NewTagIterMatcher(ident.NewTagsIterator(entry.Tags()))
+		require.True(t, tagMatcher.Matches(tags))
+		require.Equal(t, entry.data, data.Bytes())
+		require.Equal(t, digest.Checksum(entry.data), checksum)
+
+		id.Finalize()
+		tags.Close()
+		data.DecRef()
+		data.Finalize()
+	}
+	_, _, _, _, err := r.Read()
+	require.Equal(t, io.EOF, err)
+	require.NoError(t, r.Validate())
+}
+
+func TestMergeBlocksUnsupportedReader(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	w := newTestWriter(t, dir)
+	require.Equal(t, errMergeReaderNotOpen, MergeBlocks(w, newTestReader(t, dir)))
+	require.Equal(t, errMergeUnsupportedReader, MergeBlocks(w, nil))
+}
+
+func TestMergeBlocksDropsTombstonedSeries(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	older := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+	}
+
+	w := newTestWriter(t, dir)
+	writeTestDataWithVolume(t, w, 0, testWriterStart, 0, older, persist.FileSetFlushType)
+
+	newerOpts := DataWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:   testNs1ID,
+			Shard:       0,
+			BlockStart:  testWriterStart,
+			VolumeIndex: 1,
+		},
+		BlockSize:   testBlockSize,
+		FileSetType: persist.FileSetFlushType,
+	}
+	require.NoError(t, w.Open(newerOpts))
+	require.NoError(t, w.Delete(ident.StringID("foo")))
+	require.NoError(t, w.Close())
+
+	srcs := []DataFileSetReader{
+		openTestMergeReader(t, dir, 0),
+		openTestMergeReader(t, dir, 1),
+	}
+	mergedOpts := newerOpts
+	mergedOpts.Identifier.VolumeIndex = 2
+	require.NoError(t, w.Open(mergedOpts))
+	require.NoError(t, MergeBlocks(w, srcs...))
+	for _, src := range srcs {
+		require.NoError(t, src.Close())
+	}
+	require.NoError(t, w.Close())
+
+	r := openTestMergeReader(t, dir, 2)
+	defer r.Close()
+	require.Equal(t, 1, r.Entries())
+	id, tags, data, _, err := r.Read()
+	require.NoError(t, err)
+	data.IncRef()
+	require.Equal(t, "bar", id.String())
+	require.Equal(t, older[1].data, data.Bytes())
+	id.Finalize()
+	tags.Close()
+	data.DecRef()
+	data.Finalize()
+}
@@ -252,6 +252,14 @@ func (dec *Decoder) decodeIndexInfo() schema.IndexInfo {
 	indexInfo.SnapshotTime = dec.decodeVarint()
 	indexInfo.FileType = persist.FileSetType(dec.decodeVarint())
 
+	if actual < 10 {
+		dec.skip(numFieldsToSkip)
+		return indexInfo
+	}
+
+	indexInfo.MinTimestamp = dec.decodeVarint()
+	indexInfo.MaxTimestamp = dec.decodeVarint()
+
 	dec.skip(numFieldsToSkip)
 	return indexInfo
 }
@@ -312,6 +320,13 @@ func (dec *Decoder) decodeIndexEntry() schema.IndexEntry {
 
 	indexEntry.EncodedTags, _, _ = dec.decodeBytes()
 
+	if actual < 7 {
+		dec.skip(numFieldsToSkip)
+		return indexEntry
+	}
+
+	indexEntry.Deleted = dec.decodeBool()
+
 	dec.skip(numFieldsToSkip)
 	return indexEntry
 }
@@ -512,6 +527,15 @@ func (dec *Decoder) decodeFloat64() float64 {
 	return value
 }
 
+func (dec *Decoder) decodeBool() bool {
+	if dec.err != nil {
+		return false
+	}
+	value, err := dec.dec.DecodeBool()
+	dec.err = err
+	return value
+}
+
 func (dec *Decoder) decodeBytes() ([]byte, int, int) {
 	if dec.err != nil {
 		return nil, -1, -1
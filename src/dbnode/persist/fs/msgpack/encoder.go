@@ -34,6 +34,7 @@ type encodeVarintFn func(value int64)
 type encodeVarUintFn func(value uint64)
 type encodeFloat64Fn func(value float64)
 type encodeBytesFn func(value []byte)
+type encodeBoolFn func(value bool)
 type encodeArrayLenFn func(value int)
 
 // Encoder encodes data in msgpack format for persistence
@@ -48,6 +49,7 @@ type Encoder struct {
 	encodeVarUintFn            encodeVarUintFn
 	encodeFloat64Fn            encodeFloat64Fn
 	encodeBytesFn              encodeBytesFn
+	encodeBoolFn               encodeBoolFn
 	encodeArrayLenFn           encodeArrayLenFn
 
 	legacy legacyEncodingOptions
@@ -85,6 +87,7 @@ func newEncoder(legacy legacyEncodingOptions) *Encoder {
 	enc.encodeVarUintFn = enc.encodeVarUint
 	enc.encodeFloat64Fn = enc.encodeFloat64
 	enc.encodeBytesFn = enc.encodeBytes
+	enc.encodeBoolFn = enc.encodeBool
 	enc.encodeArrayLenFn = enc.encodeArrayLen
 
 	// Used primarily for testing
@@ -193,6 +196,8 @@ func (enc *Encoder) encodeIndexInfoV2(info schema.IndexInfo) {
 	enc.encodeIndexBloomFilterInfo(info.BloomFilter)
 	enc.encodeVarintFn(info.SnapshotTime)
 	enc.encodeVarintFn(int64(info.FileType))
+	enc.encodeVarintFn(info.MinTimestamp)
+	enc.encodeVarintFn(info.MaxTimestamp)
 }
 
 func (enc *Encoder) encodeIndexSummariesInfo(info schema.IndexSummariesInfo) {
@@ -226,6 +231,7 @@ func (enc *Encoder) encodeIndexEntryV2(entry schema.IndexEntry) {
 	enc.encodeVarintFn(entry.Offset)
 	enc.encodeVarintFn(entry.Checksum)
 	enc.encodeBytesFn(entry.EncodedTags)
+	enc.encodeBoolFn(entry.Deleted)
 }
 
 func (enc *Encoder) encodeIndexSummary(summary schema.IndexSummary) {
@@ -310,6 +316,13 @@ func (enc *Encoder) encodeBytes(value []byte) {
 	enc.err = enc.enc.EncodeBytes(value)
 }
 
+func (enc *Encoder) encodeBool(value bool) {
+	if enc.err != nil {
+		return
+	}
+	enc.err = enc.enc.EncodeBool(value)
+}
+
 func (enc *Encoder) encodeArrayLen(value int) {
 	if enc.err != nil {
 		return
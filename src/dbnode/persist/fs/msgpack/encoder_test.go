@@ -52,6 +52,9 @@ func testCapturingEncoder(t *testing.T) (*Encoder, *[]interface{}) {
 	encoder.encodeBytesFn = func(value []byte) {
 		result = append(result, value)
 	}
+	encoder.encodeBoolFn = func(value bool) {
+		result = append(result, value)
+	}
 	encoder.encodeArrayLenFn = func(value int) {
 		result = append(result, value)
 	}
@@ -80,6 +83,8 @@ func testExpectedResultForIndexInfo(t *testing.T, indexInfo schema.IndexInfo) []
 		indexInfo.BloomFilter.NumHashesK,
 		indexInfo.SnapshotTime,
 		int64(indexInfo.FileType),
+		indexInfo.MinTimestamp,
+		indexInfo.MaxTimestamp,
 	}
 }
 
@@ -97,6 +102,7 @@ func testExpectedResultForIndexEntry(t *testing.T, indexEntry schema.IndexEntry)
 		indexEntry.Offset,
 		indexEntry.Checksum,
 		indexEntry.EncodedTags,
+		indexEntry.Deleted,
 	}
 }
 
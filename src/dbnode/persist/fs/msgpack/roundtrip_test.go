@@ -45,6 +45,8 @@ var (
 		},
 		SnapshotTime: time.Now().UnixNano(),
 		FileType:     persist.FileSetSnapshotType,
+		MinTimestamp: time.Now().Add(-time.Hour).UnixNano(),
+		MaxTimestamp: time.Now().UnixNano(),
 	}
 
 	testIndexEntry = schema.IndexEntry{
@@ -54,6 +56,7 @@ var (
 		Offset:      2390423,
 		Checksum:    134245634534,
 		EncodedTags: []byte("testEncodedTags"),
+		Deleted:     true,
 	}
 
 	testIndexSummary = schema.IndexSummary{
@@ -112,11 +115,17 @@ func TestIndexInfoRoundTripBackwardsCompatibilityV1(t *testing.T) {
 	// the old file format
 	currSnapshotTime := testIndexInfo.SnapshotTime
 	currFileType := testIndexInfo.FileType
+	currMinTimestamp := testIndexInfo.MinTimestamp
+	currMaxTimestamp := testIndexInfo.MaxTimestamp
 	testIndexInfo.SnapshotTime = 0
 	testIndexInfo.FileType = 0
+	testIndexInfo.MinTimestamp = 0
+	testIndexInfo.MaxTimestamp = 0
 	defer func() {
 		testIndexInfo.SnapshotTime = currSnapshotTime
 		testIndexInfo.FileType = currFileType
+		testIndexInfo.MinTimestamp = currMinTimestamp
+		testIndexInfo.MaxTimestamp = currMaxTimestamp
 	}()
 
 	enc.EncodeIndexInfo(testIndexInfo)
@@ -139,6 +148,8 @@ func TestIndexInfoRoundTripForwardsCompatibilityV2(t *testing.T) {
 	// because the old decoder won't read the new fields
 	currSnapshotTime := testIndexInfo.SnapshotTime
 	currFileType := testIndexInfo.FileType
+	currMinTimestamp := testIndexInfo.MinTimestamp
+	currMaxTimestamp := testIndexInfo.MaxTimestamp
 
 	enc.EncodeIndexInfo(testIndexInfo)
 
@@ -146,9 +157,13 @@ func TestIndexInfoRoundTripForwardsCompatibilityV2(t *testing.T) {
 	// encoded the data
 	testIndexInfo.SnapshotTime = 0
 	testIndexInfo.FileType = 0
+	testIndexInfo.MinTimestamp = 0
+	testIndexInfo.MaxTimestamp = 0
 	defer func() {
 		testIndexInfo.SnapshotTime = currSnapshotTime
 		testIndexInfo.FileType = currFileType
+		testIndexInfo.MinTimestamp = currMinTimestamp
+		testIndexInfo.MaxTimestamp = currMaxTimestamp
 	}()
 
 	dec.Reset(NewDecoderStream(enc.Bytes()))
@@ -157,6 +172,39 @@ func TestIndexInfoRoundTripForwardsCompatibilityV2(t *testing.T) {
 	require.Equal(t, testIndexInfo, res)
 }
 
+// Make sure the new decoding code can handle index info written before
+// the min and max timestamp fields were added
+func TestIndexInfoRoundTripBackwardsCompatibilityV2(t *testing.T) {
+	var (
+		enc = NewEncoder()
+		dec = NewDecoder(nil)
+	)
+
+	// Encode the info without the trailing min and max timestamp fields,
+	// the root object and the info are encoded with 13 varints in total
+	var (
+		encodeVarint = enc.encodeVarintFn
+		numVarints   int
+	)
+	enc.encodeNumObjectFieldsForFn = testGenEncodeNumObjectFieldsForFn(enc, indexInfoType, -2)
+	enc.encodeVarintFn = func(value int64) {
+		numVarints++
+		if numVarints <= 11 {
+			encodeVarint(value)
+		}
+	}
+	require.NoError(t, enc.EncodeIndexInfo(testIndexInfo))
+
+	dec.Reset(NewDecoderStream(enc.Bytes()))
+	res, err := dec.DecodeIndexInfo()
+	require.NoError(t, err)
+
+	expected := testIndexInfo
+	expected.MinTimestamp = 0
+	expected.MaxTimestamp = 0
+	require.Equal(t, expected, res)
+}
+
 func TestIndexEntryRoundtrip(t *testing.T) {
 	var (
 		enc = NewEncoder()
@@ -182,9 +230,12 @@ func TestIndexEntryRoundTripBackwardsCompatibilityV1(t *testing.T) {
 	// because the new decoder won't try and read the new fields from
 	// the old file format
 	currEncodedTags := testIndexEntry.EncodedTags
+	currDeleted := testIndexEntry.Deleted
 	testIndexEntry.EncodedTags = nil
+	testIndexEntry.Deleted = false
 	defer func() {
 		testIndexEntry.EncodedTags = currEncodedTags
+		testIndexEntry.Deleted = currDeleted
 	}()
 
 	enc.EncodeIndexEntry(testIndexEntry)
@@ -206,14 +257,17 @@ func TestIndexEntryRoundTripForwardsCompatibilityV2(t *testing.T) {
 	// and then restore them at the end of the test - This is required
 	// because the old decoder won't read the new fields
 	currEncodedTags := testIndexEntry.EncodedTags
+	currDeleted := testIndexEntry.Deleted
 
 	enc.EncodeIndexEntry(testIndexEntry)
 
 	// Make sure to zero them before we compare, but after we have
 	// encoded the data
 	testIndexEntry.EncodedTags = nil
+	testIndexEntry.Deleted = false
 	defer func() {
 		testIndexEntry.EncodedTags = currEncodedTags
+		testIndexEntry.Deleted = currDeleted
 	}()
 
 	dec.Reset(NewDecoderStream(enc.Bytes()))
@@ -222,6 +276,28 @@ func TestIndexEntryRoundTripForwardsCompatibilityV2(t *testing.T) {
 	require.Equal(t, testIndexEntry, res)
 }
 
+// Make sure the new decoding code can handle index entries written before
+// the deleted field was added
+func TestIndexEntryRoundTripBackwardsCompatibilityV2(t *testing.T) {
+	var (
+		enc = NewEncoder()
+		dec = NewDecoder(nil)
+	)
+
+	// Encode the entry without the trailing deleted field
+	enc.encodeNumObjectFieldsForFn = testGenEncodeNumObjectFieldsForFn(enc, indexEntryType, -1)
+	enc.encodeBoolFn = func(value bool) {}
+	require.NoError(t, enc.EncodeIndexEntry(testIndexEntry))
+
+	dec.Reset(NewDecoderStream(enc.Bytes()))
+	res, err := dec.DecodeIndexEntry()
+	require.NoError(t, err)
+
+	expected := testIndexEntry
+	expected.Deleted = false
+	require.Equal(t, expected, res)
+}
+
 func TestIndexSummaryRoundtrip(t *testing.T) {
 	var (
 		enc = NewEncoder()
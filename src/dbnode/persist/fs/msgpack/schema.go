@@ -99,10 +99,10 @@ const (
 	// correct number of fields is encoded into the files. These values need
 	// to be incremened whenever we add new fields to an object.
 	currNumRootObjectFields           = 2
-	currNumIndexInfoFields            = 8
+	currNumIndexInfoFields            = 10
 	currNumIndexSummariesInfoFields   = 1
 	currNumIndexBloomFilterInfoFields = 2
-	currNumIndexEntryFields           = 6
+	currNumIndexEntryFields           = 7
 	currNumIndexSummaryFields         = 3
 	currNumLogInfoFields              = 3
 	currNumLogEntryFields             = 7
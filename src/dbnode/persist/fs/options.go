@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
@@ -33,6 +34,7 @@ import (
 	"github.com/m3db/m3/src/m3ninx/postings/roaring"
 	"github.com/m3db/m3x/instrument"
 	"github.com/m3db/m3x/pool"
+	xretry "github.com/m3db/m3x/retry"
 )
 
 const (
@@ -45,6 +47,9 @@ const (
 	// defaultWriterBufferSize is the default buffer size for writing TSDB files
 	defaultWriterBufferSize = 65536
 
+	// defaultWriterMaxKeyBytes is the default max key size for writing TSDB files, zero means no limit
+	defaultWriterMaxKeyBytes = 0
+
 	// defaultDataReaderBufferSize is the default buffer size for reading TSDB data and index files
 	defaultDataReaderBufferSize = 65536
 
@@ -66,8 +71,20 @@ var (
 	defaultNewFileMode      = os.FileMode(0666)
 	defaultNewDirectoryMode = os.ModeDir | os.FileMode(0755)
 
-	errTagEncoderPoolNotSet = errors.New("tag encoder pool is not set")
-	errTagDecoderPoolNotSet = errors.New("tag decoder pool is not set")
+	// defaultWriterRetryOptions retries transient data file write errors a
+	// small number of times with a jittered and capped backoff
+	defaultWriterRetryOptions = xretry.NewOptions().
+					SetInitialBackoff(10 * time.Millisecond).
+					SetBackoffFactor(2).
+					SetMaxBackoff(time.Second).
+					SetMaxRetries(3).
+					SetJitter(true)
+
+	errTagEncoderPoolNotSet      = errors.New("tag encoder pool is not set")
+	errTagDecoderPoolNotSet      = errors.New("tag decoder pool is not set")
+	errWriterMaxKeyBytesNegative = errors.New("writer max key bytes must not be negative")
+	errWriterRetryOptionsNotSet  = errors.New("writer retry options are not set")
+	errSyncFnNotSet              = errors.New("sync fn is not set")
 )
 
 type options struct {
@@ -78,9 +95,16 @@ type options struct {
 	filePathPrefix                       string
 	newFileMode                          os.FileMode
 	newDirectoryMode                     os.FileMode
+	filenameTimeFormat                   FilenameTimeFormat
 	indexSummariesPercent                float64
 	indexBloomFilterFalsePositivePercent float64
 	writerBufferSize                     int
+	writerMaxKeyBytes                    int
+	writerRetryOpts                      xretry.Options
+	writerErrorFn                        WriteErrorFn
+	writerOpenLimiter                    WriterOpenLimiter
+	syncMode                             SyncMode
+	syncFn                               SyncFn
 	dataReaderBufferSize                 int
 	infoReaderBufferSize                 int
 	seekReaderBufferSize                 int
@@ -110,9 +134,14 @@ func NewOptions() Options {
 		filePathPrefix:                       defaultFilePathPrefix,
 		newFileMode:                          defaultNewFileMode,
 		newDirectoryMode:                     defaultNewDirectoryMode,
+		filenameTimeFormat:                   DefaultFilenameTimeFormat,
 		indexSummariesPercent:                defaultIndexSummariesPercent,
 		indexBloomFilterFalsePositivePercent: defaultIndexBloomFilterFalsePositivePercent,
 		writerBufferSize:                     defaultWriterBufferSize,
+		writerMaxKeyBytes:                    defaultWriterMaxKeyBytes,
+		writerRetryOpts:                      defaultWriterRetryOptions,
+		syncMode:                             DefaultSyncMode,
+		syncFn:                               syncPath,
 		dataReaderBufferSize:                 defaultDataReaderBufferSize,
 		infoReaderBufferSize:                 defaultInfoReaderBufferSize,
 		seekReaderBufferSize:                 defaultSeekReaderBufferSize,
@@ -135,6 +164,24 @@ func (o *options) Validate() error {
 			"invalid index bloom filter false positive percent, must be >= 0 and <= 1: instead %f",
 			o.indexBloomFilterFalsePositivePercent)
 	}
+	if err := ValidateFilenameTimeFormat(o.filenameTimeFormat); err != nil {
+		return err
+	}
+	if o.writerMaxKeyBytes < 0 {
+		return errWriterMaxKeyBytesNegative
+	}
+	if o.writerRetryOpts == nil {
+		return errWriterRetryOptionsNotSet
+	}
+	if o.writerOpenLimiter != nil && o.writerOpenLimiter.MaxOpen() <= 0 {
+		return errWriterOpenLimiterMaxOpenNotPositive
+	}
+	if err := ValidateSyncMode(o.syncMode); err != nil {
+		return err
+	}
+	if o.syncFn == nil {
+		return errSyncFnNotSet
+	}
 	if o.tagEncoderPool == nil {
 		return errTagEncoderPoolNotSet
 	}
@@ -214,6 +261,16 @@ func (o *options) NewDirectoryMode() os.FileMode {
 	return o.newDirectoryMode
 }
 
+func (o *options) SetFilenameTimeFormat(value FilenameTimeFormat) Options {
+	opts := *o
+	opts.filenameTimeFormat = value
+	return &opts
+}
+
+func (o *options) FilenameTimeFormat() FilenameTimeFormat {
+	return o.filenameTimeFormat
+}
+
 func (o *options) SetIndexSummariesPercent(value float64) Options {
 	opts := *o
 	opts.indexSummariesPercent = value
@@ -244,6 +301,66 @@ func (o *options) WriterBufferSize() int {
 	return o.writerBufferSize
 }
 
+func (o *options) SetWriterMaxKeyBytes(value int) Options {
+	opts := *o
+	opts.writerMaxKeyBytes = value
+	return &opts
+}
+
+func (o *options) WriterMaxKeyBytes() int {
+	return o.writerMaxKeyBytes
+}
+
+func (o *options) SetWriterRetryOptions(value xretry.Options) Options {
+	opts := *o
+	opts.writerRetryOpts = value
+	return &opts
+}
+
+func (o *options) WriterRetryOptions() xretry.Options {
+	return o.writerRetryOpts
+}
+
+func (o *options) SetWriterErrorFn(value WriteErrorFn) Options {
+	opts := *o
+	opts.writerErrorFn = value
+	return &opts
+}
+
+func (o *options) WriterErrorFn() WriteErrorFn {
+	return o.writerErrorFn
+}
+
+func (o *options) SetWriterOpenLimiter(value WriterOpenLimiter) Options {
+	opts := *o
+	opts.writerOpenLimiter = value
+	return &opts
+}
+
+func (o *options) WriterOpenLimiter() WriterOpenLimiter {
+	return o.writerOpenLimiter
+}
+
+func (o *options) SetSyncMode(value SyncMode) Options {
+	opts := *o
+	opts.syncMode = value
+	return &opts
+}
+
+func (o *options) SyncMode() SyncMode {
+	return o.syncMode
+}
+
+func (o *options) SetSyncFn(value SyncFn) Options {
+	opts := *o
+	opts.syncFn = value
+	return &opts
+}
+
+func (o *options) SyncFn() SyncFn {
+	return o.syncFn
+}
+
 func (o *options) SetDataReaderBufferSize(value int) Options {
 	opts := *o
 	opts.dataReaderBufferSize = value
@@ -385,7 +385,7 @@ func (pm *persistManager) PrepareData(opts persist.DataPrepareOptions) (persist.
 		return prepared, err
 	}
 
-	var volumeIndex int
+	volumeIndex := opts.VolumeIndex
 	if opts.FileSetType == persist.FileSetSnapshotType {
 		// Need to work out the volume index for the next snapshot
 		volumeIndex, err = NextSnapshotFileSetVolumeIndex(pm.opts.FilePathPrefix(),
@@ -504,6 +504,12 @@ func (pm *persistManager) DoneData() error {
 		return errPersistManagerNotPersisting
 	}
 
+	// Sync the files of all the file sets written if syncs were batched
+	var err error
+	if syncer, ok := pm.dataPM.writer.(pendingFileSyncer); ok {
+		err = syncer.syncPending()
+	}
+
 	// Emit timing metrics
 	pm.metrics.writeDurationMs.Update(float64(pm.worked / time.Millisecond))
 	pm.metrics.throttleDurationMs.Update(float64(pm.slept / time.Millisecond))
@@ -511,7 +517,7 @@ func (pm *persistManager) DoneData() error {
 	// Reset state
 	pm.reset()
 
-	return nil
+	return err
 }
 
 func (pm *persistManager) dataFilesetExistsAt(prepareOpts persist.DataPrepareOptions) (bool, error) {
@@ -527,7 +533,13 @@ func (pm *persistManager) dataFilesetExistsAt(prepareOpts persist.DataPrepareOpt
 		// already exist doesn't make much sense
 		return false, nil
 	case persist.FileSetFlushType:
-		return DataFileSetExistsAt(pm.filePathPrefix, nsID, shard, blockStart)
+		fileset, ok, err := FileSetAt(pm.filePathPrefix, nsID, shard, blockStart)
+		if err != nil || !ok {
+			return false, err
+		}
+		// A block flushed again is written to a volume after the latest
+		// complete volume so only volumes from that volume onwards collide.
+		return fileset.ID.VolumeIndex >= prepareOpts.VolumeIndex, nil
 	default:
 		return false, fmt.Errorf(
 			"unable to determine if fileset exists in persist manager for fileset type: %s",
@@ -123,6 +123,56 @@ func TestPersistenceManagerPrepareDataFileExistsWithDelete(t *testing.T) {
 	require.True(t, os.IsNotExist(err))
 }
 
+func TestPersistenceManagerPrepareDataNextVolume(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pm, writer, _ := testDataPersistManager(t, ctrl)
+	defer os.RemoveAll(pm.filePathPrefix)
+
+	shard := uint32(0)
+	blockStart := time.Unix(1000, 0)
+
+	writerOpts := xtest.CmpMatcher(DataWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:   testNs1ID,
+			Shard:       shard,
+			BlockStart:  blockStart,
+			VolumeIndex: 1,
+		},
+		BlockSize: testBlockSize,
+	})
+	writer.EXPECT().Open(writerOpts).Return(nil)
+
+	shardDir := createDataShardDir(t, pm.filePathPrefix, testNs1ID, shard)
+	checkpointFilePath := filesetPathFromTime(shardDir, blockStart, checkpointFileSuffix)
+	f, err := os.Create(checkpointFilePath)
+	require.NoError(t, err)
+	f.Close()
+
+	flush, err := pm.StartDataPersist()
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, flush.DoneData())
+	}()
+
+	prepareOpts := persist.DataPrepareOptions{
+		NamespaceMetadata: testNs1Metadata(t),
+		Shard:             shard,
+		BlockStart:        blockStart,
+		VolumeIndex:       1,
+	}
+	prepared, err := flush.PrepareData(prepareOpts)
+	require.NoError(t, err)
+	require.NotNil(t, prepared.Persist)
+	require.NotNil(t, prepared.Close)
+
+	// The earlier volume is left in place
+	_, err = os.Stat(checkpointFilePath)
+	require.NoError(t, err)
+}
+
 func TestPersistenceManagerPrepareOpenError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
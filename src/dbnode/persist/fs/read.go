@@ -68,6 +68,7 @@ type reader struct {
 	indexMmap               []byte
 	indexDecoderStream      dataFileSetReaderDecoderStream
 	indexEntriesByOffsetAsc []schema.IndexEntry
+	tombstones              []schema.IndexEntry
 
 	dataFd     *os.File
 	dataMmap   []byte
@@ -90,6 +91,7 @@ type reader struct {
 	expectedDigestOfDigest    uint32
 	expectedBloomFilterDigest uint32
 	shard                     uint32
+	volumeIndex               int
 	open                      bool
 }
 
@@ -126,43 +128,44 @@ func NewReader(
 
 func (r *reader) Open(opts DataReaderOpenOptions) error {
 	var (
-		namespace     = opts.Identifier.Namespace
-		shard         = opts.Identifier.Shard
-		blockStart    = opts.Identifier.BlockStart
-		snapshotIndex = opts.Identifier.VolumeIndex
-		err           error
+		namespace   = opts.Identifier.Namespace
+		shard       = opts.Identifier.Shard
+		blockStart  = opts.Identifier.BlockStart
+		volumeIndex = opts.Identifier.VolumeIndex
+		err         error
 	)
 
 	var (
-		shardDir            string
-		checkpointFilepath  string
-		infoFilepath        string
-		digestFilepath      string
-		bloomFilterFilepath string
-		indexFilepath       string
-		dataFilepath        string
+		shardDir string
+		pathFn   filesetPathFn
 	)
 	switch opts.FileSetType {
 	case persist.FileSetSnapshotType:
 		shardDir = ShardSnapshotsDirPath(r.filePathPrefix, namespace, shard)
-		checkpointFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, snapshotIndex, checkpointFileSuffix)
-		infoFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, snapshotIndex, infoFileSuffix)
-		digestFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, snapshotIndex, digestFileSuffix)
-		bloomFilterFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, snapshotIndex, bloomFilterFileSuffix)
-		indexFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, snapshotIndex, indexFileSuffix)
-		dataFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, snapshotIndex, dataFileSuffix)
+		pathFn = func(format FilenameTimeFormat, suffix string) string {
+			return filesetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, format, suffix)
+		}
 	case persist.FileSetFlushType:
 		shardDir = ShardDataDirPath(r.filePathPrefix, namespace, shard)
-		checkpointFilepath = filesetPathFromTime(shardDir, blockStart, checkpointFileSuffix)
-		infoFilepath = filesetPathFromTime(shardDir, blockStart, infoFileSuffix)
-		digestFilepath = filesetPathFromTime(shardDir, blockStart, digestFileSuffix)
-		bloomFilterFilepath = filesetPathFromTime(shardDir, blockStart, bloomFilterFileSuffix)
-		indexFilepath = filesetPathFromTime(shardDir, blockStart, indexFileSuffix)
-		dataFilepath = filesetPathFromTime(shardDir, blockStart, dataFileSuffix)
+		pathFn = func(format FilenameTimeFormat, suffix string) string {
+			return dataFileSetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, format, suffix)
+		}
 	default:
 		return fmt.Errorf("unable to open reader with fileset type: %s", opts.FileSetType)
 	}
 
+	// Read the file set with whichever filename time format it was written
+	// with, which may differ from the format currently configured.
+	var (
+		format              = filenameTimeFormatOf(pathFn)
+		checkpointFilepath  = pathFn(format, checkpointFileSuffix)
+		infoFilepath        = pathFn(format, infoFileSuffix)
+		digestFilepath      = pathFn(format, digestFileSuffix)
+		bloomFilterFilepath = pathFn(format, bloomFilterFileSuffix)
+		indexFilepath       = pathFn(format, indexFileSuffix)
+		dataFilepath        = pathFn(format, dataFileSuffix)
+	)
+
 	// If there is no checkpoint file, don't read the data files.
 	if err := r.readCheckpointFile(checkpointFilepath); err != nil {
 		return err
@@ -234,16 +237,18 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 	r.open = true
 	r.namespace = namespace
 	r.shard = shard
+	r.volumeIndex = volumeIndex
 
 	return nil
 }
 
 func (r *reader) Status() DataFileSetReaderStatus {
 	return DataFileSetReaderStatus{
-		Open:       r.open,
-		Namespace:  r.namespace,
-		Shard:      r.shard,
-		BlockStart: r.start,
+		Open:        r.open,
+		Namespace:   r.namespace,
+		Shard:       r.shard,
+		BlockStart:  r.start,
+		VolumeIndex: r.volumeIndex,
 	}
 }
 
@@ -312,8 +317,16 @@ func (r *reader) readIndexAndSortByOffsetAsc() error {
 		if err != nil {
 			return err
 		}
+		if entry.Deleted {
+			// Tombstones have no data to read, hold onto them separately
+			// so that they are only visible to compactions
+			r.tombstones = append(r.tombstones, entry)
+			continue
+		}
 		r.indexEntriesByOffsetAsc = append(r.indexEntriesByOffsetAsc, entry)
 	}
+	r.entries = len(r.indexEntriesByOffsetAsc)
+
 	// NB(r): As we decode each block we need access to each index entry
 	// in the order we decode the data
 	sort.Sort(indexEntriesByOffsetAsc(r.indexEntriesByOffsetAsc))
@@ -473,6 +486,10 @@ func (r *reader) Close() error {
 		r.indexEntriesByOffsetAsc[i].ID = nil
 	}
 	r.indexEntriesByOffsetAsc = r.indexEntriesByOffsetAsc[:0]
+	for i := 0; i < len(r.tombstones); i++ {
+		r.tombstones[i].ID = nil
+	}
+	r.tombstones = r.tombstones[:0]
 
 	// Save fields we want to reassign after resetting struct
 	opts := r.opts
@@ -488,6 +505,7 @@ func (r *reader) Close() error {
 	bytesPool := r.bytesPool
 	tagDecoderPool := r.tagDecoderPool
 	indexEntriesByOffsetAsc := r.indexEntriesByOffsetAsc
+	tombstones := r.tombstones
 
 	// Reset struct
 	*r = reader{}
@@ -506,6 +524,7 @@ func (r *reader) Close() error {
 	r.bytesPool = bytesPool
 	r.tagDecoderPool = tagDecoderPool
 	r.indexEntriesByOffsetAsc = indexEntriesByOffsetAsc
+	r.tombstones = tombstones
 
 	return multiErr.FinalError()
 }
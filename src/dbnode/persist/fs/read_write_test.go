@@ -23,6 +23,8 @@ package fs
 import (
 	"bytes"
 	"errors"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
@@ -32,6 +34,7 @@ import (
 	"github.com/m3db/bloom"
 	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3x/checked"
 	"github.com/m3db/m3x/ident"
 	xtime "github.com/m3db/m3x/time"
@@ -253,6 +256,192 @@ func TestSimpleReadWrite(t *testing.T) {
 	readTestData(t, r, 0, testWriterStart, entries)
 }
 
+func TestWriterIndexSortedByID(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+		{"qux", nil, []byte{7, 8}},
+		{"baz", nil, []byte{9}},
+	}
+
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	buf, err := ioutil.ReadFile(filesetPathFromTime(shardDir, testWriterStart, indexFileSuffix))
+	require.NoError(t, err)
+
+	decoder := msgpack.NewDecoder(testDefaultOpts.DecodingOptions())
+	decoder.Reset(msgpack.NewDecoderStream(buf))
+
+	// Index entries are emitted sorted by ID so readers can binary search
+	// them, while the index and data offsets keep the order of the writes
+	expected := []struct {
+		id     string
+		index  int64
+		offset int64
+	}{
+		{"bar", 1, 3},
+		{"baz", 3, 8},
+		{"foo", 0, 0},
+		{"qux", 2, 6},
+	}
+	for _, e := range expected {
+		entry, err := decoder.DecodeIndexEntry()
+		require.NoError(t, err)
+		require.Equal(t, e.id, string(entry.ID))
+		require.Equal(t, e.index, entry.Index)
+		require.Equal(t, e.offset, entry.Offset)
+	}
+}
+
+func TestWriterIndexOffsetsSeekable(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, make([]byte, 65536)},
+		{"qux", nil, []byte{7, 8}},
+		{"baz", nil, []byte{9}},
+	}
+
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	buf, err := ioutil.ReadFile(filesetPathFromTime(shardDir, testWriterStart, indexFileSuffix))
+	require.NoError(t, err)
+	dataFd, err := os.Open(filesetPathFromTime(shardDir, testWriterStart, dataFileSuffix))
+	require.NoError(t, err)
+	defer dataFd.Close()
+
+	decoder := msgpack.NewDecoder(testDefaultOpts.DecodingOptions())
+	decoder.Reset(msgpack.NewDecoderStream(buf))
+
+	byIndex := make([]int64, len(entries))
+	for range entries {
+		entry, err := decoder.DecodeIndexEntry()
+		require.NoError(t, err)
+		byIndex[entry.Index] = entry.Offset
+
+		// Each entry can be read directly from its offset in the data file
+		data := make([]byte, entry.Size)
+		_, err = dataFd.ReadAt(data, entry.Offset)
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(entries[entry.Index].data, data))
+	}
+
+	// Offsets increase in write order by the size of each entry
+	var offset int64
+	for i, e := range entries {
+		require.Equal(t, offset, byIndex[i])
+		offset += int64(len(e.data))
+	}
+}
+
+func TestWriterRejectsInvalidKeys(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	w, err := NewWriter(testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetWriterMaxKeyBytes(8))
+	require.NoError(t, err)
+
+	writerOpts := DataWriterOpenOptions{
+		BlockSize: testBlockSize,
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	}
+	require.NoError(t, w.Open(writerOpts))
+
+	data := []byte{1, 2, 3}
+	require.Equal(t, errWriterInvalidKeyEmpty, w.Write(ident.StringID(""),
+		ident.Tags{}, bytesRefd(data), digest.Checksum(data)))
+	require.Equal(t, errWriterInvalidKeyTooLong, w.Write(ident.StringID("foo+bar=baz"),
+		ident.Tags{}, bytesRefd(data), digest.Checksum(data)))
+
+	// Rejected keys do not fail the rest of the fileset
+	require.NoError(t, w.Write(ident.StringID("foo"),
+		ident.Tags{}, bytesRefd(data), digest.Checksum(data)))
+	require.NoError(t, w.Close())
+
+	r := newTestReader(t, filePathPrefix)
+	readTestData(t, r, 0, testWriterStart, []testEntry{
+		{"foo", nil, data},
+	})
+}
+
+func TestWriterErrorFn(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	type writeError struct {
+		id  string
+		err error
+	}
+	var writeErrors []writeError
+	w, err := NewWriter(testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetWriterMaxKeyBytes(8).
+		SetWriterErrorFn(func(id ident.ID, err error) {
+			writeErrors = append(writeErrors, writeError{id: id.String(), err: err})
+		}))
+	require.NoError(t, err)
+
+	writerOpts := DataWriterOpenOptions{
+		BlockSize: testBlockSize,
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	}
+	require.NoError(t, w.Open(writerOpts))
+
+	data := []byte{1, 2, 3}
+	require.NoError(t, w.Write(ident.StringID("foo"),
+		ident.Tags{}, bytesRefd(data), digest.Checksum(data)))
+	require.Equal(t, errWriterInvalidKeyTooLong, w.Write(ident.StringID("foo+bar=baz"),
+		ident.Tags{}, bytesRefd(data), digest.Checksum(data)))
+
+	// Inject a data file writer that fails permanently
+	writeErr := errors.New("synthetic write error")
+	wr := w.(*writer)
+	fd := wr.dataFdWithDigest.Fd()
+	defer fd.Close()
+	wr.dataFdWithDigest = digest.NewFdWithDigestWriterWithFileWriterFn(1, func(*os.File) io.Writer {
+		return &testFlakyWriter{results: []testWriteResult{{err: writeErr}}}
+	})
+	wr.dataFdWithDigest.Reset(fd)
+
+	require.Equal(t, writeErr, w.Write(ident.StringID("bar"),
+		ident.Tags{}, bytesRefd(data), digest.Checksum(data)))
+
+	// Writes rejected by the already failed writer are not reported again
+	require.Equal(t, writeErr, w.Write(ident.StringID("baz"),
+		ident.Tags{}, bytesRefd(data), digest.Checksum(data)))
+	require.Equal(t, writeErr, w.Close())
+
+	require.Equal(t, []writeError{
+		{id: "foo+bar=baz", err: errWriterInvalidKeyTooLong},
+		{id: "bar", err: writeErr},
+	}, writeErrors)
+}
+
 func TestDuplicateWrite(t *testing.T) {
 	dir := createTempDir(t)
 	filePathPrefix := filepath.Join(dir, "")
@@ -341,6 +530,88 @@ func TestInfoReadWrite(t *testing.T) {
 	require.Equal(t, int64(len(entries)), infoFile.Entries)
 }
 
+func TestWriteEmptyFileSetWritesCheckpoint(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 0, testWriterStart, nil, persist.FileSetFlushType)
+
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	require.True(t, FileExists(filesetPathFromTime(shardDir, testWriterStart, checkpointFileSuffix)))
+
+	exists, err := DataFileSetExistsAt(filePathPrefix, testNs1ID, 0, testWriterStart)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// A block that was never flushed has no file set
+	exists, err = DataFileSetExistsAt(filePathPrefix, testNs1ID, 0, testWriterStart.Add(testBlockSize))
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	readInfoFileResults := ReadInfoFiles(filePathPrefix, testNs1ID, 0, 16, nil)
+	require.Equal(t, 1, len(readInfoFileResults))
+	require.NoError(t, readInfoFileResults[0].Err.Error())
+	require.Equal(t, int64(0), readInfoFileResults[0].Info.Entries)
+
+	r := newTestReader(t, filePathPrefix)
+	readTestData(t, r, 0, testWriterStart, nil)
+}
+
+func TestInfoMinMaxTimestamps(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	w := newTestWriter(t, filePathPrefix)
+	require.NoError(t, w.Open(DataWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+		BlockSize: testBlockSize,
+	}))
+
+	writes := []struct {
+		id       string
+		min, max time.Duration
+	}{
+		{"foo", 10 * time.Minute, 20 * time.Minute},
+		{"bar", 5 * time.Minute, 15 * time.Minute},
+		{"baz", 30 * time.Minute, 45 * time.Minute},
+	}
+	for _, write := range writes {
+		data := []byte{1, 2, 3}
+		require.NoError(t, w.WriteAllWithTimestamps(
+			ident.StringID(write.id), ident.Tags{}, []checked.Bytes{bytesRefd(data)},
+			digest.Checksum(data), testWriterStart.Add(write.min), testWriterStart.Add(write.max)))
+	}
+	require.NoError(t, w.Close())
+
+	// Data written without timestamps falls back to the bounds of the block
+	nextBlockStart := testWriterStart.Add(testBlockSize)
+	writeTestData(t, w, 0, nextBlockStart, []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+	}, persist.FileSetFlushType)
+
+	readInfoFileResults := ReadInfoFiles(filePathPrefix, testNs1ID, 0, 16, nil)
+	require.Equal(t, 2, len(readInfoFileResults))
+	for _, result := range readInfoFileResults {
+		require.NoError(t, result.Err.Error())
+	}
+
+	info := readInfoFileResults[0].Info
+	require.True(t, testWriterStart.Add(5*time.Minute).Equal(xtime.FromNanoseconds(info.MinTimestamp)))
+	require.True(t, testWriterStart.Add(45*time.Minute).Equal(xtime.FromNanoseconds(info.MaxTimestamp)))
+
+	info = readInfoFileResults[1].Info
+	require.True(t, nextBlockStart.Equal(xtime.FromNanoseconds(info.MinTimestamp)))
+	require.True(t, nextBlockStart.Add(testBlockSize-time.Nanosecond).Equal(
+		xtime.FromNanoseconds(info.MaxTimestamp)))
+}
+
 func TestReusingReaderWriter(t *testing.T) {
 	dir := createTempDir(t)
 	filePathPrefix := filepath.Join(dir, "")
@@ -455,3 +726,260 @@ func TestWriterOnlyWritesNonNilBytes(t *testing.T) {
 		{"foo", nil, []byte{1, 2, 3, 4, 5, 6}},
 	})
 }
+
+func TestWriteAndReadDataFileSetVolumes(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	volumes := [][]testEntry{
+		{
+			{"foo", nil, []byte{1, 2, 3}},
+		},
+		{
+			{"foo", nil, []byte{1, 2, 3}},
+			{"bar", nil, []byte{4, 5, 6}},
+		},
+	}
+
+	w := newTestWriter(t, filePathPrefix)
+	for volume, entries := range volumes {
+		next, err := NextDataFileSetVolumeIndex(filePathPrefix, testNs1ID, 0, testWriterStart)
+		require.NoError(t, err)
+		require.Equal(t, volume, next)
+
+		writeTestDataWithVolume(t, w, 0, testWriterStart, volume, entries, persist.FileSetFlushType)
+	}
+
+	// Volume zero keeps the original file naming
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	require.True(t, FileExists(filesetPathFromTime(shardDir, testWriterStart, checkpointFileSuffix)))
+	require.True(t, FileExists(filesetPathFromTimeAndIndex(shardDir, testWriterStart, 1, checkpointFileSuffix)))
+
+	next, err := NextDataFileSetVolumeIndex(filePathPrefix, testNs1ID, 0, testWriterStart)
+	require.NoError(t, err)
+	require.Equal(t, 2, next)
+
+	// Only the latest volume of the block is read
+	infoFiles := ReadInfoFiles(filePathPrefix, testNs1ID, 0, testReaderBufferSize,
+		testDefaultOpts.DecodingOptions())
+	require.Equal(t, 1, len(infoFiles))
+	require.NoError(t, infoFiles[0].Err.Error())
+	require.Equal(t, 1, infoFiles[0].ID.VolumeIndex)
+	require.Equal(t, int64(len(volumes[1])), infoFiles[0].Info.Entries)
+
+	fileset, ok, err := FileSetAt(filePathPrefix, testNs1ID, 0, testWriterStart)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, fileset.ID.VolumeIndex)
+
+	// The earlier volume is superseded by the latest volume
+	superseded, err := DataFileSetsSuperseded(filePathPrefix, testNs1ID, 0)
+	require.NoError(t, err)
+	require.Contains(t, superseded, filesetPathFromTime(shardDir, testWriterStart, checkpointFileSuffix))
+	require.NotContains(t, superseded, filesetPathFromTimeAndIndex(shardDir, testWriterStart, 1, checkpointFileSuffix))
+
+	r := newTestReader(t, filePathPrefix)
+	for volume, entries := range volumes {
+		require.NoError(t, r.Open(DataReaderOpenOptions{
+			Identifier: FileSetFileIdentifier{
+				Namespace:   testNs1ID,
+				Shard:       0,
+				BlockStart:  testWriterStart,
+				VolumeIndex: volume,
+			},
+			FileSetType: persist.FileSetFlushType,
+		}))
+		require.Equal(t, len(entries), r.Entries())
+		require.NoError(t, r.Close())
+	}
+}
+
+func TestWriteNamespacesToSeparateDirectories(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	namespaces := map[ident.ID][]testEntry{
+		testNs1ID: {
+			{"foo", nil, []byte{1, 2, 3}},
+		},
+		testNs2ID: {
+			{"bar", nil, []byte{4, 5, 6}},
+			{"baz", nil, []byte{7, 8}},
+		},
+	}
+
+	w := newTestWriter(t, filePathPrefix)
+	for ns, entries := range namespaces {
+		require.NoError(t, w.Open(DataWriterOpenOptions{
+			Identifier: FileSetFileIdentifier{
+				Namespace:  ns,
+				Shard:      0,
+				BlockStart: testWriterStart,
+			},
+			BlockSize:   testBlockSize,
+			FileSetType: persist.FileSetFlushType,
+		}))
+		for _, entry := range entries {
+			require.NoError(t, w.Write(entry.ID(), entry.Tags(),
+				bytesRefd(entry.data), digest.Checksum(entry.data)))
+		}
+		require.NoError(t, w.Close())
+	}
+
+	// The same shard and block start of each namespace are written under
+	// their own namespace directory and do not collide
+	require.NotEqual(t,
+		ShardDataDirPath(filePathPrefix, testNs1ID, 0),
+		ShardDataDirPath(filePathPrefix, testNs2ID, 0))
+	for ns, entries := range namespaces {
+		exists, err := DataFileSetExistsAt(filePathPrefix, ns, 0, testWriterStart)
+		require.NoError(t, err)
+		require.True(t, exists)
+
+		infoFiles := ReadInfoFiles(filePathPrefix, ns, 0, testReaderBufferSize,
+			testDefaultOpts.DecodingOptions())
+		require.Equal(t, 1, len(infoFiles))
+		require.NoError(t, infoFiles[0].Err.Error())
+		require.Equal(t, int64(len(entries)), infoFiles[0].Info.Entries)
+	}
+}
+
+func TestWriterDeleteWritesTombstone(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+	}
+
+	w := newTestWriter(t, filePathPrefix)
+	require.NoError(t, w.Open(DataWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+		BlockSize:   testBlockSize,
+		FileSetType: persist.FileSetFlushType,
+	}))
+	for _, entry := range entries {
+		require.NoError(t, w.Write(entry.ID(), entry.Tags(),
+			bytesRefd(entry.data), digest.Checksum(entry.data)))
+	}
+	require.NoError(t, w.Delete(ident.StringID("baz")))
+	require.Equal(t, errWriterInvalidKeyEmpty, w.Delete(ident.StringID("")))
+	require.NoError(t, w.Close())
+
+	// The tombstone is recorded in the index marked as deleted
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	indexData, err := ioutil.ReadFile(
+		dataFileSetPathFromTimeAndIndex(shardDir, testWriterStart, 0, indexFileSuffix))
+	require.NoError(t, err)
+
+	deleted := make(map[string]bool)
+	decoder := msgpack.NewDecoder(nil)
+	decoder.Reset(msgpack.NewDecoderStream(indexData))
+	for i := 0; i < 3; i++ {
+		entry, err := decoder.DecodeIndexEntry()
+		require.NoError(t, err)
+		deleted[string(entry.ID)] = entry.Deleted
+	}
+	require.Equal(t, map[string]bool{"foo": false, "bar": false, "baz": true}, deleted)
+
+	// Readers skip the tombstone
+	r := newTestReader(t, filePathPrefix)
+	require.NoError(t, r.Open(DataReaderOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+		FileSetType: persist.FileSetFlushType,
+	}))
+	require.Equal(t, len(entries), r.Entries())
+	for _, entry := range entries {
+		id, tags, data, _, err := r.Read()
+		require.NoError(t, err)
+		data.IncRef()
+		require.Equal(t, entry.id, id.String())
+		require.Equal(t, entry.data, data.Bytes())
+		id.Finalize()
+		tags.Close()
+		data.DecRef()
+		data.Finalize()
+	}
+	_, _, _, _, err = r.Read()
+	require.Equal(t, io.EOF, err)
+	require.NoError(t, r.Close())
+
+	// Seekers treat the tombstoned ID as not found
+	s := newTestSeeker(filePathPrefix)
+	require.NoError(t, s.Open(testNs1ID, 0, testWriterStart))
+	_, err = s.SeekByID(ident.StringID("baz"))
+	require.Equal(t, errSeekIDNotFound, err)
+	data, err := s.SeekByID(ident.StringID("foo"))
+	require.NoError(t, err)
+	data.IncRef()
+	require.Equal(t, entries[0].data, data.Bytes())
+	data.DecRef()
+	require.NoError(t, s.Close())
+}
+
+func TestWriteAndReadFilenameTimeFormats(t *testing.T) {
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+	}
+	blockStart := testWriterStart.Truncate(testBlockSize)
+
+	for _, format := range ValidFilenameTimeFormats() {
+		t.Run(format.String(), func(t *testing.T) {
+			dir := createTempDir(t)
+			filePathPrefix := filepath.Join(dir, "")
+			defer os.RemoveAll(dir)
+
+			w, err := NewWriter(testDefaultOpts.
+				SetFilePathPrefix(filePathPrefix).
+				SetWriterBufferSize(testWriterBufferSize).
+				SetFilenameTimeFormat(format))
+			require.NoError(t, err)
+			writeTestData(t, w, 0, blockStart, entries, persist.FileSetFlushType)
+
+			shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+			require.True(t, FileExists(dataFileSetPathFromTimeIndexAndFormat(
+				shardDir, blockStart, 0, format, checkpointFileSuffix)))
+
+			fileset, ok, err := FileSetAt(filePathPrefix, testNs1ID, 0, blockStart)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.True(t, blockStart.Equal(fileset.ID.BlockStart))
+
+			infoFiles := ReadInfoFiles(filePathPrefix, testNs1ID, 0, testReaderBufferSize,
+				testDefaultOpts.DecodingOptions())
+			require.Equal(t, 1, len(infoFiles))
+			require.NoError(t, infoFiles[0].Err.Error())
+
+			// Readers and seekers use the default options so must detect the format
+			r := newTestReader(t, filePathPrefix)
+			readTestData(t, r, 0, blockStart, entries)
+
+			s := newTestSeeker(filePathPrefix)
+			require.NoError(t, s.Open(testNs1ID, 0, blockStart))
+			data, err := s.SeekByID(ident.StringID("foo"))
+			require.NoError(t, err)
+			data.IncRef()
+			require.Equal(t, []byte{1, 2, 3}, data.Bytes())
+			data.DecRef()
+			data.Finalize()
+			require.NoError(t, s.Close())
+
+			verifyErrs, err := Verify(filePathPrefix)
+			require.NoError(t, err)
+			require.Empty(t, verifyErrs)
+		})
+	}
+}
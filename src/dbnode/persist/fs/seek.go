@@ -59,6 +59,9 @@ type seeker struct {
 	opts           seekerOpts
 	filePathPrefix string
 
+	// Volume of the block being seeked
+	volumeIndex int
+
 	// Data read from the indexInfo file
 	start           time.Time
 	blockSize       time.Duration
@@ -161,17 +164,38 @@ func (s *seeker) Open(namespace ident.ID, shard uint32, blockStart time.Time) er
 		return errClonesShouldNotBeOpened
 	}
 
-	shardDir := ShardDataDirPath(s.filePathPrefix, namespace, shard)
+	// Seek the latest complete volume of the block since a block flushed
+	// again is written to a new volume that supersedes its earlier volumes.
+	var volumeIndex int
+	fileset, ok, err := FileSetAt(s.filePathPrefix, namespace, shard, blockStart)
+	if err != nil {
+		return err
+	}
+	if ok {
+		volumeIndex = fileset.ID.VolumeIndex
+	}
+	s.volumeIndex = volumeIndex
+
+	var (
+		shardDir = ShardDataDirPath(s.filePathPrefix, namespace, shard)
+		pathFn   = func(format FilenameTimeFormat, suffix string) string {
+			return dataFileSetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, format, suffix)
+		}
+		format   = filenameTimeFormatOf(pathFn)
+		filePath = func(suffix string) string {
+			return pathFn(format, suffix)
+		}
+	)
 	var infoFd, indexFd, dataFd, digestFd, bloomFilterFd, summariesFd *os.File
 
 	// Open necessary files
 	if err := openFiles(os.Open, map[string]**os.File{
-		filesetPathFromTime(shardDir, blockStart, infoFileSuffix):        &infoFd,
-		filesetPathFromTime(shardDir, blockStart, indexFileSuffix):       &indexFd,
-		filesetPathFromTime(shardDir, blockStart, dataFileSuffix):        &dataFd,
-		filesetPathFromTime(shardDir, blockStart, digestFileSuffix):      &digestFd,
-		filesetPathFromTime(shardDir, blockStart, bloomFilterFileSuffix): &bloomFilterFd,
-		filesetPathFromTime(shardDir, blockStart, summariesFileSuffix):   &summariesFd,
+		filePath(infoFileSuffix):        &infoFd,
+		filePath(indexFileSuffix):       &indexFd,
+		filePath(dataFileSuffix):        &dataFd,
+		filePath(digestFileSuffix):      &digestFd,
+		filePath(bloomFilterFileSuffix): &bloomFilterFd,
+		filePath(summariesFileSuffix):   &summariesFd,
 	}); err != nil {
 		return err
 	}
@@ -208,12 +232,12 @@ func (s *seeker) Open(namespace ident.ID, shard uint32, blockStart time.Time) er
 		},
 	}
 	mmapResult, err := mmap.Files(os.Open, map[string]mmap.FileDesc{
-		filesetPathFromTime(shardDir, blockStart, indexFileSuffix): mmap.FileDesc{
+		filePath(indexFileSuffix): mmap.FileDesc{
 			File:    &indexFd,
 			Bytes:   &s.indexMmap,
 			Options: mmapOptions,
 		},
-		filesetPathFromTime(shardDir, blockStart, dataFileSuffix): mmap.FileDesc{
+		filePath(dataFileSuffix): mmap.FileDesc{
 			File:    &dataFd,
 			Bytes:   &s.dataMmap,
 			Options: mmapOptions,
@@ -250,7 +274,7 @@ func (s *seeker) Open(namespace ident.ID, shard uint32, blockStart time.Time) er
 		s.Close()
 		return fmt.Errorf(
 			"index file digest for file: %s does not match the expected digest",
-			filesetPathFromTime(shardDir, blockStart, indexFileSuffix),
+			filePath(indexFileSuffix),
 		)
 	}
 
@@ -403,6 +427,10 @@ func (s *seeker) SeekIndexEntry(id ident.ID) (IndexEntry, error) {
 		}
 		comparison := bytes.Compare(entry.ID, idBytes)
 		if comparison == 0 {
+			if entry.Deleted {
+				// The series was deleted after this ID was written
+				return IndexEntry{}, errSeekIDNotFound
+			}
 			return IndexEntry{
 				Size:        uint32(entry.Size),
 				Checksum:    uint32(entry.Checksum),
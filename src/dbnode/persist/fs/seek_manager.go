@@ -51,7 +51,7 @@ type openAnyUnopenSeekersFn func(*seekersByTime) error
 type newOpenSeekerFn func(
 	shard uint32,
 	blockStart time.Time,
-) (DataFileSetSeeker, error)
+) (DataFileSetSeeker, int, error)
 
 type seekerManagerStatus int
 
@@ -94,6 +94,7 @@ type seekersAndBloom struct {
 	wg          *sync.WaitGroup
 	seekers     []borrowableSeeker
 	bloomFilter *ManagedConcurrentBloomFilter
+	volumeIndex int
 }
 
 // borrowableSeeker is just a seeker with an additional field for keeping track of whether or not it has been borrowed.
@@ -114,6 +115,12 @@ type seekerManagerPendingClose struct {
 	blockStart time.Time
 }
 
+type seekerManagerOpenVolume struct {
+	shard       uint32
+	blockStart  time.Time
+	volumeIndex int
+}
+
 // NewSeekerManager returns a new TSDB file set seeker manager.
 func NewSeekerManager(
 	bytesPool pool.CheckedBytesPool,
@@ -297,7 +304,7 @@ func (m *seekerManager) getOrOpenSeekersWithLock(start xtime.UnixNano, byTime *s
 	byTime.Unlock()
 	// Open first one - Do this outside the context of the lock because opening
 	// a seeker can be an expensive operation (validating index files)
-	seeker, err := m.newOpenSeekerFn(byTime.shard, start.ToTime())
+	seeker, volumeIndex, err := m.newOpenSeekerFn(byTime.shard, start.ToTime())
 	// Immediately re-lock once the seeker is open regardless of errors because
 	// thats the contract of this function
 	byTime.Lock()
@@ -331,6 +338,7 @@ func (m *seekerManager) getOrOpenSeekersWithLock(start xtime.UnixNano, byTime *s
 
 	seekers.wg = nil
 	seekers.seekers = borrowableSeekers
+	seekers.volumeIndex = volumeIndex
 	// Doesn't matter which seeker we pick to grab the bloom filter from, they all share the same underlying one.
 	// Use index 0 because its guaranteed to be there.
 	seekers.bloomFilter = borrowableSeekers[0].seeker.ConcurrentIDBloomFilter()
@@ -359,13 +367,13 @@ func (m *seekerManager) openAnyUnopenSeekers(byTime *seekersByTime) error {
 func (m *seekerManager) newOpenSeeker(
 	shard uint32,
 	blockStart time.Time,
-) (DataFileSetSeeker, error) {
+) (DataFileSetSeeker, int, error) {
 	exists, err := DataFileSetExistsAt(m.filePathPrefix, m.namespace, shard, blockStart)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	if !exists {
-		return nil, errSeekerManagerFileSetNotFound
+		return nil, 0, errSeekerManagerFileSetNotFound
 	}
 
 	// NB(r): Use a lock on the unread buffer to avoid multiple
@@ -390,7 +398,7 @@ func (m *seekerManager) newOpenSeeker(
 	seeker.setUnreadBuffer(m.unreadBuf.value)
 
 	if err := seeker.Open(m.namespace, shard, blockStart); err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	// Retrieve the buffer, it may have changed due to
@@ -398,7 +406,7 @@ func (m *seekerManager) newOpenSeeker(
 	m.unreadBuf.value = seeker.unreadBuffer()
 	seeker.setUnreadBuffer(nil)
 
-	return seeker, nil
+	return seeker, seeker.volumeIndex, nil
 }
 
 func (m *seekerManager) seekersByTime(shard uint32) *seekersByTime {
@@ -483,12 +491,13 @@ func (m *seekerManager) latestSeekableBlockStart() time.Time {
 	nowFn := m.opts.ClockOptions().NowFn()
 	now := nowFn()
 	ropts := m.namespaceMetadata.Options().RetentionOptions()
-	return now.Truncate(ropts.BlockSize())
+	return retention.BlockStart(ropts, now)
 }
 
 func (m *seekerManager) openCloseLoop() {
 	var (
 		shouldTryOpen []*seekersByTime
+		openVolumes   []seekerManagerOpenVolume
 		shouldClose   []seekerManagerPendingClose
 		closing       []borrowableSeeker
 	)
@@ -497,6 +506,10 @@ func (m *seekerManager) openCloseLoop() {
 			shouldTryOpen[i] = nil
 		}
 		shouldTryOpen = shouldTryOpen[:0]
+		for i := range openVolumes {
+			openVolumes[i] = seekerManagerOpenVolume{}
+		}
+		openVolumes = openVolumes[:0]
 		for i := range shouldClose {
 			shouldClose[i] = seekerManagerPendingClose{}
 		}
@@ -536,18 +549,41 @@ func (m *seekerManager) openCloseLoop() {
 		m.RLock()
 		for shard, byTime := range m.seekersByShardIdx {
 			byTime.RLock()
-			for blockStartNano := range byTime.seekers {
+			for blockStartNano, seekers := range byTime.seekers {
 				blockStart := blockStartNano.ToTime()
 				if blockStart.Before(earliestSeekableBlockStart) {
 					shouldClose = append(shouldClose, seekerManagerPendingClose{
 						shard:      uint32(shard),
 						blockStart: blockStart,
 					})
+					continue
+				}
+				if seekers.wg == nil {
+					openVolumes = append(openVolumes, seekerManagerOpenVolume{
+						shard:       uint32(shard),
+						blockStart:  blockStart,
+						volumeIndex: seekers.volumeIndex,
+					})
 				}
 			}
 			byTime.RUnlock()
 		}
+		m.RUnlock()
+
+		// Close seekers of blocks that were flushed again to a new volume so
+		// that they are reopened on the new volume, checked out of lock as
+		// this requires IO.
+		for _, elem := range openVolumes {
+			if dataFileSetVolumeExistsAt(m.filePathPrefix, m.namespace, elem.shard,
+				elem.blockStart, elem.volumeIndex+1) {
+				shouldClose = append(shouldClose, seekerManagerPendingClose{
+					shard:      elem.shard,
+					blockStart: elem.blockStart,
+				})
+			}
+		}
 
+		m.RLock()
 		if len(shouldClose) > 0 {
 			for _, elem := range shouldClose {
 				byTime := m.seekersByShardIdx[elem.shard]
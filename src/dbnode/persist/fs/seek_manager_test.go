@@ -79,7 +79,7 @@ func TestSeekerManagerBorrowOpenSeekersLazy(t *testing.T) {
 	m.newOpenSeekerFn = func(
 		shard uint32,
 		blockStart time.Time,
-	) (DataFileSetSeeker, error) {
+	) (DataFileSetSeeker, int, error) {
 		mock := NewMockDataFileSetSeeker(ctrl)
 		mock.EXPECT().Open(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 		mock.EXPECT().ConcurrentClone().Return(mock, nil)
@@ -87,7 +87,7 @@ func TestSeekerManagerBorrowOpenSeekersLazy(t *testing.T) {
 			mock.EXPECT().Close().Return(nil)
 			mock.EXPECT().ConcurrentIDBloomFilter().Return(nil)
 		}
-		return mock, nil
+		return mock, 0, nil
 	}
 	m.sleepFn = func(_ time.Duration) {
 		time.Sleep(time.Millisecond)
@@ -0,0 +1,172 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"fmt"
+	"os"
+)
+
+// SyncMode is the mode used to sync file set files to disk once written.
+type SyncMode uint
+
+const (
+	// SyncNone never syncs file set files and leaves it to the operating
+	// system to write them to disk.
+	SyncNone SyncMode = iota
+	// SyncPerFile syncs each file set file and the shard directory as soon as
+	// the file set is written.
+	SyncPerFile
+	// SyncBatched defers syncing file set files until the end of a flush,
+	// then syncs all of the files written, writes and syncs their checkpoint
+	// files and finally syncs each of their directories once. File sets
+	// written during a flush are not checkpointed, and so not readable,
+	// until the flush completes.
+	SyncBatched
+
+	// DefaultSyncMode is the default sync mode.
+	DefaultSyncMode = SyncNone
+)
+
+// ValidSyncModes returns the valid sync modes.
+func ValidSyncModes() []SyncMode {
+	return []SyncMode{SyncNone, SyncPerFile, SyncBatched}
+}
+
+func (m SyncMode) String() string {
+	switch m {
+	case SyncNone:
+		return "none"
+	case SyncPerFile:
+		return "per_file"
+	case SyncBatched:
+		return "batched"
+	}
+	return "unknown"
+}
+
+// ValidateSyncMode validates a sync mode.
+func ValidateSyncMode(v SyncMode) error {
+	for _, valid := range ValidSyncModes() {
+		if valid == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid SyncMode '%d' valid types are: %v",
+		uint(v), ValidSyncModes())
+}
+
+// SyncFn syncs the file or directory at the path to disk.
+type SyncFn func(path string) error
+
+func syncPath(path string) error {
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	if err := fd.Sync(); err != nil {
+		// NB: The sync error takes precedence over failing to close
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+// pendingFileSyncer is implemented by writers that defer syncing the files
+// they write until syncPending is called at the end of a flush.
+type pendingFileSyncer interface {
+	syncPending() error
+}
+
+// fileSyncer syncs written files and directories as specified by the sync mode.
+type fileSyncer struct {
+	mode   SyncMode
+	syncFn SyncFn
+
+	pendingFiles []string
+	pendingDirs  []string
+}
+
+func newFileSyncer(mode SyncMode, syncFn SyncFn) *fileSyncer {
+	return &fileSyncer{mode: mode, syncFn: syncFn}
+}
+
+// syncFiles syncs the files now when syncing per file, or defers syncing
+// them until syncPending when batching.
+func (s *fileSyncer) syncFiles(paths ...string) error {
+	switch s.mode {
+	case SyncPerFile:
+		return s.syncAll(paths)
+	case SyncBatched:
+		s.pendingFiles = append(s.pendingFiles, paths...)
+	}
+	return nil
+}
+
+// syncDir syncs the directory now when syncing per file, or defers syncing
+// it until syncPending when batching, a directory is only synced once per batch.
+func (s *fileSyncer) syncDir(dir string) error {
+	switch s.mode {
+	case SyncPerFile:
+		return s.syncFn(dir)
+	case SyncBatched:
+		for _, pending := range s.pendingDirs {
+			if pending == dir {
+				return nil
+			}
+		}
+		s.pendingDirs = append(s.pendingDirs, dir)
+	}
+	return nil
+}
+
+// syncPendingFiles syncs the deferred files, a file that fails to sync is
+// kept pending along with the files after it so they are retried.
+func (s *fileSyncer) syncPendingFiles() error {
+	return s.syncPendingPaths(&s.pendingFiles)
+}
+
+// syncPendingDirs syncs the deferred directories, a directory that fails to
+// sync is kept pending along with the directories after it so they are retried.
+func (s *fileSyncer) syncPendingDirs() error {
+	return s.syncPendingPaths(&s.pendingDirs)
+}
+
+func (s *fileSyncer) syncPendingPaths(pending *[]string) error {
+	paths := *pending
+	for i, path := range paths {
+		if err := s.syncFn(path); err != nil {
+			*pending = append(paths[:0], paths[i:]...)
+			return err
+		}
+	}
+	*pending = paths[:0]
+	return nil
+}
+
+func (s *fileSyncer) syncAll(paths []string) error {
+	for _, path := range paths {
+		if err := s.syncFn(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
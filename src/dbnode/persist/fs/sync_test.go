@@ -0,0 +1,238 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+
+	"github.com/stretchr/testify/require"
+)
+
+// numFileSetSyncFiles is the number of files synced for each file set
+// written, the info, index, summaries, bloom filter, data, digest and
+// checkpoint files.
+const numFileSetSyncFiles = 7
+
+type testSyncCounter struct {
+	paths []string
+	errs  map[string]error
+}
+
+func (c *testSyncCounter) sync(path string) error {
+	if err, ok := c.errs[path]; ok {
+		delete(c.errs, path)
+		return err
+	}
+	c.paths = append(c.paths, path)
+	return nil
+}
+
+func (c *testSyncCounter) checkpointPaths() []string {
+	var paths []string
+	for _, p := range c.paths {
+		if strings.HasSuffix(p, checkpointFileSuffix+fileSuffix) {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+func (c *testSyncCounter) count(path string) int {
+	n := 0
+	for _, p := range c.paths {
+		if p == path {
+			n++
+		}
+	}
+	return n
+}
+
+func newTestSyncWriter(
+	t *testing.T,
+	filePathPrefix string,
+	mode SyncMode,
+	counter *testSyncCounter,
+) DataFileSetWriter {
+	w, err := NewWriter(testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetSyncMode(mode).
+		SetSyncFn(counter.sync))
+	require.NoError(t, err)
+	return w
+}
+
+func writeTestSyncFileSets(t *testing.T, w DataFileSetWriter, numBlocks int) {
+	for i := 0; i < numBlocks; i++ {
+		blockStart := testWriterStart.Add(time.Duration(i) * testBlockSize)
+		writeTestData(t, w, 0, blockStart, []testEntry{
+			{"foo", nil, []byte{1, 2, 3}},
+		}, persist.FileSetFlushType)
+	}
+}
+
+func TestWriterSyncModes(t *testing.T) {
+	const numBlocks = 3
+
+	tests := []struct {
+		mode          SyncMode
+		expectedFiles int
+		expectedDirs  int
+	}{
+		{mode: SyncNone},
+		{mode: SyncPerFile, expectedFiles: numBlocks * numFileSetSyncFiles, expectedDirs: numBlocks},
+		{mode: SyncBatched},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode.String(), func(t *testing.T) {
+			dir := createTempDir(t)
+			filePathPrefix := filepath.Join(dir, "")
+			defer os.RemoveAll(dir)
+
+			counter := &testSyncCounter{}
+			w := newTestSyncWriter(t, filePathPrefix, test.mode, counter)
+			writeTestSyncFileSets(t, w, numBlocks)
+
+			shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+			dirs := counter.count(shardDir)
+			require.Equal(t, test.expectedDirs, dirs)
+			require.Equal(t, test.expectedFiles, len(counter.paths)-dirs)
+		})
+	}
+}
+
+func TestWriterSyncBatchedSyncsPending(t *testing.T) {
+	const numBlocks = 3
+
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	counter := &testSyncCounter{}
+	w := newTestSyncWriter(t, filePathPrefix, SyncBatched, counter)
+	writeTestSyncFileSets(t, w, numBlocks)
+	require.Empty(t, counter.paths)
+
+	// File sets are not checkpointed until their files are synced
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	checkpoints, err := filepath.Glob(filepath.Join(shardDir, "*"+checkpointFileSuffix+fileSuffix))
+	require.NoError(t, err)
+	require.Empty(t, checkpoints)
+
+	// Files are synced once each, then the checkpoint files are written and
+	// synced followed by a single sync of their directory
+	require.NoError(t, w.(pendingFileSyncer).syncPending())
+	require.Equal(t, numBlocks*numFileSetSyncFiles+1, len(counter.paths))
+
+	checkpoints = counter.checkpointPaths()
+	require.Equal(t, numBlocks, len(checkpoints))
+	for i, p := range checkpoints {
+		_, err := os.Stat(p)
+		require.NoError(t, err)
+		require.Equal(t, p, counter.paths[numBlocks*(numFileSetSyncFiles-1)+i])
+	}
+	require.Equal(t, 1, counter.count(shardDir))
+	require.Equal(t, shardDir, counter.paths[len(counter.paths)-1])
+
+	// Nothing is left pending once synced
+	counter.paths = nil
+	require.NoError(t, w.(pendingFileSyncer).syncPending())
+	require.Empty(t, counter.paths)
+}
+
+func TestWriterSyncBatchedRetriesFailedSyncs(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	counter := &testSyncCounter{}
+	w := newTestSyncWriter(t, filePathPrefix, SyncBatched, counter)
+	writeTestSyncFileSets(t, w, 1)
+
+	// Fail to sync the data file, nothing is checkpointed
+	var dataFilePath string
+	writer := w.(*writer)
+	for _, p := range writer.filePaths {
+		if strings.HasSuffix(p, dataFileSuffix+fileSuffix) {
+			dataFilePath = p
+		}
+	}
+	require.NotEmpty(t, dataFilePath)
+	counter.errs = map[string]error{dataFilePath: errors.New("sync failed")}
+	require.Error(t, writer.syncPending())
+	require.Empty(t, counter.checkpointPaths())
+
+	_, err := os.Stat(writer.checkpointFilePath)
+	require.True(t, os.IsNotExist(err))
+
+	// The failed file and those after it are retried before checkpointing
+	require.NoError(t, writer.syncPending())
+	require.Equal(t, 1, counter.count(dataFilePath))
+	require.Equal(t, []string{writer.checkpointFilePath}, counter.checkpointPaths())
+	require.Equal(t, numFileSetSyncFiles+1, len(counter.paths))
+
+	_, err = os.Stat(writer.checkpointFilePath)
+	require.NoError(t, err)
+}
+
+func TestPersistManagerDoneDataSyncsPending(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	counter := &testSyncCounter{}
+	opts := testDefaultOpts.
+		SetFilePathPrefix(dir).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetSyncMode(SyncBatched).
+		SetSyncFn(counter.sync)
+	pm, err := NewPersistManager(opts)
+	require.NoError(t, err)
+
+	flush, err := pm.StartDataPersist()
+	require.NoError(t, err)
+
+	prepared, err := flush.PrepareData(persist.DataPrepareOptions{
+		NamespaceMetadata: testNs1Metadata(t),
+		Shard:             0,
+		BlockStart:        testWriterStart,
+	})
+	require.NoError(t, err)
+	require.NoError(t, prepared.Close())
+	require.Empty(t, counter.paths)
+
+	require.NoError(t, flush.DoneData())
+	require.Equal(t, numFileSetSyncFiles+1, len(counter.paths))
+}
+
+func TestValidateSyncMode(t *testing.T) {
+	for _, mode := range ValidSyncModes() {
+		require.NoError(t, ValidateSyncMode(mode))
+	}
+	require.Error(t, ValidateSyncMode(SyncMode(len(ValidSyncModes()))))
+}
@@ -39,6 +39,7 @@ import (
 	"github.com/m3db/m3x/ident"
 	"github.com/m3db/m3x/instrument"
 	"github.com/m3db/m3x/pool"
+	xretry "github.com/m3db/m3x/retry"
 	xtime "github.com/m3db/m3x/time"
 )
 
@@ -69,8 +70,29 @@ type DataWriterSnapshotOptions struct {
 	SnapshotTime time.Time
 }
 
+// WriteErrorFn is called with the ID of each series that fails to be written to a
+// file set along with the error, the ID must not be retained after the call returns
+type WriteErrorFn func(id ident.ID, err error)
+
+// WriterOpenLimiter bounds the number of file set writers that are open at once
+// so that many concurrent flushes do not exhaust the file descriptor limit
+type WriterOpenLimiter interface {
+	// Acquire reserves a slot for a writer being opened, returning an error
+	// if no slot is available and the limiter does not wait for one
+	Acquire() error
+
+	// Release returns a slot reserved by a writer that has been closed
+	Release()
+
+	// MaxOpen returns the max number of writers that can be open at once
+	MaxOpen() int
+}
+
 // DataFileSetWriter provides an unsynchronized writer for a TSDB file set
 type DataFileSetWriter interface {
+	// Close commits the file set. Closing a writer that received no writes still
+	// commits an empty file set with info and checkpoint files so that a block which
+	// was flushed with no series can be told apart from one that was never flushed.
 	io.Closer
 
 	// Open opens the files for writing data to the given shard in the given namespace.
@@ -84,8 +106,28 @@ type DataFileSetWriter interface {
 	Write(id ident.ID, tags ident.Tags, data checked.Bytes, checksum uint32) error
 
 	// WriteAll will write the id and all byte slices and returns an error on a write error.
-	// Callers must not call this method with a given ID more than once.
+	// Callers must not call this method with a given ID more than once. Entries with an
+	// empty ID or an ID exceeding the max key bytes are rejected without failing the writer.
 	WriteAll(id ident.ID, tags ident.Tags, data []checked.Bytes, checksum uint32) error
+
+	// WriteAllWithTimestamps is WriteAll for data whose datapoints have timestamps
+	// within [minTimestamp, maxTimestamp]. The earliest and latest timestamps of
+	// all such writes are stored in the info file so readers can skip the file set
+	// for queries it does not intersect, if none are written the bounds of the
+	// block are stored instead.
+	WriteAllWithTimestamps(
+		id ident.ID,
+		tags ident.Tags,
+		data []checked.Bytes,
+		checksum uint32,
+		minTimestamp time.Time,
+		maxTimestamp time.Time,
+	) error
+
+	// Delete will write a tombstone for the id marking the series as deleted so that
+	// readers and compactions skip it. Callers must not call this method with an ID
+	// that is also written.
+	Delete(id ident.ID) error
 }
 
 // DataFileSetReaderStatus describes the status of a file set reader
@@ -93,8 +135,9 @@ type DataFileSetReaderStatus struct {
 	Namespace  ident.ID
 	BlockStart time.Time
 
-	Shard uint32
-	Open  bool
+	Shard       uint32
+	VolumeIndex int
+	Open        bool
 }
 
 // DataReaderOpenOptions is options struct for the reader open method.
@@ -359,6 +402,14 @@ type Options interface {
 	// NewDirectoryMode returns the new directory mode
 	NewDirectoryMode() os.FileMode
 
+	// SetFilenameTimeFormat sets the format used to encode block start times
+	// in the names of data file set files written
+	SetFilenameTimeFormat(value FilenameTimeFormat) Options
+
+	// FilenameTimeFormat returns the format used to encode block start times
+	// in the names of data file set files written
+	FilenameTimeFormat() FilenameTimeFormat
+
 	// SetIndexSummariesPercent size sets the percent of index summaries to write
 	SetIndexSummariesPercent(value float64) Options
 
@@ -379,6 +430,48 @@ type Options interface {
 	// WriterBufferSize returns the buffer size for writing TSDB files
 	WriterBufferSize() int
 
+	// SetWriterMaxKeyBytes sets the max size of keys written to TSDB files, zero means no limit
+	SetWriterMaxKeyBytes(value int) Options
+
+	// WriterMaxKeyBytes returns the max size of keys written to TSDB files, zero means no limit
+	WriterMaxKeyBytes() int
+
+	// SetWriterRetryOptions sets the retry options for data file writes that
+	// fail with a transient error, non-transient errors are never retried
+	SetWriterRetryOptions(value xretry.Options) Options
+
+	// WriterRetryOptions returns the retry options for data file writes that
+	// fail with a transient error, non-transient errors are never retried
+	WriterRetryOptions() xretry.Options
+
+	// SetWriterErrorFn sets the function called with the ID and error of each series that
+	// fails to be written, allowing callers to sample or aggregate write errors
+	SetWriterErrorFn(value WriteErrorFn) Options
+
+	// WriterErrorFn returns the function called with the ID and error of each series that
+	// fails to be written, allowing callers to sample or aggregate write errors
+	WriterErrorFn() WriteErrorFn
+
+	// SetWriterOpenLimiter sets the limiter shared by writers to bound the number of
+	// writers open at once, nil means no limit
+	SetWriterOpenLimiter(value WriterOpenLimiter) Options
+
+	// WriterOpenLimiter returns the limiter shared by writers to bound the number of
+	// writers open at once, nil means no limit
+	WriterOpenLimiter() WriterOpenLimiter
+
+	// SetSyncMode sets the mode used to sync written file set files to disk
+	SetSyncMode(value SyncMode) Options
+
+	// SyncMode returns the mode used to sync written file set files to disk
+	SyncMode() SyncMode
+
+	// SetSyncFn sets the function used to sync written files and directories to disk
+	SetSyncFn(value SyncFn) Options
+
+	// SyncFn returns the function used to sync written files and directories to disk
+	SyncFn() SyncFn
+
 	// SetInfoReaderBufferSize sets the buffer size for reading TSDB info, digest and checkpoint files
 	SetInfoReaderBufferSize(value int) Options
 
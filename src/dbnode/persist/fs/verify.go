@@ -0,0 +1,201 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist"
+
+	"github.com/m3db/m3x/ident"
+)
+
+const (
+	// numFileSetDigests is the number of digests stored in a data fileset
+	// digest file, one for each of info, index, summaries, bloom filter and data.
+	numFileSetDigests = 5
+)
+
+var (
+	errVerifyMissingFile        = errors.New("fileset file does not exist")
+	errVerifyDigestFileTooShort = errors.New("digest file does not contain all fileset digests")
+)
+
+// VerificationError describes a single problem found with a fileset on disk.
+type VerificationError struct {
+	// ID identifies the fileset the problem was found in.
+	ID FileSetFileIdentifier
+	// Filepath is the path of the file the problem relates to.
+	Filepath string
+	// Err is the underlying problem.
+	Err error
+}
+
+// Error returns a description of the problem.
+func (e VerificationError) Error() string {
+	return fmt.Sprintf("namespace %s, shard %d, block %s, volume %d: %s: %v",
+		e.ID.Namespace.String(), e.ID.Shard, e.ID.BlockStart.String(),
+		e.ID.VolumeIndex, e.Filepath, e.Err)
+}
+
+// Verify walks every data fileset of every namespace and shard beneath the
+// file path prefix and checks that all of the fileset files are present and
+// that their contents match the digests recorded when they were written. All
+// problems found are returned rather than stopping at the first, the returned
+// error is only non-nil if the directory tree itself could not be walked.
+func Verify(filePathPrefix string) ([]VerificationError, error) {
+	namespaceDirs, err := findSubDirectoriesAndPaths(DataDirPath(filePathPrefix))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var verifyErrs []VerificationError
+	for _, namespace := range sortedDirectoryNames(namespaceDirs) {
+		shardDirs, err := findSubDirectoriesAndPaths(namespaceDirs[namespace])
+		if err != nil {
+			return nil, err
+		}
+
+		namespaceID := ident.StringID(namespace)
+		for _, shardDir := range sortedDirectoryNames(shardDirs) {
+			shard, err := strconv.ParseUint(shardDir, 10, 32)
+			if err != nil {
+				// Not a shard directory.
+				continue
+			}
+
+			matched, err := filesetFiles(filesetFilesSelector{
+				fileSetType:    persist.FileSetFlushType,
+				contentType:    persist.FileSetDataContentType,
+				filePathPrefix: filePathPrefix,
+				namespace:      namespaceID,
+				shard:          uint32(shard),
+				pattern:        filesetFilePattern,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, fileset := range matched {
+				var format FilenameTimeFormat
+				if len(fileset.AbsoluteFilepaths) > 0 {
+					format = filenameTimeFormatFromPath(fileset.AbsoluteFilepaths[0])
+				}
+				verifyErrs = append(verifyErrs, verifyFileSet(filePathPrefix, fileset.ID, format)...)
+			}
+		}
+	}
+
+	return verifyErrs, nil
+}
+
+func verifyFileSet(
+	filePathPrefix string,
+	id FileSetFileIdentifier,
+	format FilenameTimeFormat,
+) []VerificationError {
+	var (
+		shardDir = ShardDataDirPath(filePathPrefix, id.Namespace, id.Shard)
+		filePath = func(suffix string) string {
+			return dataFileSetPathFromTimeIndexAndFormat(shardDir, id.BlockStart,
+				id.VolumeIndex, format, suffix)
+		}
+		// NB: Ordered to match the order of digests in the digest file.
+		digestedFilepaths = []string{
+			filePath(infoFileSuffix),
+			filePath(indexFileSuffix),
+			filePath(summariesFileSuffix),
+			filePath(bloomFilterFileSuffix),
+			filePath(dataFileSuffix),
+		}
+		checkpointFilepath = filePath(checkpointFileSuffix)
+		digestFilepath     = filePath(digestFileSuffix)
+		verifyErrs         []VerificationError
+	)
+	addErr := func(filePath string, err error) {
+		verifyErrs = append(verifyErrs, VerificationError{
+			ID:       id,
+			Filepath: filePath,
+			Err:      err,
+		})
+	}
+
+	for _, filePath := range append(digestedFilepaths, digestFilepath, checkpointFilepath) {
+		if !FileExists(filePath) {
+			addErr(filePath, errVerifyMissingFile)
+		}
+	}
+	if len(verifyErrs) > 0 {
+		// Without the full set of files the digests cannot be validated.
+		return verifyErrs
+	}
+
+	checkpointFd, err := os.Open(checkpointFilepath)
+	if err != nil {
+		addErr(checkpointFilepath, err)
+		return verifyErrs
+	}
+	digestBuf := digest.NewBuffer()
+	expectedDigestOfDigest, err := digestBuf.ReadDigestFromFile(checkpointFd)
+	checkpointFd.Close()
+	if err != nil {
+		addErr(checkpointFilepath, err)
+		return verifyErrs
+	}
+
+	digestData, err := readAndValidate(digestFilepath,
+		defaultInfoReaderBufferSize, expectedDigestOfDigest)
+	if err != nil {
+		addErr(digestFilepath, err)
+		return verifyErrs
+	}
+	if len(digestData) < numFileSetDigests*len(digestBuf) {
+		addErr(digestFilepath, errVerifyDigestFileTooShort)
+		return verifyErrs
+	}
+
+	for i, filePath := range digestedFilepaths {
+		expectedDigest := digest.ToBuffer(digestData[i*len(digestBuf):]).ReadDigest()
+		if _, err := readAndValidate(filePath,
+			defaultDataReaderBufferSize, expectedDigest); err != nil {
+			addErr(filePath, err)
+		}
+	}
+
+	return verifyErrs
+}
+
+func sortedDirectoryNames(dirs directoryNamesToPaths) []string {
+	names := make([]string, 0, len(dirs))
+	for name := range dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeVerifyTestData(t *testing.T, filePathPrefix string) {
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+	}
+
+	w := newTestWriter(t, filePathPrefix)
+	for shard := uint32(0); shard < 2; shard++ {
+		for i := 0; i < 2; i++ {
+			blockStart := testWriterStart.Add(testBlockSize * time.Duration(i))
+			writeTestData(t, w, shard, blockStart, entries, persist.FileSetFlushType)
+		}
+	}
+}
+
+func TestVerifyNoDataDirectory(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	verifyErrs, err := Verify(dir)
+	require.NoError(t, err)
+	require.Empty(t, verifyErrs)
+}
+
+func TestVerifyValidFileSets(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	writeVerifyTestData(t, dir)
+
+	verifyErrs, err := Verify(dir)
+	require.NoError(t, err)
+	require.Empty(t, verifyErrs)
+}
+
+func TestVerifyCorruptFileSet(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	writeVerifyTestData(t, dir)
+
+	// Corrupt the data file of a single block.
+	corruptBlockStart := testWriterStart.Add(testBlockSize)
+	shardDir := ShardDataDirPath(dir, testNs1ID, 1)
+	dataFilepath := dataFileSetPathFromTimeAndIndex(shardDir, corruptBlockStart, 0, dataFileSuffix)
+	data, err := ioutil.ReadFile(dataFilepath)
+	require.NoError(t, err)
+	data[0] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(dataFilepath, data, defaultNewFileMode))
+
+	verifyErrs, err := Verify(dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(verifyErrs))
+	require.True(t, testNs1ID.Equal(verifyErrs[0].ID.Namespace))
+	require.Equal(t, uint32(1), verifyErrs[0].ID.Shard)
+	require.True(t, corruptBlockStart.Equal(verifyErrs[0].ID.BlockStart))
+	require.Equal(t, dataFilepath, verifyErrs[0].Filepath)
+	require.Error(t, verifyErrs[0].Err)
+}
+
+func TestVerifyMissingFiles(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	writeVerifyTestData(t, dir)
+
+	shardDir := ShardDataDirPath(dir, testNs1ID, 0)
+	checkpointFilepath := dataFileSetPathFromTimeAndIndex(shardDir, testWriterStart, 0, checkpointFileSuffix)
+	indexFilepath := dataFileSetPathFromTimeAndIndex(shardDir, testWriterStart, 0, indexFileSuffix)
+	require.NoError(t, os.Remove(checkpointFilepath))
+	require.NoError(t, os.Remove(indexFilepath))
+
+	verifyErrs, err := Verify(dir)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(verifyErrs))
+	for _, verifyErr := range verifyErrs {
+		require.Equal(t, uint32(0), verifyErr.ID.Shard)
+		require.True(t, testWriterStart.Equal(verifyErr.ID.BlockStart))
+		require.Equal(t, errVerifyMissingFile, verifyErr.Err)
+	}
+	require.Equal(t, indexFilepath, verifyErrs[0].Filepath)
+	require.Equal(t, checkpointFilepath, verifyErrs[1].Filepath)
+}
@@ -24,6 +24,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"sort"
@@ -37,12 +38,17 @@ import (
 	"github.com/m3db/m3/src/dbnode/serialize"
 	"github.com/m3db/m3x/checked"
 	"github.com/m3db/m3x/ident"
+	xretry "github.com/m3db/m3x/retry"
 	xtime "github.com/m3db/m3x/time"
 )
 
 var (
 	errWriterEncodeTagsDataNotAccessible = errors.New(
 		"failed to encode tags: cannot get data")
+	errWriterInvalidKeyEmpty = errors.New(
+		"invalid key: key is empty")
+	errWriterInvalidKeyTooLong = errors.New(
+		"invalid key: key exceeds max key bytes")
 )
 
 type writer struct {
@@ -50,6 +56,12 @@ type writer struct {
 	filePathPrefix   string
 	newFileMode      os.FileMode
 	newDirectoryMode os.FileMode
+	maxKeyBytes      int
+	writeErrorFn     WriteErrorFn
+	openLimiter      WriterOpenLimiter
+	syncer           *fileSyncer
+
+	filenameTimeFormat FilenameTimeFormat
 
 	summariesPercent                float64
 	bloomFilterFalsePositivePercent float64
@@ -61,10 +73,15 @@ type writer struct {
 	dataFdWithDigest           digest.FdWithDigestWriter
 	digestFdWithDigestContents digest.FdWithDigestContentsWriter
 	checkpointFilePath         string
+	shardDir                   string
+	filePaths                  []string
+	pendingCheckpoints         []pendingCheckpoint
 	indexEntries               indexEntries
 
 	start              time.Time
 	snapshotTime       time.Time
+	minTimestamp       time.Time
+	maxTimestamp       time.Time
 	currIdx            int64
 	currOffset         int64
 	encoder            *msgpack.Encoder
@@ -72,6 +89,14 @@ type writer struct {
 	singleCheckedBytes []checked.Bytes
 	tagEncoderPool     serialize.TagEncoderPool
 	err                error
+	holdsOpenSlot      bool
+}
+
+// pendingCheckpoint is a checkpoint file deferred until the files of its
+// file set have been synced.
+type pendingCheckpoint struct {
+	filePath       string
+	digestChecksum uint32
 }
 
 type indexEntry struct {
@@ -82,6 +107,7 @@ type indexEntry struct {
 	indexFileOffset int64
 	size            uint32
 	checksum        uint32
+	deleted         bool
 }
 
 type indexEntries []indexEntry
@@ -109,18 +135,29 @@ func NewWriter(opts Options) (DataFileSetWriter, error) {
 	if err := opts.Validate(); err != nil {
 		return nil, err
 	}
-	bufferSize := opts.WriterBufferSize()
+	var (
+		bufferSize   = opts.WriterBufferSize()
+		retrier      = xretry.NewRetrier(opts.WriterRetryOptions())
+		fileWriterFn = func(fd *os.File) io.Writer {
+			return newRetryWriter(fd, retrier)
+		}
+	)
 	return &writer{
 		filePathPrefix:                  opts.FilePathPrefix(),
 		newFileMode:                     opts.NewFileMode(),
 		newDirectoryMode:                opts.NewDirectoryMode(),
+		maxKeyBytes:                     opts.WriterMaxKeyBytes(),
+		writeErrorFn:                    opts.WriterErrorFn(),
+		openLimiter:                     opts.WriterOpenLimiter(),
+		syncer:                          newFileSyncer(opts.SyncMode(), opts.SyncFn()),
+		filenameTimeFormat:              opts.FilenameTimeFormat(),
 		summariesPercent:                opts.IndexSummariesPercent(),
 		bloomFilterFalsePositivePercent: opts.IndexBloomFilterFalsePositivePercent(),
 		infoFdWithDigest:                digest.NewFdWithDigestWriter(bufferSize),
 		indexFdWithDigest:               digest.NewFdWithDigestWriter(bufferSize),
 		summariesFdWithDigest:           digest.NewFdWithDigestWriter(bufferSize),
 		bloomFilterFdWithDigest:         digest.NewFdWithDigestWriter(bufferSize),
-		dataFdWithDigest:                digest.NewFdWithDigestWriter(bufferSize),
+		dataFdWithDigest:                digest.NewFdWithDigestWriterWithFileWriterFn(bufferSize, fileWriterFn),
 		digestFdWithDigestContents:      digest.NewFdWithDigestContentsWriter(bufferSize),
 		encoder:                         msgpack.NewEncoder(),
 		digestBuf:                       digest.NewBuffer(),
@@ -132,18 +169,52 @@ func NewWriter(opts Options) (DataFileSetWriter, error) {
 // Open initializes the internal state for writing to the given shard,
 // specifically creating the shard directory if it doesn't exist, and
 // opening / truncating files associated with that shard for writing.
+// If the writer has an open limiter Open first waits for, or fails
+// without, a slot which is held until the writer is closed.
 func (w *writer) Open(opts DataWriterOpenOptions) error {
+	if err := w.acquireOpenSlot(); err != nil {
+		return err
+	}
+	if err := w.open(opts); err != nil {
+		w.releaseOpenSlot()
+		return err
+	}
+	return nil
+}
+
+func (w *writer) acquireOpenSlot() error {
+	if w.openLimiter == nil || w.holdsOpenSlot {
+		return nil
+	}
+	if err := w.openLimiter.Acquire(); err != nil {
+		return err
+	}
+	w.holdsOpenSlot = true
+	return nil
+}
+
+func (w *writer) releaseOpenSlot() {
+	if !w.holdsOpenSlot {
+		return
+	}
+	w.openLimiter.Release()
+	w.holdsOpenSlot = false
+}
+
+func (w *writer) open(opts DataWriterOpenOptions) error {
 	var (
-		nextSnapshotIndex int
-		err               error
-		namespace         = opts.Identifier.Namespace
-		shard             = opts.Identifier.Shard
-		blockStart        = opts.Identifier.BlockStart
+		volumeIndex = opts.Identifier.VolumeIndex
+		err         error
+		namespace   = opts.Identifier.Namespace
+		shard       = opts.Identifier.Shard
+		blockStart  = opts.Identifier.BlockStart
 	)
 
 	w.blockSize = opts.BlockSize
 	w.start = blockStart
 	w.snapshotTime = opts.Snapshot.SnapshotTime
+	w.minTimestamp = time.Time{}
+	w.maxTimestamp = time.Time{}
 	w.currIdx = 0
 	w.currOffset = 0
 	w.err = nil
@@ -166,27 +237,26 @@ func (w *writer) Open(opts DataWriterOpenOptions) error {
 			return err
 		}
 
-		nextSnapshotIndex = opts.Identifier.VolumeIndex
-		w.checkpointFilePath = filesetPathFromTimeAndIndex(shardDir, blockStart, nextSnapshotIndex, checkpointFileSuffix)
-		infoFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, nextSnapshotIndex, infoFileSuffix)
-		indexFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, nextSnapshotIndex, indexFileSuffix)
-		summariesFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, nextSnapshotIndex, summariesFileSuffix)
-		bloomFilterFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, nextSnapshotIndex, bloomFilterFileSuffix)
-		dataFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, nextSnapshotIndex, dataFileSuffix)
-		digestFilepath = filesetPathFromTimeAndIndex(shardDir, blockStart, nextSnapshotIndex, digestFileSuffix)
+		w.checkpointFilePath = filesetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, checkpointFileSuffix)
+		infoFilepath = filesetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, infoFileSuffix)
+		indexFilepath = filesetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, indexFileSuffix)
+		summariesFilepath = filesetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, summariesFileSuffix)
+		bloomFilterFilepath = filesetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, bloomFilterFileSuffix)
+		dataFilepath = filesetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, dataFileSuffix)
+		digestFilepath = filesetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, digestFileSuffix)
 	case persist.FileSetFlushType:
 		shardDir = ShardDataDirPath(w.filePathPrefix, namespace, shard)
 		if err := os.MkdirAll(shardDir, w.newDirectoryMode); err != nil {
 			return err
 		}
 
-		w.checkpointFilePath = filesetPathFromTime(shardDir, blockStart, checkpointFileSuffix)
-		infoFilepath = filesetPathFromTime(shardDir, blockStart, infoFileSuffix)
-		indexFilepath = filesetPathFromTime(shardDir, blockStart, indexFileSuffix)
-		summariesFilepath = filesetPathFromTime(shardDir, blockStart, summariesFileSuffix)
-		bloomFilterFilepath = filesetPathFromTime(shardDir, blockStart, bloomFilterFileSuffix)
-		dataFilepath = filesetPathFromTime(shardDir, blockStart, dataFileSuffix)
-		digestFilepath = filesetPathFromTime(shardDir, blockStart, digestFileSuffix)
+		w.checkpointFilePath = dataFileSetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, checkpointFileSuffix)
+		infoFilepath = dataFileSetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, infoFileSuffix)
+		indexFilepath = dataFileSetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, indexFileSuffix)
+		summariesFilepath = dataFileSetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, summariesFileSuffix)
+		bloomFilterFilepath = dataFileSetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, bloomFilterFileSuffix)
+		dataFilepath = dataFileSetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, dataFileSuffix)
+		digestFilepath = dataFileSetPathFromTimeIndexAndFormat(shardDir, blockStart, volumeIndex, w.filenameTimeFormat, digestFileSuffix)
 	default:
 		return fmt.Errorf("unable to open reader with fileset type: %s", opts.FileSetType)
 	}
@@ -206,6 +276,15 @@ func (w *writer) Open(opts DataWriterOpenOptions) error {
 		return err
 	}
 
+	w.shardDir = shardDir
+	w.filePaths = append(w.filePaths[:0],
+		infoFilepath,
+		indexFilepath,
+		summariesFilepath,
+		bloomFilterFilepath,
+		dataFilepath,
+		digestFilepath,
+	)
 	w.infoFdWithDigest.Reset(infoFd)
 	w.indexFdWithDigest.Reset(indexFd)
 	w.summariesFdWithDigest.Reset(summariesFd)
@@ -248,13 +327,88 @@ func (w *writer) WriteAll(
 		return w.err
 	}
 
+	// NB: Invalid keys are rejected before anything is written so they
+	// do not fail the rest of the fileset
+	if err := w.validateKey(id); err != nil {
+		w.reportError(id, err)
+		return err
+	}
+
 	if err := w.writeAll(id, tags, data, checksum); err != nil {
+		w.reportError(id, err)
 		w.err = err
 		return err
 	}
 	return nil
 }
 
+func (w *writer) WriteAllWithTimestamps(
+	id ident.ID,
+	tags ident.Tags,
+	data []checked.Bytes,
+	checksum uint32,
+	minTimestamp time.Time,
+	maxTimestamp time.Time,
+) error {
+	prevIdx := w.currIdx
+	if err := w.WriteAll(id, tags, data, checksum); err != nil {
+		return err
+	}
+	if w.currIdx == prevIdx {
+		// Nothing was written for empty data
+		return nil
+	}
+	if w.minTimestamp.IsZero() || minTimestamp.Before(w.minTimestamp) {
+		w.minTimestamp = minTimestamp
+	}
+	if maxTimestamp.After(w.maxTimestamp) {
+		w.maxTimestamp = maxTimestamp
+	}
+	return nil
+}
+
+func (w *writer) Delete(id ident.ID) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if err := w.validateKey(id); err != nil {
+		w.reportError(id, err)
+		return err
+	}
+
+	// NB: Tombstones have no data, they only occupy an entry in the index
+	w.indexEntries = append(w.indexEntries, indexEntry{
+		index:          w.currIdx,
+		id:             id,
+		dataFileOffset: w.currOffset,
+		deleted:        true,
+	})
+	w.currIdx++
+
+	return nil
+}
+
+// reportError passes the error for the series to the write error callback.
+// Writes rejected because the writer already failed are not reported since
+// the series that caused the failure was already reported.
+func (w *writer) reportError(id ident.ID, err error) {
+	if w.writeErrorFn != nil {
+		w.writeErrorFn(id, err)
+	}
+}
+
+func (w *writer) validateKey(id ident.ID) error {
+	n := len(id.Bytes())
+	if n == 0 {
+		return errWriterInvalidKeyEmpty
+	}
+	if w.maxKeyBytes > 0 && n > w.maxKeyBytes {
+		return errWriterInvalidKeyTooLong
+	}
+	return nil
+}
+
 func (w *writer) writeAll(
 	id ident.ID,
 	tags ident.Tags,
@@ -296,6 +450,9 @@ func (w *writer) writeAll(
 }
 
 func (w *writer) Close() error {
+	// NB: The files are closed or abandoned by Close even when it fails
+	defer w.releaseOpenSlot()
+
 	err := w.close()
 	if w.err != nil {
 		return w.err
@@ -304,15 +461,76 @@ func (w *writer) Close() error {
 		w.err = err
 		return err
 	}
+	// NB: Sync the files before writing the checkpoint file when syncing per
+	// file so that a checkpointed file set is never missing data after a crash.
+	if err := w.syncer.syncFiles(w.filePaths...); err != nil {
+		w.err = err
+		return err
+	}
+	digestChecksum := w.digestFdWithDigestContents.Digest().Sum32()
+	if w.syncer.mode == SyncBatched {
+		// NB: Defer the checkpoint file until the batched files are synced
+		// so that a checkpointed file set is never missing data after a crash.
+		w.pendingCheckpoints = append(w.pendingCheckpoints, pendingCheckpoint{
+			filePath:       w.checkpointFilePath,
+			digestChecksum: digestChecksum,
+		})
+		return w.syncer.syncDir(w.shardDir)
+	}
 	// NB(xichen): only write out the checkpoint file if there are no errors
 	// encountered between calling writer.Open() and writer.Close().
-	if err := w.writeCheckpointFile(); err != nil {
+	if err := w.writeCheckpointFile(w.checkpointFilePath, digestChecksum); err != nil {
+		w.err = err
+		return err
+	}
+	if err := w.syncCheckpointFile(w.checkpointFilePath); err != nil {
 		w.err = err
 		return err
 	}
 	return nil
 }
 
+func (w *writer) syncCheckpointFile(filePath string) error {
+	if err := w.syncer.syncFiles(filePath); err != nil {
+		return err
+	}
+	return w.syncer.syncDir(w.shardDir)
+}
+
+// syncPending completes the file sets closed since it was last called when
+// batching syncs, it is called by the persist manager once a flush is done.
+// The files of the file sets are synced before their checkpoint files are
+// written and synced followed by their directories. Anything that fails is
+// kept pending so that it is retried by the next call.
+func (w *writer) syncPending() error {
+	if err := w.syncer.syncPendingFiles(); err != nil {
+		return err
+	}
+	if err := w.writePendingCheckpointFiles(); err != nil {
+		return err
+	}
+	if err := w.syncer.syncPendingFiles(); err != nil {
+		return err
+	}
+	return w.syncer.syncPendingDirs()
+}
+
+func (w *writer) writePendingCheckpointFiles() error {
+	pending := w.pendingCheckpoints
+	for i, checkpoint := range pending {
+		err := w.writeCheckpointFile(checkpoint.filePath, checkpoint.digestChecksum)
+		if err == nil {
+			err = w.syncer.syncFiles(checkpoint.filePath)
+		}
+		if err != nil {
+			w.pendingCheckpoints = append(pending[:0], pending[i:]...)
+			return err
+		}
+	}
+	w.pendingCheckpoints = pending[:0]
+	return nil
+}
+
 func (w *writer) close() error {
 	if err := w.writeIndexRelatedFiles(); err != nil {
 		return err
@@ -338,12 +556,11 @@ func (w *writer) close() error {
 	)
 }
 
-func (w *writer) writeCheckpointFile() error {
-	fd, err := w.openWritable(w.checkpointFilePath)
+func (w *writer) writeCheckpointFile(filePath string, digestChecksum uint32) error {
+	fd, err := w.openWritable(filePath)
 	if err != nil {
 		return err
 	}
-	digestChecksum := w.digestFdWithDigestContents.Digest().Sum32()
 	if err := w.digestBuf.WriteDigestToFile(fd, digestChecksum); err != nil {
 		// NB(prateek): intentionally skipping fd.Close() error, as failure
 		// to write takes precedence over failure to close the file
@@ -443,6 +660,7 @@ func (w *writer) writeIndexFileContents(
 			Offset:      w.indexEntries[i].dataFileOffset,
 			Checksum:    int64(w.indexEntries[i].checksum),
 			EncodedTags: encodedTags,
+			Deleted:     w.indexEntries[i].deleted,
 		}
 
 		w.encoder.Reset()
@@ -457,8 +675,11 @@ func (w *writer) writeIndexFileContents(
 
 		// Add to the bloom filter, note this must be zero alloc or else this will
 		// cause heavy GC churn as we flush millions of series at end of each
-		// time window
-		bloomFilter.Add(id)
+		// time window. Tombstones are left out so that lookups for deleted
+		// series are rejected by the bloom filter.
+		if !entry.Deleted {
+			bloomFilter.Add(id)
+		}
 
 		if i%summaryEvery == 0 {
 			// Capture the offset for when we write this summary back, only capture
@@ -515,6 +736,13 @@ func (w *writer) writeInfoFileContents(
 	bloomFilter *bloom.BloomFilter,
 	summaries int,
 ) error {
+	minTimestamp, maxTimestamp := w.minTimestamp, w.maxTimestamp
+	if minTimestamp.IsZero() {
+		// Fall back to the bounds of the block if no timestamps were written
+		minTimestamp = w.start
+		maxTimestamp = w.start.Add(w.blockSize - time.Nanosecond)
+	}
+
 	info := schema.IndexInfo{
 		BlockStart:   xtime.ToNanoseconds(w.start),
 		SnapshotTime: xtime.ToNanoseconds(w.snapshotTime),
@@ -528,6 +756,8 @@ func (w *writer) writeInfoFileContents(
 			NumElementsM: int64(bloomFilter.M()),
 			NumHashesK:   int64(bloomFilter.K()),
 		},
+		MinTimestamp: xtime.ToNanoseconds(minTimestamp),
+		MaxTimestamp: xtime.ToNanoseconds(maxTimestamp),
 	}
 
 	w.encoder.Reset()
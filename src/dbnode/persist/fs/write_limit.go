@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+)
+
+var (
+	// ErrTooManyOpenWriters is returned when opening a file set writer would
+	// exceed the limit of a non-blocking writer open limiter.
+	ErrTooManyOpenWriters = errors.New("too many open file set writers")
+
+	errWriterOpenLimiterMaxOpenNotPositive = errors.New("writer open limiter max open must be positive")
+)
+
+type writerOpenLimiter struct {
+	slots    chan struct{}
+	blocking bool
+}
+
+// NewWriterOpenLimiter returns a new limiter that allows at most maxOpen
+// writers sharing it to be open at once. When blocking is true opening a
+// writer past the limit waits for another writer to close, otherwise it
+// fails with ErrTooManyOpenWriters. maxOpen must be positive since no
+// writer could ever be opened otherwise.
+func NewWriterOpenLimiter(maxOpen int, blocking bool) (WriterOpenLimiter, error) {
+	if maxOpen <= 0 {
+		return nil, errWriterOpenLimiterMaxOpenNotPositive
+	}
+	return &writerOpenLimiter{
+		slots:    make(chan struct{}, maxOpen),
+		blocking: blocking,
+	}, nil
+}
+
+func (l *writerOpenLimiter) MaxOpen() int {
+	return cap(l.slots)
+}
+
+func (l *writerOpenLimiter) Acquire() error {
+	if l.blocking {
+		l.slots <- struct{}{}
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+		return ErrTooManyOpenWriters
+	}
+}
+
+func (l *writerOpenLimiter) Release() {
+	<-l.slots
+}
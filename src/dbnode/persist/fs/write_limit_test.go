@@ -0,0 +1,143 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimitedWriter(
+	t *testing.T,
+	filePathPrefix string,
+	limiter WriterOpenLimiter,
+) DataFileSetWriter {
+	w, err := NewWriter(testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetWriterOpenLimiter(limiter))
+	require.NoError(t, err)
+	return w
+}
+
+func testLimitedWriterOpenOptions(shard uint32) DataWriterOpenOptions {
+	return DataWriterOpenOptions{
+		BlockSize: testBlockSize,
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      shard,
+			BlockStart: testWriterStart,
+		},
+	}
+}
+
+func TestWriterOpenLimiterNonBlocking(t *testing.T) {
+	l, err := NewWriterOpenLimiter(2, false)
+	require.NoError(t, err)
+	require.NoError(t, l.Acquire())
+	require.NoError(t, l.Acquire())
+	require.Equal(t, ErrTooManyOpenWriters, l.Acquire())
+
+	l.Release()
+	require.NoError(t, l.Acquire())
+}
+
+func TestWriterOpenFailsPastLimit(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	limiter, err := NewWriterOpenLimiter(1, false)
+	require.NoError(t, err)
+	first := newTestLimitedWriter(t, filePathPrefix, limiter)
+	second := newTestLimitedWriter(t, filePathPrefix, limiter)
+
+	require.NoError(t, first.Open(testLimitedWriterOpenOptions(0)))
+	require.Equal(t, ErrTooManyOpenWriters, second.Open(testLimitedWriterOpenOptions(1)))
+
+	// Closing a writer frees its slot
+	require.NoError(t, first.Close())
+	require.NoError(t, second.Open(testLimitedWriterOpenOptions(1)))
+	require.NoError(t, second.Close())
+
+	// A writer that fails to open does not hold on to its slot
+	invalidOpts := testLimitedWriterOpenOptions(0)
+	invalidOpts.FileSetType = -1
+	require.Error(t, first.Open(invalidOpts))
+	require.NoError(t, second.Open(testLimitedWriterOpenOptions(1)))
+	require.NoError(t, second.Close())
+}
+
+func TestWriterOpenBlocksPastLimit(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	limiter, err := NewWriterOpenLimiter(1, true)
+	require.NoError(t, err)
+	first := newTestLimitedWriter(t, filePathPrefix, limiter)
+	second := newTestLimitedWriter(t, filePathPrefix, limiter)
+
+	require.NoError(t, first.Open(testLimitedWriterOpenOptions(0)))
+
+	opened := make(chan error, 1)
+	go func() {
+		opened <- second.Open(testLimitedWriterOpenOptions(1))
+	}()
+
+	select {
+	case <-opened:
+		require.FailNow(t, "open should block until a writer is closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, first.Close())
+	select {
+	case err := <-opened:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "open should unblock once a writer is closed")
+	}
+	require.NoError(t, second.Close())
+}
+
+type testZeroWriterOpenLimiter struct {
+	WriterOpenLimiter
+}
+
+func (l testZeroWriterOpenLimiter) MaxOpen() int { return 0 }
+
+func TestWriterOpenLimiterMaxOpenMustBePositive(t *testing.T) {
+	for _, maxOpen := range []int{0, -1} {
+		_, err := NewWriterOpenLimiter(maxOpen, true)
+		require.Error(t, err)
+	}
+
+	// Writers are never created with a limiter that could never be acquired
+	opts := testDefaultOpts.SetWriterOpenLimiter(testZeroWriterOpenLimiter{})
+	require.Error(t, opts.Validate())
+	_, err := NewWriter(opts)
+	require.Error(t, err)
+}
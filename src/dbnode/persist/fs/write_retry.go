@@ -0,0 +1,89 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io"
+	"os"
+	"syscall"
+
+	xerrors "github.com/m3db/m3x/errors"
+	xretry "github.com/m3db/m3x/retry"
+)
+
+// retryWriter retries writes to the underlying writer that fail with a
+// transient error, resuming from the last byte written so that partial
+// writes are never duplicated.
+type retryWriter struct {
+	writer  io.Writer
+	retrier xretry.Retrier
+}
+
+func newRetryWriter(writer io.Writer, retrier xretry.Retrier) io.Writer {
+	return &retryWriter{
+		writer:  writer,
+		retrier: retrier,
+	}
+}
+
+func (w *retryWriter) Write(p []byte) (int, error) {
+	var (
+		written      int
+		permanentErr error
+	)
+	err := w.retrier.Attempt(func() error {
+		n, err := w.writer.Write(p[written:])
+		written += n
+		if err == nil {
+			return nil
+		}
+		if !isTransientWriteError(err) {
+			permanentErr = err
+			return xerrors.NewNonRetryableError(err)
+		}
+		return err
+	})
+	if permanentErr != nil {
+		return written, permanentErr
+	}
+	return written, err
+}
+
+// isTransientWriteError returns whether a write error may succeed if the
+// write is retried, such as an interrupted system call or a temporary lack
+// of disk space.
+func isTransientWriteError(err error) bool {
+	switch e := err.(type) {
+	case *os.PathError:
+		err = e.Err
+	case *os.SyscallError:
+		err = e.Err
+	}
+	errno, ok := err.(syscall.Errno)
+	if !ok {
+		return false
+	}
+	switch errno {
+	case syscall.EINTR, syscall.EAGAIN, syscall.ENOSPC:
+		return true
+	}
+	return false
+}
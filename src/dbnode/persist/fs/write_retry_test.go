@@ -0,0 +1,125 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	xretry "github.com/m3db/m3x/retry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testWriteResult struct {
+	maxBytes int
+	err      error
+}
+
+// testFlakyWriter writes at most maxBytes of each write to buf and returns
+// err for each of the queued results, then writes normally.
+type testFlakyWriter struct {
+	buf     bytes.Buffer
+	results []testWriteResult
+	calls   int
+}
+
+func (w *testFlakyWriter) Write(p []byte) (int, error) {
+	w.calls++
+	if len(w.results) == 0 {
+		return w.buf.Write(p)
+	}
+	result := w.results[0]
+	w.results = w.results[1:]
+	if result.maxBytes < len(p) {
+		p = p[:result.maxBytes]
+	}
+	n, _ := w.buf.Write(p)
+	return n, result.err
+}
+
+func newTestRetrier(maxRetries int) xretry.Retrier {
+	return xretry.NewRetrier(xretry.NewOptions().
+		SetInitialBackoff(time.Millisecond).
+		SetMaxBackoff(time.Millisecond).
+		SetMaxRetries(maxRetries).
+		SetJitter(true))
+}
+
+func TestRetryWriterRetriesTransientErrors(t *testing.T) {
+	flaky := &testFlakyWriter{
+		results: []testWriteResult{
+			{maxBytes: 3, err: &os.PathError{Op: "write", Path: "data", Err: syscall.EINTR}},
+			{maxBytes: 2, err: syscall.ENOSPC},
+		},
+	}
+	w := newRetryWriter(flaky, newTestRetrier(3))
+
+	data := []byte("hello world")
+	n, err := w.Write(data)
+	require.NoError(t, err)
+	assert.Equal(t, len(data), n)
+	assert.Equal(t, 3, flaky.calls)
+
+	// Partial writes are resumed rather than written again
+	assert.Equal(t, data, flaky.buf.Bytes())
+}
+
+func TestRetryWriterDoesNotRetryPermanentErrors(t *testing.T) {
+	permanentErr := errors.New("permanent")
+	flaky := &testFlakyWriter{
+		results: []testWriteResult{
+			{maxBytes: 3, err: permanentErr},
+		},
+	}
+	w := newRetryWriter(flaky, newTestRetrier(3))
+
+	n, err := w.Write([]byte("hello world"))
+	require.Equal(t, permanentErr, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestRetryWriterGivesUpAfterMaxRetries(t *testing.T) {
+	flaky := &testFlakyWriter{}
+	for i := 0; i < 3; i++ {
+		flaky.results = append(flaky.results, testWriteResult{err: syscall.EAGAIN})
+	}
+	w := newRetryWriter(flaky, newTestRetrier(2))
+
+	n, err := w.Write([]byte("hello world"))
+	require.Equal(t, syscall.EAGAIN, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, 3, flaky.calls)
+}
+
+func TestIsTransientWriteError(t *testing.T) {
+	assert.True(t, isTransientWriteError(syscall.EINTR))
+	assert.True(t, isTransientWriteError(&os.SyscallError{Syscall: "write", Err: syscall.EAGAIN}))
+	assert.True(t, isTransientWriteError(&os.PathError{Op: "write", Path: "data", Err: syscall.ENOSPC}))
+	assert.False(t, isTransientWriteError(&os.PathError{Op: "write", Path: "data", Err: syscall.EBADF}))
+	assert.False(t, isTransientWriteError(errors.New("permanent")))
+}
@@ -29,7 +29,9 @@ import (
 // tooling needs to upgrade older files to newer files before a server restart
 const MajorVersion = 1
 
-// IndexInfo stores metadata information about block filesets
+// IndexInfo stores metadata information about block filesets. MinTimestamp
+// and MaxTimestamp bound the timestamps of the datapoints in the fileset and
+// are zero for filesets written before they were recorded.
 type IndexInfo struct {
 	MajorVersion int64
 	BlockStart   int64
@@ -39,6 +41,8 @@ type IndexInfo struct {
 	BloomFilter  IndexBloomFilterInfo
 	SnapshotTime int64
 	FileType     persist.FileSetType
+	MinTimestamp int64
+	MaxTimestamp int64
 }
 
 // IndexSummariesInfo stores metadata about the summaries
@@ -60,6 +64,7 @@ type IndexEntry struct {
 	Offset      int64
 	Checksum    int64
 	EncodedTags []byte
+	Deleted     bool
 }
 
 // IndexSummary stores a summary of an index entry to lookup
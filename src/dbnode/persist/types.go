@@ -98,6 +98,10 @@ type DataPrepareOptions struct {
 	Shard             uint32
 	FileSetType       FileSetType
 	DeleteIfExists    bool
+	// VolumeIndex is the volume flushed filesets are written to, a block
+	// flushed again is written to a new volume rather than replacing the
+	// volume already on disk.
+	VolumeIndex int
 	// Snapshot options are applicable to snapshots (index yes, data yes)
 	Snapshot DataPrepareSnapshotOptions
 }
@@ -49,3 +49,13 @@ func (c *Configuration) Options() Options {
 	}
 	return opts
 }
+
+// ValidatedOptions returns `Options` corresponding to the provided struct values,
+// or an error if they do not make valid retention options
+func (c *Configuration) ValidatedOptions() (Options, error) {
+	opts := c.Options()
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
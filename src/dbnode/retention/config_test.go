@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
 )
 
 func TestConfigurationAssignment(t *testing.T) {
@@ -53,3 +54,38 @@ func TestConfigurationAssignment(t *testing.T) {
 	require.Equal(t, blockDataExpiry, opts.BlockDataExpiry())
 	require.Equal(t, blockDataExpiryAfterNotAccessedPeriod, opts.BlockDataExpiryAfterNotAccessedPeriod())
 }
+
+func TestConfigurationValidatedOptionsFromYAML(t *testing.T) {
+	input := `
+retentionPeriod: 48h
+blockSize: 2h
+bufferFuture: 10m
+bufferPast: 15m
+blockDataExpiryAfterNotAccessedPeriod: 30m
+`
+	var config Configuration
+	require.NoError(t, yaml.Unmarshal([]byte(input), &config))
+
+	opts, err := config.ValidatedOptions()
+	require.NoError(t, err)
+	require.Equal(t, 48*time.Hour, opts.RetentionPeriod())
+	require.Equal(t, 2*time.Hour, opts.BlockSize())
+	require.Equal(t, 10*time.Minute, opts.BufferFuture())
+	require.Equal(t, 15*time.Minute, opts.BufferPast())
+	require.Equal(t, defaultDataExpiry, opts.BlockDataExpiry())
+	require.Equal(t, 30*time.Minute, opts.BlockDataExpiryAfterNotAccessedPeriod())
+}
+
+func TestConfigurationValidatedOptionsInvalid(t *testing.T) {
+	input := `
+retentionPeriod: 1h
+blockSize: 2h
+bufferFuture: 10m
+bufferPast: 10m
+`
+	var config Configuration
+	require.NoError(t, yaml.Unmarshal([]byte(input), &config))
+
+	_, err := config.ValidatedOptions()
+	require.Equal(t, errRetentionPeriodTooSmall, err)
+}
@@ -22,6 +22,16 @@ package retention
 
 import "time"
 
+// BlockStart is the start of the block containing the given time
+func BlockStart(opts Options, t time.Time) time.Time {
+	return BlockStartForBlockSize(opts.BlockSize(), t)
+}
+
+// BlockStartForBlockSize is the start of the block containing the given time
+func BlockStartForBlockSize(blockSize time.Duration, t time.Time) time.Time {
+	return t.Truncate(blockSize)
+}
+
 // FlushTimeStart is the earliest flushable time
 func FlushTimeStart(opts Options, t time.Time) time.Time {
 	return FlushTimeStartForRetentionPeriod(opts.RetentionPeriod(), opts.BlockSize(), t)
@@ -29,7 +39,7 @@ func FlushTimeStart(opts Options, t time.Time) time.Time {
 
 // FlushTimeStartForRetentionPeriod is the earliest flushable time
 func FlushTimeStartForRetentionPeriod(retentionPeriod time.Duration, blockSize time.Duration, t time.Time) time.Time {
-	return t.Add(-retentionPeriod).Truncate(blockSize)
+	return BlockStartForBlockSize(blockSize, t.Add(-retentionPeriod))
 }
 
 // FlushTimeEnd is the latest flushable time
@@ -39,5 +49,5 @@ func FlushTimeEnd(opts Options, t time.Time) time.Time {
 
 // FlushTimeEndForBlockSize is the latest flushable time
 func FlushTimeEndForBlockSize(blockSize time.Duration, t time.Time) time.Time {
-	return t.Add(-blockSize).Truncate(blockSize)
+	return BlockStartForBlockSize(blockSize, t.Add(-blockSize))
 }
@@ -0,0 +1,60 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockStart(t *testing.T) {
+	opts := NewOptions().SetBlockSize(2 * time.Hour)
+	start := time.Unix(0, 0).Add(10 * time.Hour)
+
+	inputs := []struct {
+		t        time.Time
+		expected time.Time
+	}{
+		{start, start},
+		{start.Add(time.Nanosecond), start},
+		{start.Add(time.Hour), start},
+		{start.Add(2*time.Hour - time.Nanosecond), start},
+		{start.Add(2 * time.Hour), start.Add(2 * time.Hour)},
+		{start.Add(-time.Nanosecond), start.Add(-2 * time.Hour)},
+	}
+	for _, input := range inputs {
+		require.Equal(t, input.expected, BlockStart(opts, input.t))
+		require.Equal(t, input.expected, BlockStartForBlockSize(opts.BlockSize(), input.t))
+	}
+}
+
+func TestFlushTimesAreBlockAligned(t *testing.T) {
+	opts := NewOptions().
+		SetRetentionPeriod(48 * time.Hour).
+		SetBlockSize(2 * time.Hour).
+		SetBufferPast(10 * time.Minute)
+	now := time.Unix(0, 0).Add(100*time.Hour + 5*time.Minute)
+
+	require.Equal(t, time.Unix(0, 0).Add(52*time.Hour), FlushTimeStart(opts, now))
+	require.Equal(t, time.Unix(0, 0).Add(96*time.Hour), FlushTimeEnd(opts, now))
+}
@@ -289,6 +289,7 @@ func Run(runOpts RunOptions) {
 		SetFilePathPrefix(cfg.Filesystem.FilePathPrefix).
 		SetNewFileMode(newFileMode).
 		SetNewDirectoryMode(newDirectoryMode).
+		SetFilenameTimeFormat(cfg.Filesystem.FilenameTimeFormatOrDefault()).
 		SetWriterBufferSize(cfg.Filesystem.WriteBufferSize).
 		SetDataReaderBufferSize(cfg.Filesystem.DataReadBufferSize).
 		SetInfoReaderBufferSize(cfg.Filesystem.InfoReadBufferSize).
@@ -530,8 +531,13 @@ func Run(runOpts RunOptions) {
 	defer tchannelthriftClusterClose()
 	logger.Infof("cluster tchannelthrift: listening on %v", cfg.ClusterListenAddress)
 
-	httpjsonNodeClose, err := hjnode.NewServer(db,
-		cfg.HTTPNodeListenAddress, contextPool, nil, ttopts).ListenAndServe()
+	httpjsonNodeServer, err := hjnode.NewServerValidated(db,
+		cfg.HTTPNodeListenAddress, contextPool, nil, ttopts)
+	if err != nil {
+		logger.Fatalf("invalid httpjson address %s: %v",
+			cfg.HTTPNodeListenAddress, err)
+	}
+	httpjsonNodeClose, err := httpjsonNodeServer.ListenAndServe()
 	if err != nil {
 		logger.Fatalf("could not open httpjson interface on %s: %v",
 			cfg.HTTPNodeListenAddress, err)
@@ -539,8 +545,13 @@ func Run(runOpts RunOptions) {
 	defer httpjsonNodeClose()
 	logger.Infof("node httpjson: listening on %v", cfg.HTTPNodeListenAddress)
 
-	httpjsonClusterClose, err := hjcluster.NewServer(m3dbClient,
-		cfg.HTTPClusterListenAddress, contextPool, nil).ListenAndServe()
+	httpjsonClusterServer, err := hjcluster.NewServerValidated(m3dbClient,
+		cfg.HTTPClusterListenAddress, contextPool, nil)
+	if err != nil {
+		logger.Fatalf("invalid httpjson address %s: %v",
+			cfg.HTTPClusterListenAddress, err)
+	}
+	httpjsonClusterClose, err := httpjsonClusterServer.ListenAndServe()
 	if err != nil {
 		logger.Fatalf("could not open httpjson interface on %s: %v",
 			cfg.HTTPClusterListenAddress, err)
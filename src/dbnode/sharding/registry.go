@@ -0,0 +1,90 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sharding
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/m3db/m3cluster/shard"
+)
+
+const (
+	// DefaultHashName is the name the default murmur32 hash is registered under
+	DefaultHashName = "murmur3"
+)
+
+var (
+	errHashNameEmpty = errors.New("hash name must not be empty")
+	errHashGenNil    = errors.New("hash gen must not be nil")
+
+	hashGensLock sync.RWMutex
+	hashGens     = map[string]HashGen{
+		DefaultHashName: DefaultHashFn,
+	}
+)
+
+// RegisterHashGen registers a HashGen under a name so that it can be
+// referenced by configuration, registering a name twice is an error
+func RegisterHashGen(name string, gen HashGen) error {
+	if name == "" {
+		return errHashNameEmpty
+	}
+	if gen == nil {
+		return errHashGenNil
+	}
+
+	hashGensLock.Lock()
+	defer hashGensLock.Unlock()
+
+	if _, ok := hashGens[name]; ok {
+		return fmt.Errorf("hash %s is already registered", name)
+	}
+	hashGens[name] = gen
+	return nil
+}
+
+// HashGenByName returns the HashGen registered under a name
+func HashGenByName(name string) (HashGen, error) {
+	hashGensLock.RLock()
+	gen, ok := hashGens[name]
+	hashGensLock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("no hash registered with name %s", name)
+	}
+	return gen, nil
+}
+
+// NewShardSetByName creates a new sharding scheme with a set of shards
+// using the hash registered under a name
+func NewShardSetByName(shards []shard.Shard, name string) (ShardSet, error) {
+	gen, err := HashGenByName(name)
+	if err != nil {
+		return nil, err
+	}
+	fn := gen(len(shards))
+	if err := ValidateHashFn(fn, len(shards)); err != nil {
+		return nil, err
+	}
+	return NewShardSet(shards, fn)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sharding
+
+import (
+	"testing"
+
+	"github.com/m3db/m3cluster/shard"
+	"github.com/m3db/m3x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterHashGenResolveByName(t *testing.T) {
+	name := "test-static-hash"
+	staticShard := uint32(3)
+	require.NoError(t, RegisterHashGen(name, func(length int) HashFn {
+		return func(id ident.ID) uint32 {
+			return staticShard
+		}
+	}))
+	require.Error(t, RegisterHashGen(name, DefaultHashFn))
+
+	ss, err := NewShardSetByName(NewShards([]uint32{0, 1, 2, 3}, shard.Available), name)
+	require.NoError(t, err)
+	require.Equal(t, staticShard, ss.Lookup(ident.StringID("foo")))
+}
+
+func TestRegisterHashGenInvalid(t *testing.T) {
+	require.Equal(t, errHashNameEmpty, RegisterHashGen("", DefaultHashFn))
+	require.Equal(t, errHashGenNil, RegisterHashGen("test-nil-hash", nil))
+}
+
+func TestNewShardSetByNameDefault(t *testing.T) {
+	shards := NewShards([]uint32{0, 1, 2, 3}, shard.Available)
+	ss, err := NewShardSetByName(shards, DefaultHashName)
+	require.NoError(t, err)
+
+	id := ident.StringID("foo")
+	require.Equal(t, DefaultHashFn(len(shards))(id), ss.Lookup(id))
+}
+
+func TestNewShardSetByNameUnknown(t *testing.T) {
+	ss, err := NewShardSetByName(NewShards([]uint32{0}, shard.Available), "unknown")
+	require.Error(t, err)
+	require.Nil(t, ss)
+}
+
+func TestNewShardSetByNameHashOutOfRange(t *testing.T) {
+	name := "test-out-of-range-hash"
+	require.NoError(t, RegisterHashGen(name, func(length int) HashFn {
+		return func(id ident.ID) uint32 {
+			return uint32(length)
+		}
+	}))
+
+	ss, err := NewShardSetByName(NewShards([]uint32{0, 1}, shard.Available), name)
+	require.Error(t, err)
+	require.Nil(t, ss)
+}
@@ -22,7 +22,9 @@ package sharding
 
 import (
 	"errors"
+	"fmt"
 	"math"
+	"strconv"
 
 	"github.com/m3db/m3cluster/shard"
 	"github.com/m3db/m3x/ident"
@@ -36,6 +38,16 @@ var (
 
 	// ErrInvalidShardID is returned on an invalid shard ID
 	ErrInvalidShardID = errors.New("no shard with given ID")
+
+	// ErrHashOutOfRange is returned when a hash maps an ID outside of the shard space
+	ErrHashOutOfRange = errors.New("hash maps outside of shard space")
+
+	errNumShardsNotPositive = errors.New("number of shards must be positive")
+	errInvalidShardRange    = errors.New("min shard must not be greater than max shard")
+)
+
+const (
+	hashValidationSamples = 1024
 )
 
 type shardSet struct {
@@ -164,3 +176,34 @@ func NewHashFn(length int, seed uint32) HashFn {
 		return murmur3.Sum32WithSeed(id.Bytes(), seed) % uint32(length)
 	}
 }
+
+// NewSeededRangeHashFn generates a HashFn based on murmur32 with a given seed
+// that maps IDs to the inclusive range of shards [min, max]. Using different
+// seeds for different clusters separates their collision domains so the
+// same ID maps to unrelated shards in each cluster.
+func NewSeededRangeHashFn(min, max uint32, seed uint32) (HashFn, error) {
+	if min > max {
+		return nil, errInvalidShardRange
+	}
+	numShards := uint64(max-min) + 1
+	return func(id ident.ID) uint32 {
+		hash := uint64(murmur3.Sum32WithSeed(id.Bytes(), seed))
+		return min + uint32(hash%numShards)
+	}, nil
+}
+
+// ValidateHashFn sanity checks that a HashFn maps into a shard space of
+// numShards by hashing a sample of generated IDs
+func ValidateHashFn(fn HashFn, numShards int) error {
+	if numShards <= 0 {
+		return errNumShardsNotPositive
+	}
+	for i := 0; i < hashValidationSamples; i++ {
+		id := ident.StringID(strconv.Itoa(i))
+		if shard := fn(id); shard >= uint32(numShards) {
+			return fmt.Errorf("%v: id %s mapped to shard %d, num shards %d",
+				ErrHashOutOfRange, id.String(), shard, numShards)
+		}
+	}
+	return nil
+}
@@ -21,6 +21,8 @@
 package sharding
 
 import (
+	"math"
+	"strconv"
 	"testing"
 
 	"github.com/m3db/m3cluster/shard"
@@ -75,3 +77,67 @@ func TestLookupShardState(t *testing.T) {
 	require.Equal(t, ErrInvalidShardID, err)
 	require.Equal(t, noState, shardTwoState)
 }
+
+func TestValidateHashFn(t *testing.T) {
+	require.NoError(t, ValidateHashFn(DefaultHashFn(64), 64))
+	require.Equal(t, errNumShardsNotPositive, ValidateHashFn(DefaultHashFn(64), 0))
+
+	err := ValidateHashFn(DefaultHashFn(128), 64)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), ErrHashOutOfRange.Error())
+}
+
+func TestSeededRangeHashFnDeterministic(t *testing.T) {
+	fn, err := NewSeededRangeHashFn(16, 31, 42)
+	require.NoError(t, err)
+	other, err := NewSeededRangeHashFn(16, 31, 42)
+	require.NoError(t, err)
+
+	for i := 0; i < 1024; i++ {
+		id := ident.StringID(strconv.Itoa(i))
+		shard := fn(id)
+		require.True(t, shard >= 16 && shard <= 31)
+		require.Equal(t, shard, fn(id))
+		require.Equal(t, shard, other(id))
+	}
+
+	// The zero seed matches the default hash fn offset into the range
+	zeroSeed, err := NewSeededRangeHashFn(16, 31, 0)
+	require.NoError(t, err)
+	id := ident.StringID("foo")
+	require.Equal(t, DefaultHashFn(16)(id)+16, zeroSeed(id))
+}
+
+func TestSeededRangeHashFnSeedsDistributeDifferently(t *testing.T) {
+	fn, err := NewSeededRangeHashFn(0, 63, 1)
+	require.NoError(t, err)
+	other, err := NewSeededRangeHashFn(0, 63, 2)
+	require.NoError(t, err)
+
+	var (
+		numIDs  = 1024
+		differs int
+	)
+	for i := 0; i < numIDs; i++ {
+		id := ident.StringID(strconv.Itoa(i))
+		if fn(id) != other(id) {
+			differs++
+		}
+	}
+	// Unrelated distributions only agree for roughly 1 in 64 IDs
+	require.True(t, differs > numIDs*9/10)
+}
+
+func TestSeededRangeHashFnFullRange(t *testing.T) {
+	fn, err := NewSeededRangeHashFn(0, math.MaxUint32, 42)
+	require.NoError(t, err)
+	id := ident.StringID("foo")
+	require.Equal(t, fn(id), fn(id))
+
+	single, err := NewSeededRangeHashFn(7, 7, 42)
+	require.NoError(t, err)
+	require.Equal(t, uint32(7), single(id))
+
+	_, err = NewSeededRangeHashFn(8, 7, 42)
+	require.Equal(t, errInvalidShardRange, err)
+}
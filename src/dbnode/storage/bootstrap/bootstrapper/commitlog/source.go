@@ -31,6 +31,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
@@ -297,7 +298,7 @@ func (s *commitLogSource) ReadData(
 			dp:         dp,
 			unit:       unit,
 			annotation: annotation,
-			blockStart: dp.Timestamp.Truncate(blockSize),
+			blockStart: retention.BlockStartForBlockSize(blockSize, dp.Timestamp),
 		}
 	}
 
@@ -383,7 +384,7 @@ func (s *commitLogSource) mostRecentCompleteSnapshotByBlockShard(
 		mostRecentSnapshotsByBlockShard = map[xtime.UnixNano]map[uint32]fs.FileSetFile{}
 	)
 
-	for currBlockStart := minBlock.Truncate(blockSize); currBlockStart.Before(maxBlock); currBlockStart = currBlockStart.Add(blockSize) {
+	for currBlockStart := retention.BlockStartForBlockSize(blockSize, minBlock); currBlockStart.Before(maxBlock); currBlockStart = currBlockStart.Add(blockSize) {
 		for shard := range shardsTimeRanges {
 			// Anonymous func for easier clean up using defer.
 			func() {
@@ -520,7 +521,7 @@ func (s *commitLogSource) bootstrapShardSnapshots(
 			allSeriesSoFar = shardResult.AllSeries()
 		}
 
-		for blockStart := currRange.Start.Truncate(blockSize); blockStart.Before(currRange.End); blockStart = blockStart.Add(blockSize) {
+		for blockStart := retention.BlockStartForBlockSize(blockSize, currRange.Start); blockStart.Before(currRange.End); blockStart = blockStart.Add(blockSize) {
 			snapshotsForBlock := mostRecentCompleteSnapshotByBlockShard[xtime.ToUnixNano(blockStart)]
 			mostRecentCompleteSnapshotForShardBlock := snapshotsForBlock[shard]
 
@@ -906,7 +907,7 @@ func (s *commitLogSource) shouldEncodeForData(
 	}
 
 	// Check if the block corresponds to the time-range that we're trying to bootstrap
-	blockStart := timestamp.Truncate(dataBlockSize)
+	blockStart := retention.BlockStartForBlockSize(dataBlockSize, timestamp)
 	blockEnd := blockStart.Add(dataBlockSize)
 	blockRange := xtime.Range{
 		Start: blockStart,
@@ -937,7 +938,7 @@ func (s *commitLogSource) shouldIncludeInIndex(
 
 	// Check if the timestamp corresponds to one of the index blocks we're
 	// trying to bootstrap.
-	indexBlockStart := ts.Truncate(indexBlockSize)
+	indexBlockStart := retention.BlockStartForBlockSize(indexBlockSize, ts)
 	indexBlockEnd := indexBlockStart.Add(indexBlockSize)
 	indexBlockRange := xtime.Range{
 		Start: indexBlockStart,
@@ -28,6 +28,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
@@ -339,9 +340,10 @@ func (s *fileSystemSource) newShardReaders(
 
 		openOpts := fs.DataReaderOpenOptions{
 			Identifier: fs.FileSetFileIdentifier{
-				Namespace:  ns.ID(),
-				Shard:      shard,
-				BlockStart: blockStart,
+				Namespace:   ns.ID(),
+				Shard:       shard,
+				BlockStart:  blockStart,
+				VolumeIndex: result.ID.VolumeIndex,
 			},
 		}
 		if err := r.Open(openOpts); err != nil {
@@ -763,7 +765,7 @@ func (s *fileSystemSource) incrementalBootstrapIndexSegment(
 	// occur since we always group readers by block size)
 	min, max := requestedRanges.MinMax()
 	blockSize := ns.Options().IndexOptions().BlockSize()
-	blockStart := min.Truncate(blockSize)
+	blockStart := retention.BlockStartForBlockSize(blockSize, min)
 
 	shards := make(map[uint32]struct{})
 	expectedRanges := make(result.ShardTimeRanges, len(requestedRanges))
@@ -269,17 +269,15 @@ func (b bootstrapProcess) targetRanges(
 	at time.Time,
 	opts targetRangesOptions,
 ) []TargetRange {
-	start := at.Add(-opts.retentionPeriod).
-		Truncate(opts.blockSize)
-	midPoint := at.
-		Add(-opts.blockSize).
-		Add(-opts.bufferPast).
-		Truncate(opts.blockSize).
+	start := retention.BlockStartForBlockSize(opts.blockSize,
+		at.Add(-opts.retentionPeriod))
+	midPoint := retention.BlockStartForBlockSize(opts.blockSize,
+		at.Add(-opts.blockSize).Add(-opts.bufferPast)).
 		// NB(r): Since "end" is exclusive we need to add a
 		// an extra block size when specifying the end time.
 		Add(opts.blockSize)
-	cutover := at.Add(opts.bufferFuture).
-		Truncate(opts.blockSize).
+	cutover := retention.BlockStartForBlockSize(opts.blockSize,
+		at.Add(opts.bufferFuture)).
 		Add(opts.blockSize)
 
 	// NB(r): We want the large initial time range bootstrapped to
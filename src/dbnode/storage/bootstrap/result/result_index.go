@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/m3ninx/index/segment"
 	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
@@ -103,7 +104,7 @@ func (r IndexResults) GetOrAddSegment(
 	// there is only one entry for this time is because index blocks must be a
 	// positive multiple of the data block size, making it easy to map a data
 	// block entry to at most one index block entry.
-	blockStart := t.Truncate(idxopts.BlockSize())
+	blockStart := retention.BlockStartForBlockSize(idxopts.BlockSize(), t)
 	blockStartNanos := xtime.ToUnixNano(blockStart)
 
 	block, exists := r[blockStartNanos]
@@ -139,7 +140,7 @@ func (r IndexResults) MarkFulfilled(
 	// there is only one entry for this time is because index blocks must be a
 	// positive multiple of the data block size, making it easy to map a data
 	// block entry to at most one index block entry.
-	blockStart := t.Truncate(idxopts.BlockSize())
+	blockStart := retention.BlockStartForBlockSize(idxopts.BlockSize(), t)
 	blockStartNanos := xtime.ToUnixNano(blockStart)
 
 	blockRange := xtime.Range{
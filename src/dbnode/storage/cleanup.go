@@ -375,13 +375,10 @@ func commitLogNamespaceBlockTimes(
 	commitlogBlockSize time.Duration,
 	nsRetention retention.Options,
 ) (time.Time, time.Time) {
-	earliest := blockStart.
-		Add(-nsRetention.BufferPast()).
-		Truncate(nsRetention.BlockSize())
-	latest := blockStart.
-		Add(commitlogBlockSize).
-		Add(nsRetention.BufferFuture()).
-		Truncate(nsRetention.BlockSize())
+	earliest := retention.BlockStart(nsRetention,
+		blockStart.Add(-nsRetention.BufferPast()))
+	latest := retention.BlockStart(nsRetention,
+		blockStart.Add(commitlogBlockSize).Add(nsRetention.BufferFuture()))
 	return earliest, latest
 }
 
@@ -24,6 +24,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -57,6 +58,12 @@ var (
 
 	// errDatabaseIsClosed raised when trying to perform an action that requires an open database
 	errDatabaseIsClosed = errors.New("database is closed")
+
+	// errDatabaseNotBootstrapped raised when trying to snapshot a database that is not bootstrapped
+	errDatabaseNotBootstrapped = errors.New("database is not bootstrapped")
+
+	// errDatabaseReadOnly raised when trying to write to or persist data for a read only database
+	errDatabaseReadOnly = errors.New("database is read only")
 )
 
 type databaseState int
@@ -101,6 +108,7 @@ type databaseMetrics struct {
 	unknownNamespaceRead                tally.Counter
 	unknownNamespaceWrite               tally.Counter
 	unknownNamespaceWriteTagged         tally.Counter
+	unknownNamespaceWriteBatch          tally.Counter
 	unknownNamespaceFetchBlocks         tally.Counter
 	unknownNamespaceFetchBlocksMetadata tally.Counter
 	unknownNamespaceQueryIDs            tally.Counter
@@ -115,6 +123,7 @@ func newDatabaseMetrics(scope tally.Scope) databaseMetrics {
 		unknownNamespaceRead:                unknownNamespaceScope.Counter("read"),
 		unknownNamespaceWrite:               unknownNamespaceScope.Counter("write"),
 		unknownNamespaceWriteTagged:         unknownNamespaceScope.Counter("write-tagged"),
+		unknownNamespaceWriteBatch:          unknownNamespaceScope.Counter("write-batch"),
 		unknownNamespaceFetchBlocks:         unknownNamespaceScope.Counter("fetch-blocks"),
 		unknownNamespaceFetchBlocksMetadata: unknownNamespaceScope.Counter("fetch-blocks-metadata"),
 		unknownNamespaceQueryIDs:            unknownNamespaceScope.Counter("query-ids"),
@@ -354,6 +363,20 @@ func (d *db) ShardSet() sharding.ShardSet {
 	return shardSet
 }
 
+func (d *db) Shards() []uint32 {
+	d.RLock()
+	ids := d.shardSet.AllIDs()
+	d.RUnlock()
+
+	// NB: Copy the IDs so sorting them does not reorder the shard set
+	shards := make([]uint32, len(ids))
+	copy(shards, ids)
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i] < shards[j]
+	})
+	return shards
+}
+
 func (d *db) queueBootstrapWithLock() {
 	// NB(r): Trigger another bootstrap, if already bootstrapping this will
 	// enqueue a new bootstrap to execute before the current bootstrap
@@ -481,6 +504,10 @@ func (d *db) Write(
 	unit xtime.Unit,
 	annotation []byte,
 ) error {
+	if d.opts.ReadOnly() {
+		return errDatabaseReadOnly
+	}
+
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
 		d.metrics.unknownNamespaceWrite.Inc(1)
@@ -504,6 +531,10 @@ func (d *db) WriteTagged(
 	unit xtime.Unit,
 	annotation []byte,
 ) error {
+	if d.opts.ReadOnly() {
+		return errDatabaseReadOnly
+	}
+
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
 		d.metrics.unknownNamespaceWriteTagged.Inc(1)
@@ -517,6 +548,30 @@ func (d *db) WriteTagged(
 	return err
 }
 
+func (d *db) WriteBatch(
+	ctx context.Context,
+	namespace ident.ID,
+	points []DataPoint,
+) BatchError {
+	if d.opts.ReadOnly() {
+		return newBatchErrorForAll(len(points), errDatabaseReadOnly)
+	}
+
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		d.metrics.unknownNamespaceWriteBatch.Inc(1)
+		return newBatchErrorForAll(len(points), err)
+	}
+
+	errs := n.WriteBatch(ctx, points)
+	for i := range errs {
+		if errs[i].Err == commitlog.ErrCommitLogQueueFull {
+			d.errors.Record(1)
+		}
+	}
+	return errs
+}
+
 func (d *db) QueryIDs(
 	ctx context.Context,
 	namespace ident.ID,
@@ -626,6 +681,77 @@ func (d *db) Repair() error {
 	return d.mediator.Repair()
 }
 
+func (d *db) Snapshot(snapshotTime time.Time) error {
+	if d.opts.ReadOnly() {
+		return errDatabaseReadOnly
+	}
+	if !d.IsBootstrapped() {
+		return errDatabaseNotBootstrapped
+	}
+	return d.mediator.Snapshot(snapshotTime)
+}
+
+func (d *db) FlushNow(blockStart time.Time) error {
+	if d.opts.ReadOnly() {
+		return errDatabaseReadOnly
+	}
+	if !d.IsBootstrapped() {
+		return errDatabaseNotBootstrapped
+	}
+	return d.mediator.FlushNow(blockStart)
+}
+
+func (d *db) Tick() (TickResult, error) {
+	return d.mediator.Tick(syncRun, noForce)
+}
+
+func (d *db) Stats() DatabaseStats {
+	d.RLock()
+	namespaces := d.ownedNamespacesWithLock()
+	d.RUnlock()
+
+	var stats DatabaseStats
+	for _, ns := range namespaces {
+		for _, shard := range ns.GetOwnedShards() {
+			stats.NumSeries += shard.NumSeries()
+			stats.NumBufferedDatapoints += shard.NumBufferedDatapoints()
+		}
+	}
+	return stats
+}
+
+func (d *db) SetRetentionPeriod(namespace ident.ID, value time.Duration) error {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return err
+	}
+
+	prev := n.Options().RetentionOptions().RetentionPeriod()
+	if err := n.SetRetentionPeriod(value); err != nil {
+		return xerrors.NewInvalidParamsError(err)
+	}
+	d.log.WithFields(
+		xlog.NewField("namespace", namespace.String()),
+		xlog.NewField("prev", prev.String()),
+		xlog.NewField("next", value.String()),
+	).Infof("updated namespace retention period")
+
+	if value >= prev || d.opts.ReadOnly() || !d.IsBootstrapped() {
+		return nil
+	}
+
+	// Clean up the data files no longer retained now rather than waiting for
+	// the next regularly scheduled file operations run, which cleans them up
+	// regardless so failing to do so here does not fail the update.
+	if err := d.mediator.CleanupNow(d.nowFn()); err != nil {
+		d.log.WithFields(
+			xlog.NewField("namespace", namespace.String()),
+			xlog.NewField("error", err.Error()),
+		).Warnf("deferred clean up of data files no longer retained to the next file operations run")
+	}
+	return nil
+}
+
 func (d *db) Truncate(namespace ident.ID) (int64, error) {
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
@@ -634,6 +760,14 @@ func (d *db) Truncate(namespace ident.ID) (int64, error) {
 	return n.Truncate()
 }
 
+func (d *db) MissingWithin(namespace ident.ID, bounds xtime.Range) (xtime.Ranges, error) {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return xtime.Ranges{}, err
+	}
+	return n.MissingWithin(bounds), nil
+}
+
 func (d *db) IsOverloaded() bool {
 	return d.errors.Count(d.errWindow) > d.errThreshold
 }
@@ -451,6 +451,25 @@ func TestGetOwnedNamespacesErrorIfClosed(t *testing.T) {
 	require.Equal(t, errDatabaseIsClosed, err)
 }
 
+func TestDatabaseShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, BootstrapNotStarted)
+	defer func() {
+		close(mapCh)
+	}()
+
+	require.Equal(t, []uint32{0, 1}, d.Shards())
+
+	// Shards are returned in order regardless of the order they were assigned
+	shardSet, err := sharding.NewShardSet(sharding.NewShards([]uint32{7, 2, 5}, shard.Available), nil)
+	require.NoError(t, err)
+	d.AssignShardSet(shardSet)
+
+	require.Equal(t, []uint32{2, 5, 7}, d.Shards())
+}
+
 func TestDatabaseAssignShardSet(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -746,3 +765,234 @@ func TestDatabaseBootstrapState(t *testing.T) {
 		},
 	}, dbBootstrapState)
 }
+
+func TestDatabaseSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	var (
+		blockSize = 2 * time.Hour
+		nsOpts    = namespace.NewOptions().SetRetentionOptions(retention.NewOptions().
+				SetBlockSize(blockSize).
+				SetBufferPast(10 * time.Minute).
+				SetBufferFuture(2 * time.Minute))
+		currBlockStart = time.Unix(0, 0).Add(100 * blockSize)
+		prevBlockStart = currBlockStart.Add(-blockSize)
+		snapshotTime   = currBlockStart.Add(5 * time.Minute)
+	)
+
+	// The previous block has already been flushed for the first namespace
+	// so only the current block should be snapshotted
+	ns1 := dbAddNewMockNamespace(ctrl, d, "testns1")
+	ns1.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns1.EXPECT().NeedsFlush(currBlockStart, currBlockStart).Return(true)
+	ns1.EXPECT().NeedsFlush(prevBlockStart, prevBlockStart).Return(false)
+	ns1.EXPECT().Snapshot(currBlockStart, snapshotTime, gomock.Any()).Return(nil)
+
+	// The previous block can still hold buffered data for the second namespace
+	ns2 := dbAddNewMockNamespace(ctrl, d, "testns2")
+	ns2.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns2.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(true).Times(2)
+	ns2.EXPECT().Snapshot(currBlockStart, snapshotTime, gomock.Any()).Return(nil)
+	ns2.EXPECT().Snapshot(prevBlockStart, snapshotTime, gomock.Any()).Return(nil)
+
+	require.NoError(t, d.Snapshot(snapshotTime))
+}
+
+func TestDatabaseSnapshotNotBootstrapped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapping)
+	defer func() {
+		close(mapCh)
+	}()
+
+	require.Equal(t, errDatabaseNotBootstrapped, d.Snapshot(time.Now()))
+}
+
+func TestDatabaseFlushNow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	mediator.EXPECT().IsBootstrapped().Return(true).AnyTimes()
+	d.mediator = mediator
+
+	// Flushes on demand are rejected while other file operations run
+	blockStart := time.Unix(0, 0).Add(101 * 2 * time.Hour)
+	mediator.EXPECT().FlushNow(blockStart).Return(nil)
+	require.NoError(t, d.FlushNow(blockStart))
+
+	mediator.EXPECT().FlushNow(blockStart).Return(errFlushOperationsInProgress)
+	require.Equal(t, errFlushOperationsInProgress, d.FlushNow(blockStart))
+}
+
+func TestDatabaseFlushNowNotBootstrapped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapping)
+	defer func() {
+		close(mapCh)
+	}()
+
+	require.Equal(t, errDatabaseNotBootstrapped, d.FlushNow(time.Now()))
+}
+
+func TestDatabaseTick(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	d.mediator = mediator
+
+	expected := TickResult{FlushedBlocks: 2, FailedBlocks: 1, MoreWork: true}
+	mediator.EXPECT().Tick(syncRun, noForce).Return(expected, nil)
+	result, err := d.Tick()
+	require.NoError(t, err)
+	require.Equal(t, expected, result)
+
+	mediator.EXPECT().Tick(syncRun, noForce).Return(TickResult{}, errTickInProgress)
+	_, err = d.Tick()
+	require.Equal(t, errTickInProgress, err)
+}
+
+func TestDatabaseMissingWithin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	var (
+		now    = time.Now()
+		bounds = xtime.Range{Start: now.Add(-24 * time.Hour), End: now}
+		gap    = xtime.Range{Start: now.Add(-12 * time.Hour), End: now.Add(-10 * time.Hour)}
+	)
+	ns := dbAddNewMockNamespace(ctrl, d, "testns1")
+	ns.EXPECT().MissingWithin(bounds).Return(xtime.NewRanges(gap))
+
+	missing, err := d.MissingWithin(ident.StringID("testns1"), bounds)
+	require.NoError(t, err)
+	require.Equal(t, xtime.NewRanges(gap), missing)
+
+	_, err = d.MissingWithin(ident.StringID("nonexistent"), bounds)
+	require.Error(t, err)
+}
+
+func TestDatabaseSetRetentionPeriod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	mediator.EXPECT().IsBootstrapped().Return(true).AnyTimes()
+	d.mediator = mediator
+
+	ns := dbAddNewMockNamespace(ctrl, d, "testns1")
+	ns.EXPECT().Options().Return(defaultTestNs1Opts).AnyTimes()
+
+	// Extending retention does not need a cleanup
+	ns.EXPECT().SetRetentionPeriod(4 * 24 * time.Hour).Return(nil)
+	require.NoError(t, d.SetRetentionPeriod(ident.StringID("testns1"), 4*24*time.Hour))
+
+	// Shrinking retention cleans up the files no longer retained
+	ns.EXPECT().SetRetentionPeriod(24 * time.Hour).Return(nil)
+	mediator.EXPECT().CleanupNow(gomock.Any()).Return(nil)
+	require.NoError(t, d.SetRetentionPeriod(ident.StringID("testns1"), 24*time.Hour))
+
+	// A cleanup rejected while other file operations are in progress is left
+	// to the next file operations run
+	ns.EXPECT().SetRetentionPeriod(24 * time.Hour).Return(nil)
+	mediator.EXPECT().CleanupNow(gomock.Any()).Return(errFlushOperationsInProgress)
+	require.NoError(t, d.SetRetentionPeriod(ident.StringID("testns1"), 24*time.Hour))
+
+	ns.EXPECT().SetRetentionPeriod(time.Hour).Return(errRetentionPeriodBelowBlockSize)
+	err := d.SetRetentionPeriod(ident.StringID("testns1"), time.Hour)
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+
+	require.Error(t, d.SetRetentionPeriod(ident.StringID("nonexistent"), 24*time.Hour))
+}
+
+func TestDatabaseStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	newShard := func(numSeries, numDatapoints int64) databaseShard {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().NumSeries().Return(numSeries)
+		shard.EXPECT().NumBufferedDatapoints().Return(numDatapoints)
+		return shard
+	}
+
+	ns1 := dbAddNewMockNamespace(ctrl, d, "testns1")
+	ns1.EXPECT().GetOwnedShards().Return([]databaseShard{
+		newShard(2, 10),
+		newShard(3, 20),
+	})
+	ns2 := dbAddNewMockNamespace(ctrl, d, "testns2")
+	ns2.EXPECT().GetOwnedShards().Return([]databaseShard{
+		newShard(5, 40),
+	})
+
+	require.Equal(t, DatabaseStats{
+		NumSeries:             10,
+		NumBufferedDatapoints: 70,
+	}, d.Stats())
+}
+
+func TestDatabaseReadOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+	d.opts = d.opts.SetReadOnly(true)
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	var (
+		ns  = ident.StringID("testns1")
+		id  = ident.StringID("foo")
+		now = time.Now()
+	)
+	require.Equal(t, errDatabaseReadOnly,
+		d.Write(ctx, ns, id, now, 1.0, xtime.Second, nil))
+	require.Equal(t, errDatabaseReadOnly,
+		d.WriteTagged(ctx, ns, id, ident.EmptyTagIterator, now, 1.0, xtime.Second, nil))
+	require.Equal(t, errDatabaseReadOnly, d.Snapshot(now))
+	require.Equal(t, errDatabaseReadOnly, d.FlushNow(now))
+	require.Equal(t, BatchError{{Index: 0, Err: errDatabaseReadOnly}},
+		d.WriteBatch(ctx, ns, []DataPoint{{ID: id, Timestamp: now, Value: 1.0, Unit: xtime.Second}}))
+}
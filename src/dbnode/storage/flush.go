@@ -21,14 +21,17 @@
 package storage
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"sync"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/retention"
 	xerrors "github.com/m3db/m3x/errors"
+	xtime "github.com/m3db/m3x/time"
 
 	"github.com/uber-go/tally"
 )
@@ -62,6 +65,10 @@ type flushManager struct {
 	isFlushing      tally.Gauge
 	isSnapshotting  tally.Gauge
 	isIndexFlushing tally.Gauge
+	// lastSelected tracks when blocks of each namespace were last selected
+	// for a flush within the flush retry backoff, it is only accessed by the
+	// single flush in progress.
+	lastSelected map[string]map[xtime.UnixNano]time.Time
 }
 
 func newFlushManager(database database, scope tally.Scope) databaseFlushManager {
@@ -73,48 +80,53 @@ func newFlushManager(database database, scope tally.Scope) databaseFlushManager
 		isFlushing:      scope.Gauge("flush"),
 		isSnapshotting:  scope.Gauge("snapshot"),
 		isIndexFlushing: scope.Gauge("index-flush"),
+		lastSelected:    make(map[string]map[xtime.UnixNano]time.Time),
 	}
 }
 
 func (m *flushManager) Flush(
 	tickStart time.Time,
 	dbBootstrapStateAtTickStart DatabaseBootstrapState,
-) error {
+) (flushResult, error) {
+	var result flushResult
+
 	// ensure only a single flush is happening at a time
-	m.Lock()
-	if m.state != flushManagerIdle {
-		m.Unlock()
-		return errFlushOperationsInProgress
+	if err := m.setNotIdle(); err != nil {
+		return result, err
 	}
-	m.state = flushManagerNotIdle
-	m.Unlock()
-
 	defer m.setState(flushManagerIdle)
 
 	// create flush-er
 	flush, err := m.pm.StartDataPersist()
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	namespaces, err := m.database.GetOwnedNamespaces()
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	multiErr := xerrors.NewMultiError()
 	m.setState(flushManagerFlushInProgress)
 	for _, ns := range namespaces {
 		// Flush first because we will only snapshot if there are no outstanding flushes
-		flushTimes := m.namespaceFlushTimes(ns, tickStart)
+		flushTimes, numDeferred, numEmpty := m.namespaceFlushTimes(ns, tickStart)
+		result.deferredBlocks += numDeferred
+		result.emptyBlocks += numEmpty
 		shardBootstrapTimes, ok := dbBootstrapStateAtTickStart.NamespaceBootstrapStates[ns.ID().String()]
 		if !ok {
 			// Could happen if namespaces are added / removed.
 			multiErr = multiErr.Add(fmt.Errorf(
 				"tried to flush ns: %s, but did not have shard bootstrap times", ns.ID().String()))
+			result.failedBlocks += len(flushTimes)
 			continue
 		}
-		multiErr = multiErr.Add(m.flushNamespaceWithTimes(ns, shardBootstrapTimes, flushTimes, flush))
+		nsResult, err := m.flushNamespaceWithTimes(ns, shardBootstrapTimes, flushTimes, flush)
+		result.flushedBlocks += nsResult.flushedBlocks
+		result.failedBlocks += nsResult.failedBlocks
+		result.deferredBlocks += nsResult.deferredBlocks
+		multiErr = multiErr.Add(err)
 	}
 
 	// Perform two separate loops through all the namespaces so that we can emit better
@@ -148,7 +160,7 @@ func (m *flushManager) Flush(
 	indexFlush, err := m.pm.StartIndexPersist()
 	if err != nil {
 		multiErr = multiErr.Add(err)
-		return multiErr.FinalError()
+		return result, multiErr.FinalError()
 	}
 
 	m.setState(flushManagerIndexFlushInProgress)
@@ -165,6 +177,109 @@ func (m *flushManager) Flush(
 	// mark index flush finished
 	multiErr = multiErr.Add(indexFlush.DoneIndex())
 
+	return result, multiErr.FinalError()
+}
+
+func (m *flushManager) Snapshot(snapshotTime time.Time) error {
+	// ensure snapshots on demand do not race with a flush
+	if err := m.setNotIdle(); err != nil {
+		return err
+	}
+	defer m.setState(flushManagerIdle)
+
+	namespaces, err := m.database.GetOwnedNamespaces()
+	if err != nil {
+		return err
+	}
+
+	flush, err := m.pm.StartDataPersist()
+	if err != nil {
+		return err
+	}
+
+	multiErr := xerrors.NewMultiError()
+	m.setState(flushManagerSnapshotInProgress)
+	for _, ns := range namespaces {
+		var (
+			ropts     = ns.Options().RetentionOptions()
+			blockSize = ropts.BlockSize()
+			earliest  = retention.BlockStart(ropts, snapshotTime.Add(-ropts.BufferPast()))
+			latest    = retention.BlockStart(ropts, snapshotTime.Add(ropts.BufferFuture()))
+		)
+		// Snapshot every block that can still hold buffered data, skipping
+		// blocks that have already been flushed by every shard
+		for _, blockStart := range timesInRange(earliest, latest, blockSize) {
+			if !ns.NeedsFlush(blockStart, blockStart) {
+				continue
+			}
+			if err := ns.Snapshot(blockStart, snapshotTime, flush); err != nil {
+				detailedErr := fmt.Errorf("namespace %s failed to snapshot data: %v",
+					ns.ID().String(), err)
+				multiErr = multiErr.Add(detailedErr)
+			}
+		}
+	}
+
+	multiErr = multiErr.Add(flush.DoneData())
+	return multiErr.FinalError()
+}
+
+func (m *flushManager) FlushNow(blockStart time.Time) error {
+	// ensure flushes on demand do not race with a regular flush
+	if err := m.setNotIdle(); err != nil {
+		return err
+	}
+	defer m.setState(flushManagerIdle)
+
+	namespaces, err := m.database.GetOwnedNamespaces()
+	if err != nil {
+		return err
+	}
+
+	flush, err := m.pm.StartDataPersist()
+	if err != nil {
+		return err
+	}
+
+	var (
+		multiErr = xerrors.NewMultiError()
+		now      = m.opts.ClockOptions().NowFn()()
+		aligned  = 0
+	)
+	m.setState(flushManagerFlushInProgress)
+	for _, ns := range namespaces {
+		// Namespaces can have different block sizes, only flush the namespaces
+		// for which the block start is a block boundary
+		ropts := ns.Options().RetentionOptions()
+		if !retention.BlockStart(ropts, blockStart).Equal(blockStart) {
+			continue
+		}
+		aligned++
+
+		// Blocks that can still receive writes are never flushed on demand
+		earliest, latest := m.flushRange(ropts, now)
+		if blockStart.Before(earliest) || blockStart.After(latest) {
+			multiErr = multiErr.Add(fmt.Errorf(
+				"namespace %s failed to flush at time %v, block is not flushable",
+				ns.ID().String(), blockStart.String()))
+			continue
+		}
+		if !ns.NeedsFlushAttempt(blockStart, blockStart) {
+			continue
+		}
+		if err := ns.FlushNow(blockStart, flush); err != nil {
+			detailedErr := fmt.Errorf("namespace %s failed to flush data: %v",
+				ns.ID().String(), err)
+			multiErr = multiErr.Add(detailedErr)
+		}
+	}
+	if len(namespaces) > 0 && aligned == 0 {
+		multiErr = multiErr.Add(fmt.Errorf(
+			"failed to flush at time %v, not aligned to the blockSize of any namespace",
+			blockStart.String()))
+	}
+
+	multiErr = multiErr.Add(flush.DoneData())
 	return multiErr.FinalError()
 }
 
@@ -192,6 +307,18 @@ func (m *flushManager) Report() {
 	}
 }
 
+// setNotIdle marks the flush manager as in use, returning an error if it is
+// already in use by a flush or snapshot.
+func (m *flushManager) setNotIdle() error {
+	m.Lock()
+	defer m.Unlock()
+	if m.state != flushManagerIdle {
+		return errFlushOperationsInProgress
+	}
+	m.state = flushManagerNotIdle
+	return nil
+}
+
 func (m *flushManager) setState(state flushManagerState) {
 	m.Lock()
 	m.state = state
@@ -201,7 +328,6 @@ func (m *flushManager) setState(state flushManagerState) {
 func (m *flushManager) snapshotBlockStart(ns databaseNamespace, curr time.Time) time.Time {
 	var (
 		rOpts      = ns.Options().RetentionOptions()
-		blockSize  = rOpts.BlockSize()
 		bufferPast = rOpts.BufferPast()
 	)
 	// Only begin snapshotting a new block once the previous one is immutable. I.E if we have
@@ -213,24 +339,79 @@ func (m *flushManager) snapshotBlockStart(ns databaseNamespace, curr time.Time)
 	// 		   "buffer past" writes) and 2:09.Add(-10min).Truncate(2hours) = 12PM
 	// 		4) 2:10PM we want to snapshot with a 2PM block start (because the 12PM block can no long receive
 	// 		   "buffer past" writes) and 2:10.Add(-10min).Truncate(2hours) = 2PM
-	return curr.Add(-bufferPast).Truncate(blockSize)
+	return retention.BlockStart(rOpts, curr.Add(-bufferPast))
 }
 
 func (m *flushManager) flushRange(ropts retention.Options, t time.Time) (time.Time, time.Time) {
 	return retention.FlushTimeStart(ropts, t), retention.FlushTimeEnd(ropts, t)
 }
 
-func (m *flushManager) namespaceFlushTimes(ns databaseNamespace, curr time.Time) []time.Time {
+// namespaceFlushTimes returns the block starts of the namespace to flush, along
+// with the number of blocks that need flushing but are deferred to a later flush
+// and the number of empty blocks left unflushed until data arrives for them.
+func (m *flushManager) namespaceFlushTimes(ns databaseNamespace, curr time.Time) ([]time.Time, int, int) {
 	var (
 		rOpts            = ns.Options().RetentionOptions()
 		blockSize        = rOpts.BlockSize()
 		earliest, latest = m.flushRange(rOpts, curr)
+		skipEmpty        = m.opts.SkipFlushEmptyBlocks()
+		retryBackoff     = m.opts.FlushRetryBackoff()
+		selected         = m.recentlySelected(ns, curr, retryBackoff)
+		numDeferred      int
+		numEmpty         int
 	)
 
 	candidateTimes := timesInRange(earliest, latest, blockSize)
-	return filterTimes(candidateTimes, func(t time.Time) bool {
-		return ns.NeedsFlush(t, t)
+	flushTimes := filterTimes(candidateTimes, func(t time.Time) bool {
+		if !ns.NeedsFlushAttempt(t, t) {
+			return false
+		}
+		// Blocks selected within the retry backoff still need a flush after
+		// the previous attempt, e.g. as it failed, so they back off before
+		// being attempted again.
+		if _, ok := selected[xtime.ToUnixNano(t)]; ok {
+			numDeferred++
+			return false
+		}
+		// NB: blocks without data remain unflushed and are reconsidered on
+		// subsequent flushes in case data arrives for them, e.g. from a bootstrap.
+		if skipEmpty && !ns.HasData(t) {
+			numEmpty++
+			return false
+		}
+		return true
 	})
+	if retryBackoff > 0 {
+		for _, t := range flushTimes {
+			selected[xtime.ToUnixNano(t)] = curr
+		}
+	}
+	return flushTimes, numDeferred, numEmpty
+}
+
+// recentlySelected returns the blocks of the namespace selected for a flush
+// within the retry backoff before curr, evicting blocks selected earlier.
+func (m *flushManager) recentlySelected(
+	ns databaseNamespace,
+	curr time.Time,
+	retryBackoff time.Duration,
+) map[xtime.UnixNano]time.Time {
+	key := ns.ID().String()
+	if retryBackoff <= 0 {
+		delete(m.lastSelected, key)
+		return nil
+	}
+	selected, ok := m.lastSelected[key]
+	if !ok {
+		selected = make(map[xtime.UnixNano]time.Time)
+		m.lastSelected[key] = selected
+	}
+	for t, at := range selected {
+		if curr.Sub(at) >= retryBackoff {
+			delete(selected, t)
+		}
+	}
+	return selected
 }
 
 // flushWithTime flushes in-memory data for a given namespace, at a given
@@ -240,16 +421,57 @@ func (m *flushManager) flushNamespaceWithTimes(
 	ShardBootstrapStates ShardBootstrapStates,
 	times []time.Time,
 	flush persist.DataFlush,
-) error {
+) (flushResult, error) {
+	var result flushResult
 	multiErr := xerrors.NewMultiError()
 	for _, t := range times {
 		// NB(xichen): we still want to proceed if a namespace fails to flush its data.
 		// Probably want to emit a counter here, but for now just log it.
-		if err := ns.Flush(t, ShardBootstrapStates, flush); err != nil {
+		nsResult, err := ns.Flush(t, ShardBootstrapStates, flush)
+		if err != nil {
 			detailedErr := fmt.Errorf("namespace %s failed to flush data: %v",
 				ns.ID().String(), err)
 			multiErr = multiErr.Add(detailedErr)
+			result.failedBlocks++
+			continue
+		}
+		switch {
+		case nsResult.deferredShards > 0:
+			// The block is only flushed once every shard has flushed it
+			result.deferredBlocks++
+		case nsResult.flushedShards > 0:
+			result.flushedBlocks++
 		}
 	}
-	return multiErr.FinalError()
+	return result, multiErr.FinalError()
+}
+
+// flushJitterOffset returns a deterministic offset in [0, jitter) for a given
+// shard and block start, so that retries of the same flush observe the same
+// offset while different shards are spread across the jitter window.
+func flushJitterOffset(shard uint32, blockStart time.Time, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return 0
+	}
+	var buf [12]byte
+	binary.LittleEndian.PutUint32(buf[:4], shard)
+	binary.LittleEndian.PutUint64(buf[4:], uint64(blockStart.UnixNano()))
+	h := fnv.New64a()
+	h.Write(buf[:])
+	return time.Duration(h.Sum64() % uint64(jitter))
+}
+
+// flushEligibleTime returns the earliest time at which a shard may flush the
+// block starting at blockStart, accounting for the block's buffer past and the
+// shard's flush jitter offset.
+func flushEligibleTime(
+	ropts retention.Options,
+	shard uint32,
+	blockStart time.Time,
+	jitter time.Duration,
+) time.Time {
+	return blockStart.
+		Add(ropts.BlockSize()).
+		Add(ropts.BufferPast()).
+		Add(flushJitterOffset(shard, blockStart, jitter))
 }
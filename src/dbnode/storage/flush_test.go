@@ -96,20 +96,84 @@ func TestFlushManagerFlushAlreadyInProgress(t *testing.T) {
 	// go routine 1 should successfully flush
 	go func() {
 		defer wg.Done()
-		require.NoError(t, fm.Flush(now, DatabaseBootstrapState{}))
+		_, err := fm.Flush(now, DatabaseBootstrapState{})
+		require.NoError(t, err)
 	}()
 
 	// go routine 2 should indicate already flushing
 	go func() {
 		defer wg.Done()
 		<-startCh
-		require.Equal(t, errFlushOperationsInProgress, fm.Flush(now, DatabaseBootstrapState{}))
+		_, err := fm.Flush(now, DatabaseBootstrapState{})
+		require.Equal(t, errFlushOperationsInProgress, err)
 		doneCh <- struct{}{}
 	}()
 
 	wg.Wait()
 }
 
+func TestFlushManagerSnapshotWhileFlushInProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fm, _, _ := newMultipleFlushManagerNeedsFlush(t, ctrl)
+	fm.state = flushManagerFlushInProgress
+
+	require.Equal(t, errFlushOperationsInProgress, fm.Snapshot(time.Now()))
+	require.Equal(t, flushManagerFlushInProgress, fm.state)
+}
+
+func TestFlushManagerFlushNow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		blockSize = 2 * time.Hour
+		now       = time.Unix(0, 0).Add(1000*blockSize + 30*time.Minute)
+		openBlock = retention.BlockStartForBlockSize(blockSize, now)
+		prevBlock = openBlock.Add(-blockSize)
+	)
+
+	ns1 := NewMockdatabaseNamespace(ctrl)
+	ns1.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
+	ns1.EXPECT().Options().Return(namespace.NewOptions().SetRetentionOptions(
+		retention.NewOptions().SetBlockSize(blockSize))).AnyTimes()
+	ns2 := NewMockdatabaseNamespace(ctrl)
+	ns2.EXPECT().ID().Return(ident.StringID("testns2")).AnyTimes()
+	ns2.EXPECT().Options().Return(namespace.NewOptions().SetRetentionOptions(
+		retention.NewOptions().SetBlockSize(4 * blockSize))).AnyTimes()
+
+	flush := persist.NewMockDataFlush(ctrl)
+	flush.EXPECT().DoneData().Return(nil).AnyTimes()
+	pm := persist.NewMockManager(ctrl)
+	pm.EXPECT().StartDataPersist().Return(flush, nil).AnyTimes()
+
+	db := newMockdatabase(ctrl, ns1, ns2)
+	fm := newFlushManager(db, tally.NoopScope).(*flushManager)
+	fm.pm = pm
+	fm.opts = fm.opts.SetClockOptions(fm.opts.ClockOptions().SetNowFn(func() time.Time {
+		return now
+	}))
+
+	// The previous block is not a block boundary of the second namespace
+	ns1.EXPECT().NeedsFlushAttempt(prevBlock, prevBlock).Return(true)
+	ns1.EXPECT().FlushNow(prevBlock, flush).Return(nil)
+	require.NoError(t, fm.FlushNow(prevBlock))
+
+	// Blocks already flushed are not flushed again
+	ns1.EXPECT().NeedsFlushAttempt(prevBlock, prevBlock).Return(false)
+	require.NoError(t, fm.FlushNow(prevBlock))
+
+	// The open block can still receive writes and is never flushed
+	require.Error(t, fm.FlushNow(openBlock))
+
+	require.Error(t, fm.FlushNow(prevBlock.Add(time.Minute)))
+
+	// Flushes on demand do not race with a regular flush
+	fm.state = flushManagerFlushInProgress
+	require.Equal(t, errFlushOperationsInProgress, fm.FlushNow(prevBlock))
+}
+
 func TestFlushManagerFlushDoneDataError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -133,7 +197,8 @@ func TestFlushManagerFlushDoneDataError(t *testing.T) {
 	fm.pm = mockPersistManager
 
 	now := time.Unix(0, 0)
-	require.EqualError(t, fakeErr, fm.Flush(now, DatabaseBootstrapState{}).Error())
+	_, err := fm.Flush(now, DatabaseBootstrapState{})
+	require.EqualError(t, fakeErr, err.Error())
 }
 
 func TestFlushManagerFlushDoneIndexError(t *testing.T) {
@@ -159,7 +224,8 @@ func TestFlushManagerFlushDoneIndexError(t *testing.T) {
 	fm.pm = mockPersistManager
 
 	now := time.Unix(0, 0)
-	require.EqualError(t, fakeErr, fm.Flush(now, DatabaseBootstrapState{}).Error())
+	_, err := fm.Flush(now, DatabaseBootstrapState{})
+	require.EqualError(t, fakeErr, err.Error())
 }
 
 func TestFlushManagerSkipNamespaceIndexingDisabled(t *testing.T) {
@@ -171,7 +237,8 @@ func TestFlushManagerSkipNamespaceIndexingDisabled(t *testing.T) {
 	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
 	ns.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
 	ns.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
-	ns.EXPECT().Flush(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ns.EXPECT().NeedsFlushAttempt(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	ns.EXPECT().Flush(gomock.Any(), gomock.Any(), gomock.Any()).Return(namespaceFlushResult{flushedShards: 1}, nil).AnyTimes()
 
 	mockFlusher := persist.NewMockDataFlush(ctrl)
 	mockFlusher.EXPECT().DoneData().Return(nil)
@@ -196,7 +263,8 @@ func TestFlushManagerSkipNamespaceIndexingDisabled(t *testing.T) {
 			ns.ID().String(): ShardBootstrapStates{},
 		},
 	}
-	require.NoError(t, fm.Flush(now, bootstrapStates))
+	_, err := fm.Flush(now, bootstrapStates)
+	require.NoError(t, err)
 }
 
 func TestFlushManagerNamespaceIndexingEnabled(t *testing.T) {
@@ -208,7 +276,8 @@ func TestFlushManagerNamespaceIndexingEnabled(t *testing.T) {
 	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
 	ns.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
 	ns.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
-	ns.EXPECT().Flush(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ns.EXPECT().NeedsFlushAttempt(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	ns.EXPECT().Flush(gomock.Any(), gomock.Any(), gomock.Any()).Return(namespaceFlushResult{flushedShards: 1}, nil).AnyTimes()
 	ns.EXPECT().FlushIndex(gomock.Any()).Return(nil)
 
 	mockFlusher := persist.NewMockDataFlush(ctrl)
@@ -234,7 +303,119 @@ func TestFlushManagerNamespaceIndexingEnabled(t *testing.T) {
 			ns.ID().String(): ShardBootstrapStates{},
 		},
 	}
-	require.NoError(t, fm.Flush(now, bootstrapStates))
+	_, err := fm.Flush(now, bootstrapStates)
+	require.NoError(t, err)
+}
+
+func TestFlushManagerFlushResult(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{t})
+	defer ctrl.Finish()
+
+	nsOpts := defaultTestNs1Opts.SetIndexOptions(namespace.NewIndexOptions().SetEnabled(false))
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
+	ns.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	ns.EXPECT().NeedsFlushAttempt(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+
+	// The first block fails to flush and the rest succeed
+	fakeErr := errors.New("fake error while flushing")
+	ns.EXPECT().Flush(gomock.Any(), gomock.Any(), gomock.Any()).Return(namespaceFlushResult{}, fakeErr)
+	ns.EXPECT().Flush(gomock.Any(), gomock.Any(), gomock.Any()).Return(namespaceFlushResult{flushedShards: 1}, nil).AnyTimes()
+
+	mockFlusher := persist.NewMockDataFlush(ctrl)
+	mockFlusher.EXPECT().DoneData().Return(nil)
+	mockPersistManager := persist.NewMockManager(ctrl)
+	mockPersistManager.EXPECT().StartDataPersist().Return(mockFlusher, nil)
+
+	mockIndexFlusher := persist.NewMockIndexFlush(ctrl)
+	mockIndexFlusher.EXPECT().DoneIndex().Return(nil)
+	mockPersistManager.EXPECT().StartIndexPersist().Return(mockIndexFlusher, nil)
+
+	testOpts := testDatabaseOptions().SetPersistManager(mockPersistManager)
+	db := newMockdatabase(ctrl)
+	db.EXPECT().Options().Return(testOpts).AnyTimes()
+	db.EXPECT().GetOwnedNamespaces().Return([]databaseNamespace{ns}, nil)
+
+	fm := newFlushManager(db, tally.NoopScope).(*flushManager)
+	fm.pm = mockPersistManager
+
+	var (
+		now       = time.Unix(0, 0).Add(10 * 24 * time.Hour)
+		ropts     = nsOpts.RetentionOptions()
+		start     = retention.FlushTimeStart(ropts, now)
+		end       = retention.FlushTimeEnd(ropts, now)
+		numBlocks = numIntervals(start, end, ropts.BlockSize())
+	)
+	require.True(t, numBlocks > 1)
+
+	bootstrapStates := DatabaseBootstrapState{
+		NamespaceBootstrapStates: map[string]ShardBootstrapStates{
+			ns.ID().String(): ShardBootstrapStates{},
+		},
+	}
+	result, err := fm.Flush(now, bootstrapStates)
+	require.Error(t, err)
+	require.Equal(t, flushResult{
+		flushedBlocks: numBlocks - 1,
+		failedBlocks:  1,
+	}, result)
+}
+
+func TestFlushManagerFlushResultDeferredShards(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{t})
+	defer ctrl.Finish()
+
+	nsOpts := defaultTestNs1Opts.SetIndexOptions(namespace.NewIndexOptions().SetEnabled(false))
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
+	ns.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	ns.EXPECT().NeedsFlushAttempt(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+
+	// A shard is yet to flush the first block as its flush jitter has not
+	// elapsed, the other shard flushed it
+	ns.EXPECT().Flush(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(namespaceFlushResult{flushedShards: 1, deferredShards: 1}, nil)
+	ns.EXPECT().Flush(gomock.Any(), gomock.Any(), gomock.Any()).Return(namespaceFlushResult{flushedShards: 1}, nil).AnyTimes()
+
+	mockFlusher := persist.NewMockDataFlush(ctrl)
+	mockFlusher.EXPECT().DoneData().Return(nil)
+	mockPersistManager := persist.NewMockManager(ctrl)
+	mockPersistManager.EXPECT().StartDataPersist().Return(mockFlusher, nil)
+
+	mockIndexFlusher := persist.NewMockIndexFlush(ctrl)
+	mockIndexFlusher.EXPECT().DoneIndex().Return(nil)
+	mockPersistManager.EXPECT().StartIndexPersist().Return(mockIndexFlusher, nil)
+
+	testOpts := testDatabaseOptions().SetPersistManager(mockPersistManager)
+	db := newMockdatabase(ctrl)
+	db.EXPECT().Options().Return(testOpts).AnyTimes()
+	db.EXPECT().GetOwnedNamespaces().Return([]databaseNamespace{ns}, nil)
+
+	fm := newFlushManager(db, tally.NoopScope).(*flushManager)
+	fm.pm = mockPersistManager
+
+	var (
+		now       = time.Unix(0, 0).Add(10 * 24 * time.Hour)
+		ropts     = nsOpts.RetentionOptions()
+		start     = retention.FlushTimeStart(ropts, now)
+		end       = retention.FlushTimeEnd(ropts, now)
+		numBlocks = numIntervals(start, end, ropts.BlockSize())
+	)
+	require.True(t, numBlocks > 1)
+
+	bootstrapStates := DatabaseBootstrapState{
+		NamespaceBootstrapStates: map[string]ShardBootstrapStates{
+			ns.ID().String(): ShardBootstrapStates{},
+		},
+	}
+	result, err := fm.Flush(now, bootstrapStates)
+	require.NoError(t, err)
+	require.Equal(t, flushResult{
+		flushedBlocks:  numBlocks - 1,
+		deferredBlocks: 1,
+	}, result)
 }
 
 func TestFlushManagerFlushTimeStart(t *testing.T) {
@@ -285,8 +466,10 @@ func TestFlushManagerNamespaceFlushTimesNoNeedFlush(t *testing.T) {
 	now := time.Now()
 
 	ns1.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(false).AnyTimes()
-	flushTimes := fm.namespaceFlushTimes(ns1, now)
+	ns1.EXPECT().NeedsFlushAttempt(gomock.Any(), gomock.Any()).Return(false).AnyTimes()
+	flushTimes, numDeferred, _ := fm.namespaceFlushTimes(ns1, now)
 	require.Empty(t, flushTimes)
+	require.Equal(t, 0, numDeferred)
 }
 
 func TestFlushManagerNamespaceFlushTimesAllNeedFlush(t *testing.T) {
@@ -297,7 +480,8 @@ func TestFlushManagerNamespaceFlushTimesAllNeedFlush(t *testing.T) {
 	now := time.Now()
 
 	ns1.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
-	times := fm.namespaceFlushTimes(ns1, now)
+	ns1.EXPECT().NeedsFlushAttempt(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	times, _, _ := fm.namespaceFlushTimes(ns1, now)
 	sort.Sort(timesInOrder(times))
 
 	blockSize := ns1.Options().RetentionOptions().BlockSize()
@@ -328,20 +512,98 @@ func TestFlushManagerNamespaceFlushTimesSomeNeedFlush(t *testing.T) {
 
 		// skip 1/3 of input
 		if i%3 == 0 {
-			ns1.EXPECT().NeedsFlush(st, st).Return(false)
+			ns1.EXPECT().NeedsFlushAttempt(st, st).Return(false)
 			continue
 		}
 
-		ns1.EXPECT().NeedsFlush(st, st).Return(true)
+		ns1.EXPECT().NeedsFlushAttempt(st, st).Return(true)
 		expectedTimes = append(expectedTimes, st)
 	}
 
-	times := fm.namespaceFlushTimes(ns1, now)
+	times, _, _ := fm.namespaceFlushTimes(ns1, now)
 	require.NotEmpty(t, times)
 	sort.Sort(timesInOrder(times))
 	require.Equal(t, expectedTimes, times)
 }
 
+func TestFlushManagerNamespaceFlushTimesSkipEmptyBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fm, ns1, _ := newMultipleFlushManagerNeedsFlush(t, ctrl)
+	fm.opts = fm.opts.SetSkipFlushEmptyBlocks(true)
+	now := time.Now()
+
+	var (
+		ropts     = ns1.Options().RetentionOptions()
+		blockSize = ropts.BlockSize()
+		start     = retention.FlushTimeStart(ropts, now)
+		end       = retention.FlushTimeEnd(ropts, now)
+		empty     = start.Add(blockSize)
+	)
+
+	var expectedTimes []time.Time
+	for st := start; !st.After(end); st = st.Add(blockSize) {
+		ns1.EXPECT().NeedsFlushAttempt(st, st).Return(true)
+		if st.Equal(empty) {
+			ns1.EXPECT().HasData(st).Return(false)
+			continue
+		}
+		ns1.EXPECT().HasData(st).Return(true)
+		expectedTimes = append(expectedTimes, st)
+	}
+
+	times, numDeferred, numEmpty := fm.namespaceFlushTimes(ns1, now)
+	sort.Sort(timesInOrder(times))
+	require.Equal(t, expectedTimes, times)
+	require.Equal(t, 0, numDeferred)
+	require.Equal(t, 1, numEmpty)
+}
+
+func TestFlushManagerNamespaceFlushTimesRetryBackoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fm, ns1, _ := newMultipleFlushManagerNeedsFlush(t, ctrl)
+	fm.opts = fm.opts.SetFlushRetryBackoff(time.Minute)
+	ns1.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	ns1.EXPECT().NeedsFlushAttempt(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+
+	// Fixed so that the flush range does not change within the test
+	now := time.Unix(0, 0).Add(10*24*time.Hour + time.Hour)
+	first, numDeferred, _ := fm.namespaceFlushTimes(ns1, now)
+	require.NotEmpty(t, first)
+	require.Equal(t, 0, numDeferred)
+
+	// A rapid second tick backs off from retrying the blocks that still need
+	// a flush after the first attempt
+	second, numDeferred, _ := fm.namespaceFlushTimes(ns1, now.Add(time.Second))
+	require.Empty(t, second)
+	require.Equal(t, len(first), numDeferred)
+
+	// Once the backoff has elapsed blocks that still need a flush are retried
+	third, numDeferred, _ := fm.namespaceFlushTimes(ns1, now.Add(time.Minute))
+	require.Equal(t, 0, numDeferred)
+	sort.Sort(timesInOrder(first))
+	sort.Sort(timesInOrder(third))
+	require.Equal(t, first, third)
+}
+
+func TestFlushManagerNamespaceFlushTimesNoRetryBackoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fm, ns1, _ := newMultipleFlushManagerNeedsFlush(t, ctrl)
+	ns1.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+	ns1.EXPECT().NeedsFlushAttempt(gomock.Any(), gomock.Any()).Return(true).AnyTimes()
+
+	now := time.Now()
+	first, _, _ := fm.namespaceFlushTimes(ns1, now)
+	second, numDeferred, _ := fm.namespaceFlushTimes(ns1, now.Add(time.Second))
+	require.Equal(t, 0, numDeferred)
+	require.Equal(t, len(first), len(second))
+}
+
 func TestFlushManagerFlushSnapshot(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -360,7 +622,7 @@ func TestFlushManagerFlushSnapshot(t *testing.T) {
 
 		for i := 0; i < num; i++ {
 			st := start.Add(time.Duration(i) * blockSize)
-			ns.EXPECT().NeedsFlush(st, st).Return(false)
+			ns.EXPECT().NeedsFlushAttempt(st, st).Return(false)
 		}
 
 		currBlockStart := now.Add(-bufferPast).Truncate(blockSize)
@@ -375,7 +637,8 @@ func TestFlushManagerFlushSnapshot(t *testing.T) {
 			ns2.ID().String(): ShardBootstrapStates{},
 		},
 	}
-	require.NoError(t, fm.Flush(now, bootstrapStates))
+	_, err := fm.Flush(now, bootstrapStates)
+	require.NoError(t, err)
 }
 
 func TestFlushManagerFlushNoSnapshotWhileFlushPending(t *testing.T) {
@@ -396,7 +659,7 @@ func TestFlushManagerFlushNoSnapshotWhileFlushPending(t *testing.T) {
 
 		for i := 0; i < num; i++ {
 			st := start.Add(time.Duration(i) * blockSize)
-			ns.EXPECT().NeedsFlush(st, st).Return(false)
+			ns.EXPECT().NeedsFlushAttempt(st, st).Return(false)
 		}
 
 		currBlockStart := now.Add(-bufferPast).Truncate(blockSize)
@@ -410,7 +673,8 @@ func TestFlushManagerFlushNoSnapshotWhileFlushPending(t *testing.T) {
 			ns2.ID().String(): ShardBootstrapStates{},
 		},
 	}
-	require.NoError(t, fm.Flush(now, bootstrapStates))
+	_, err := fm.Flush(now, bootstrapStates)
+	require.NoError(t, err)
 }
 
 func TestFlushManagerSnapshotBlockStart(t *testing.T) {
@@ -457,6 +721,38 @@ func TestFlushManagerSnapshotBlockStart(t *testing.T) {
 	}
 }
 
+func TestFlushEligibleTimeJitterSpreadWithinWindow(t *testing.T) {
+	var (
+		rOpts = retention.NewOptions().
+			SetBlockSize(2 * time.Hour).
+			SetBufferPast(10 * time.Minute)
+		jitter     = 5 * time.Minute
+		blockStart = time.Unix(0, 0).Add(10 * rOpts.BlockSize())
+		earliest   = blockStart.Add(rOpts.BlockSize()).Add(rOpts.BufferPast())
+		latest     = earliest.Add(jitter)
+		distinct   = make(map[time.Time]struct{})
+	)
+
+	for shard := uint32(0); shard < 64; shard++ {
+		eligibleAt := flushEligibleTime(rOpts, shard, blockStart, jitter)
+		require.False(t, eligibleAt.Before(earliest))
+		require.True(t, eligibleAt.Before(latest))
+
+		// Ensure deterministic so that retries don't re-randomize
+		require.Equal(t, eligibleAt, flushEligibleTime(rOpts, shard, blockStart, jitter))
+
+		distinct[eligibleAt] = struct{}{}
+	}
+
+	// Ensure shards are actually spread across the window
+	require.True(t, len(distinct) > 1)
+
+	// Ensure no jitter means all shards are eligible at the same time
+	for shard := uint32(0); shard < 64; shard++ {
+		require.Equal(t, earliest, flushEligibleTime(rOpts, shard, blockStart, 0))
+	}
+}
+
 type timesInOrder []time.Time
 
 func (a timesInOrder) Len() int           { return len(a) }
@@ -21,12 +21,17 @@
 package storage
 
 import (
+	"errors"
 	"sync"
 	"time"
 
 	xlog "github.com/m3db/m3x/log"
 )
 
+var (
+	errFileOpsDisabled = errors.New("file operations are disabled")
+)
+
 type fileOpStatus int
 
 const (
@@ -34,6 +39,9 @@ const (
 	fileOpInProgress
 	fileOpSuccess
 	fileOpFailed
+	// fileOpPermanentlyFailed is set when a flush fails with an error that
+	// is not retryable, the flush is then never retried.
+	fileOpPermanentlyFailed
 )
 
 type fileOpState struct {
@@ -41,6 +49,19 @@ type fileOpState struct {
 	NumFailures int
 }
 
+// NeedsFlush returns whether the block is yet to be durably flushed. Blocks
+// that permanently failed to flush still need a flush so that the commit logs
+// and snapshots holding their data are not cleaned up.
+func (s fileOpState) NeedsFlush() bool {
+	return s.Status != fileOpSuccess
+}
+
+// NeedsFlushAttempt returns whether the block needs a flush that should be
+// attempted, blocks that permanently failed to flush are never flushed again.
+func (s fileOpState) NeedsFlushAttempt() bool {
+	return s.Status != fileOpPermanentlyFailed && s.NeedsFlush()
+}
+
 type runType int
 
 const (
@@ -115,22 +136,31 @@ func (m *fileSystemManager) Run(
 	dbBootstrapStates DatabaseBootstrapState,
 	runType runType,
 	forceType forceType,
-) bool {
+) (flushResult, bool) {
 	m.Lock()
 	if forceType == noForce && !m.shouldRunWithLock() {
 		m.Unlock()
-		return false
+		return flushResult{}, false
 	}
 	m.status = fileOpInProgress
 	m.Unlock()
 
 	// NB(xichen): perform data cleanup and flushing sequentially to minimize the impact of disk seeks.
+	var result flushResult
 	flushFn := func() {
 		if err := m.Cleanup(t); err != nil {
 			m.log.Errorf("error when cleaning up data for time %v: %v", t, err)
 		}
-		if err := m.Flush(t, dbBootstrapStates); err != nil {
-			m.log.Errorf("error when flushing data for time %v: %v", t, err)
+		// NB: Read only databases receive their data files from elsewhere
+		// so there is never any data to flush.
+		if !m.opts.ReadOnly() {
+			flushed, err := m.Flush(t, dbBootstrapStates)
+			if err != nil {
+				m.log.Errorf("error when flushing data for time %v: %v", t, err)
+			}
+			if runType == syncRun {
+				result = flushed
+			}
 		}
 		m.Lock()
 		m.status = fileOpNotStarted
@@ -142,7 +172,48 @@ func (m *fileSystemManager) Run(
 	} else {
 		go flushFn()
 	}
-	return true
+	return result, true
+}
+
+func (m *fileSystemManager) Snapshot(snapshotTime time.Time) error {
+	return m.runOnDemand(func() error {
+		return m.databaseFlushManager.Snapshot(snapshotTime)
+	})
+}
+
+func (m *fileSystemManager) FlushNow(blockStart time.Time) error {
+	return m.runOnDemand(func() error {
+		return m.databaseFlushManager.FlushNow(blockStart)
+	})
+}
+
+func (m *fileSystemManager) CleanupNow(t time.Time) error {
+	return m.runOnDemand(func() error {
+		return m.Cleanup(t)
+	})
+}
+
+// runOnDemand runs a file operation requested on demand, it is rejected while
+// file operations are disabled or already in progress.
+func (m *fileSystemManager) runOnDemand(fn func() error) error {
+	m.Lock()
+	if !m.enabled {
+		m.Unlock()
+		return errFileOpsDisabled
+	}
+	if m.status == fileOpInProgress {
+		m.Unlock()
+		return errFlushOperationsInProgress
+	}
+	m.status = fileOpInProgress
+	m.Unlock()
+
+	defer func() {
+		m.Lock()
+		m.status = fileOpNotStarted
+		m.Unlock()
+	}()
+	return fn()
 }
 
 func (m *fileSystemManager) Report() {
@@ -86,9 +86,114 @@ func TestFileSystemManagerRun(t *testing.T) {
 	ts := time.Now()
 	gomock.InOrder(
 		cm.EXPECT().Cleanup(ts).Return(errors.New("foo")),
-		fm.EXPECT().Flush(ts, DatabaseBootstrapState{}).Return(errors.New("bar")),
+		fm.EXPECT().Flush(ts, DatabaseBootstrapState{}).Return(flushResult{}, errors.New("bar")),
 	)
 
 	mgr.Run(ts, DatabaseBootstrapState{}, syncRun, noForce)
 	require.Equal(t, fileOpNotStarted, mgr.status)
 }
+
+func TestFileSystemManagerRunReadOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	database := newMockdatabase(ctrl)
+	database.EXPECT().IsBootstrapped().Return(true).AnyTimes()
+
+	fm := NewMockdatabaseFlushManager(ctrl)
+	cm := NewMockdatabaseCleanupManager(ctrl)
+	fsm := newFileSystemManager(database, testDatabaseOptions().SetReadOnly(true))
+	mgr := fsm.(*fileSystemManager)
+	mgr.databaseFlushManager = fm
+	mgr.databaseCleanupManager = cm
+
+	// No flush is expected to be performed
+	ts := time.Now()
+	cm.EXPECT().Cleanup(ts).Return(nil)
+
+	result, ran := mgr.Run(ts, DatabaseBootstrapState{}, syncRun, noForce)
+	require.True(t, ran)
+	require.Equal(t, flushResult{}, result)
+	require.Equal(t, fileOpNotStarted, mgr.status)
+}
+
+func TestFileSystemManagerSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	database := newMockdatabase(ctrl)
+
+	fm := NewMockdatabaseFlushManager(ctrl)
+	fsm := newFileSystemManager(database, testDatabaseOptions())
+	mgr := fsm.(*fileSystemManager)
+	mgr.databaseFlushManager = fm
+
+	// Snapshots are rejected while other file operations are in progress
+	ts := time.Now()
+	mgr.status = fileOpInProgress
+	require.Equal(t, errFlushOperationsInProgress, mgr.Snapshot(ts))
+	mgr.status = fileOpNotStarted
+
+	// Snapshots are rejected while file operations are disabled
+	mgr.Disable()
+	require.Equal(t, errFileOpsDisabled, mgr.Snapshot(ts))
+	mgr.Enable()
+
+	fm.EXPECT().Snapshot(ts).Do(func(time.Time) {
+		require.Equal(t, fileOpInProgress, mgr.Status())
+	}).Return(nil)
+	require.NoError(t, mgr.Snapshot(ts))
+	require.Equal(t, fileOpNotStarted, mgr.status)
+}
+
+func TestFileSystemManagerFlushNow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	database := newMockdatabase(ctrl)
+
+	fm := NewMockdatabaseFlushManager(ctrl)
+	fsm := newFileSystemManager(database, testDatabaseOptions())
+	mgr := fsm.(*fileSystemManager)
+	mgr.databaseFlushManager = fm
+
+	// Flushes on demand are rejected while other file operations are in progress
+	blockStart := time.Now().Truncate(2 * time.Hour)
+	mgr.status = fileOpInProgress
+	require.Equal(t, errFlushOperationsInProgress, mgr.FlushNow(blockStart))
+	mgr.status = fileOpNotStarted
+
+	mgr.Disable()
+	require.Equal(t, errFileOpsDisabled, mgr.FlushNow(blockStart))
+	mgr.Enable()
+
+	fm.EXPECT().FlushNow(blockStart).Do(func(time.Time) {
+		require.Equal(t, fileOpInProgress, mgr.Status())
+	}).Return(nil)
+	require.NoError(t, mgr.FlushNow(blockStart))
+	require.Equal(t, fileOpNotStarted, mgr.status)
+}
+
+func TestFileSystemManagerCleanupNow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	database := newMockdatabase(ctrl)
+
+	cm := NewMockdatabaseCleanupManager(ctrl)
+	fsm := newFileSystemManager(database, testDatabaseOptions())
+	mgr := fsm.(*fileSystemManager)
+	mgr.databaseCleanupManager = cm
+
+	// Cleanups on demand are rejected while other file operations are in progress
+	ts := time.Now()
+	mgr.status = fileOpInProgress
+	require.Equal(t, errFlushOperationsInProgress, mgr.CleanupNow(ts))
+	mgr.status = fileOpNotStarted
+
+	mgr.Disable()
+	require.Equal(t, errFileOpsDisabled, mgr.CleanupNow(ts))
+	mgr.Enable()
+
+	cm.EXPECT().Cleanup(ts).Do(func(time.Time) {
+		require.Equal(t, fileOpInProgress, mgr.Status())
+	}).Return(nil)
+	require.NoError(t, mgr.CleanupNow(ts))
+	require.Equal(t, fileOpNotStarted, mgr.status)
+}
@@ -243,7 +243,7 @@ func newNamespaceIndexWithOptions(
 	idx.state.insertQueue = queue
 
 	// allocate the current block to ensure we're able to index as soon as we return
-	currentBlock := nowFn().Truncate(idx.blockSize)
+	currentBlock := retention.BlockStartForBlockSize(idx.blockSize, nowFn())
 	idx.state.RLock()
 	defer idx.state.RUnlock()
 	if _, err := idx.ensureBlockPresentWithRLock(currentBlock); err != nil {
@@ -260,7 +260,7 @@ func (i *nsIndex) SetRuntimeOptions(value runtime.Options) {
 }
 
 func (i *nsIndex) BlockStartForWriteTime(writeTime time.Time) xtime.UnixNano {
-	return xtime.ToUnixNano(writeTime.Truncate(i.blockSize))
+	return xtime.ToUnixNano(retention.BlockStartForBlockSize(i.blockSize, writeTime))
 }
 
 // NB(prateek): including the call chains leading to this point:
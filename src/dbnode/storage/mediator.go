@@ -133,6 +133,19 @@ func (m *mediator) EnableFileOps() {
 	m.databaseFileSystemManager.Enable()
 }
 
+// FlushNow flushes the block on demand once the file operations already in
+// progress have completed, waiting for them for up to the flush now timeout.
+func (m *mediator) FlushNow(blockStart time.Time) error {
+	deadline := m.nowFn().Add(m.opts.FlushNowTimeout())
+	for {
+		err := m.databaseFileSystemManager.FlushNow(blockStart)
+		if err != errFlushOperationsInProgress || !m.nowFn().Before(deadline) {
+			return err
+		}
+		m.sleepFn(fileOpCheckInterval)
+	}
+}
+
 // Tick mediates the relationship between ticks and flushes/snapshots/cleanups.
 //
 // For example, the requirements to perform a flush are:
@@ -153,20 +166,27 @@ func (m *mediator) EnableFileOps() {
 // a shard flush state (due to it expiring), but since the flush logic is using a slightly more stale timestamp it
 // will think that the old block hasn't been flushed (even thought it has) and try to flush it even though the data
 // is potentially still on disk (if it hasn't been cleaned up yet).
-func (m *mediator) Tick(runType runType, forceType forceType) error {
+func (m *mediator) Tick(runType runType, forceType forceType) (TickResult, error) {
 	tickStart := m.nowFn()
 	dbBootstrapStateAtTickStart := m.database.BootstrapState()
 
 	if err := m.databaseTickManager.Tick(forceType, tickStart); err != nil {
-		return err
+		return TickResult{}, err
 	}
 
 	// NB(r): Cleanup and/or flush if required to cleanup files and/or
 	// flush blocks to disk. Note this has to run after the tick as
 	// blocks may only have just become available during a tick beginning
 	// from the tick begin marker.
-	m.databaseFileSystemManager.Run(tickStart, dbBootstrapStateAtTickStart, syncRun, forceType)
-	return nil
+	flushResult, _ := m.databaseFileSystemManager.Run(tickStart,
+		dbBootstrapStateAtTickStart, syncRun, forceType)
+	return TickResult{
+		FlushedBlocks:  flushResult.flushedBlocks,
+		FailedBlocks:   flushResult.failedBlocks,
+		DeferredBlocks: flushResult.deferredBlocks,
+		EmptyBlocks:    flushResult.emptyBlocks,
+		MoreWork:       flushResult.failedBlocks > 0 || flushResult.deferredBlocks > 0,
+	}, nil
 }
 
 func (m *mediator) Report() {
@@ -199,7 +219,7 @@ func (m *mediator) ongoingTick() {
 			// NB(xichen): if we attempt to tick while another tick
 			// is in progress, throttle a little to avoid constantly
 			// checking whether the ongoing tick is finished
-			err := m.Tick(asyncRun, noForce)
+			_, err := m.Tick(asyncRun, noForce)
 			if err == errTickInProgress {
 				m.sleepFn(tickCheckInterval)
 			} else if err != nil {
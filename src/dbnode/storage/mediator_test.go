@@ -89,3 +89,113 @@ func TestDatabaseMediatorDisableFileOps(t *testing.T) {
 	m.DisableFileOps()
 	require.Equal(t, 3, len(slept))
 }
+
+func TestDatabaseMediatorFlushNowWaitsForFileOps(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := testDatabaseOptions().SetRepairEnabled(false).SetFlushNowTimeout(time.Minute)
+	now := time.Now()
+	opts = opts.
+		SetBootstrapProcessProvider(nil).
+		SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+			return now
+		}))
+
+	db := NewMockdatabase(ctrl)
+	db.EXPECT().Options().Return(opts).AnyTimes()
+	med, err := newMediator(db, opts)
+	require.NoError(t, err)
+
+	m := med.(*mediator)
+	fsm := NewMockdatabaseFileSystemManager(ctrl)
+	m.databaseFileSystemManager = fsm
+	var slept []time.Duration
+	m.sleepFn = func(d time.Duration) {
+		slept = append(slept, d)
+		now = now.Add(d)
+	}
+
+	// The flush waits for the file operations in progress to complete
+	blockStart := now.Truncate(2 * time.Hour).Add(-2 * time.Hour)
+	gomock.InOrder(
+		fsm.EXPECT().FlushNow(blockStart).Return(errFlushOperationsInProgress),
+		fsm.EXPECT().FlushNow(blockStart).Return(errFlushOperationsInProgress),
+		fsm.EXPECT().FlushNow(blockStart).Return(nil),
+	)
+	require.NoError(t, m.FlushNow(blockStart))
+	require.Equal(t, 2, len(slept))
+
+	// The flush is rejected once the timeout elapses
+	slept = nil
+	fsm.EXPECT().FlushNow(blockStart).Return(errFlushOperationsInProgress).
+		Times(int(time.Minute/fileOpCheckInterval) + 1)
+	require.Equal(t, errFlushOperationsInProgress, m.FlushNow(blockStart))
+	require.Equal(t, int(time.Minute/fileOpCheckInterval), len(slept))
+
+	// Other errors are returned without waiting
+	slept = nil
+	fsm.EXPECT().FlushNow(blockStart).Return(errFileOpsDisabled)
+	require.Equal(t, errFileOpsDisabled, m.FlushNow(blockStart))
+	require.Equal(t, 0, len(slept))
+}
+
+func TestDatabaseMediatorTickResult(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := testDatabaseOptions().SetRepairEnabled(false)
+	now := time.Now()
+	opts = opts.
+		SetBootstrapProcessProvider(nil).
+		SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+			return now
+		}))
+
+	db := NewMockdatabase(ctrl)
+	db.EXPECT().Options().Return(opts).AnyTimes()
+	db.EXPECT().BootstrapState().Return(DatabaseBootstrapState{}).AnyTimes()
+	med, err := newMediator(db, opts)
+	require.NoError(t, err)
+
+	m := med.(*mediator)
+	tm := NewMockdatabaseTickManager(ctrl)
+	tm.EXPECT().Tick(noForce, now).Return(nil).AnyTimes()
+	m.databaseTickManager = tm
+	fsm := NewMockdatabaseFileSystemManager(ctrl)
+	m.databaseFileSystemManager = fsm
+
+	inputs := []struct {
+		flushResult flushResult
+		ran         bool
+		expected    TickResult
+	}{
+		{
+			flushResult{flushedBlocks: 3}, true,
+			TickResult{FlushedBlocks: 3},
+		},
+		{
+			flushResult{flushedBlocks: 2, failedBlocks: 1}, true,
+			TickResult{FlushedBlocks: 2, FailedBlocks: 1, MoreWork: true},
+		},
+		{
+			flushResult{flushedBlocks: 2, deferredBlocks: 1}, true,
+			TickResult{FlushedBlocks: 2, DeferredBlocks: 1, MoreWork: true},
+		},
+		// Empty blocks only need flushing once data arrives for them
+		{
+			flushResult{flushedBlocks: 2, emptyBlocks: 1}, true,
+			TickResult{FlushedBlocks: 2, EmptyBlocks: 1},
+		},
+		{flushResult{}, false, TickResult{}},
+	}
+	for _, input := range inputs {
+		fsm.EXPECT().
+			Run(now, DatabaseBootstrapState{}, syncRun, noForce).
+			Return(input.flushResult, input.ran)
+
+		result, err := m.Tick(asyncRun, noForce)
+		require.NoError(t, err)
+		require.Equal(t, input.expected, result)
+	}
+}
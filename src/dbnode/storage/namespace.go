@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"math"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
@@ -32,6 +33,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
@@ -53,8 +55,9 @@ import (
 )
 
 var (
-	errNamespaceAlreadyClosed    = errors.New("namespace already closed")
-	errNamespaceIndexingDisabled = errors.New("namespace indexing is disabled")
+	errNamespaceAlreadyClosed        = errors.New("namespace already closed")
+	errNamespaceIndexingDisabled     = errors.New("namespace indexing is disabled")
+	errRetentionPeriodBelowBlockSize = errors.New("retention period must not be less than the block size")
 )
 
 type commitLogWriter interface {
@@ -148,6 +151,7 @@ type databaseNamespaceMetrics struct {
 	snapshot            instrument.MethodMetrics
 	write               instrument.MethodMetrics
 	writeTagged         instrument.MethodMetrics
+	writeBatch          instrument.MethodMetrics
 	read                instrument.MethodMetrics
 	fetchBlocks         instrument.MethodMetrics
 	fetchBlocksMetadata instrument.MethodMetrics
@@ -161,9 +165,10 @@ type databaseNamespaceMetrics struct {
 }
 
 type databaseNamespaceShardMetrics struct {
-	add         tally.Counter
-	close       tally.Counter
-	closeErrors tally.Counter
+	add          tally.Counter
+	close        tally.Counter
+	closeErrors  tally.Counter
+	flushMissing tally.Counter
 }
 
 type databaseNamespaceTickMetrics struct {
@@ -177,6 +182,7 @@ type databaseNamespaceTickMetrics struct {
 	madeUnwiredBlocks      tally.Counter
 	madeExpiredBlocks      tally.Counter
 	mergedOutOfOrderBlocks tally.Counter
+	releasedIdleBuffers    tally.Counter
 	errors                 tally.Counter
 	index                  databaseNamespaceIndexTickMetrics
 }
@@ -218,6 +224,7 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 		snapshot:            instrument.NewMethodMetrics(scope, "snapshot", samplingRate),
 		write:               instrument.NewMethodMetrics(scope, "write", samplingRate),
 		writeTagged:         instrument.NewMethodMetrics(scope, "write-tagged", samplingRate),
+		writeBatch:          instrument.NewMethodMetrics(scope, "write-batch", samplingRate),
 		read:                instrument.NewMethodMetrics(scope, "read", samplingRate),
 		fetchBlocks:         instrument.NewMethodMetrics(scope, "fetchBlocks", samplingRate),
 		fetchBlocksMetadata: instrument.NewMethodMetrics(scope, "fetchBlocksMetadata", samplingRate),
@@ -226,9 +233,10 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 		bootstrapStart:      scope.Counter("bootstrap.start"),
 		bootstrapEnd:        scope.Counter("bootstrap.end"),
 		shards: databaseNamespaceShardMetrics{
-			add:         shardsScope.Counter("add"),
-			close:       shardsScope.Counter("close"),
-			closeErrors: shardsScope.Counter("close-errors"),
+			add:          shardsScope.Counter("add"),
+			close:        shardsScope.Counter("close"),
+			closeErrors:  shardsScope.Counter("close-errors"),
+			flushMissing: shardsScope.Counter("flush-missing"),
 		},
 		tick: databaseNamespaceTickMetrics{
 			activeSeries:           tickScope.Gauge("active-series"),
@@ -241,6 +249,7 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 			madeUnwiredBlocks:      tickScope.Counter("made-unwired-blocks"),
 			madeExpiredBlocks:      tickScope.Counter("made-expired-blocks"),
 			mergedOutOfOrderBlocks: tickScope.Counter("merged-out-of-order-blocks"),
+			releasedIdleBuffers:    tickScope.Counter("released-idle-buffers"),
 			errors:                 tickScope.Counter("errors"),
 			index: databaseNamespaceIndexTickMetrics{
 				numDocs:          indexTickScope.Gauge("num-docs"),
@@ -359,7 +368,43 @@ func (n *dbNamespace) reportStatusLoop() {
 }
 
 func (n *dbNamespace) Options() namespace.Options {
-	return n.nopts
+	n.RLock()
+	nopts := n.nopts
+	n.RUnlock()
+	return nopts
+}
+
+func (n *dbNamespace) SetRetentionPeriod(value time.Duration) error {
+	n.Lock()
+	defer n.Unlock()
+
+	ropts := n.nopts.RetentionOptions()
+	if value < ropts.BlockSize() {
+		return errRetentionPeriodBelowBlockSize
+	}
+
+	ropts = ropts.SetRetentionPeriod(value)
+	nopts := n.nopts.SetRetentionOptions(ropts)
+	metadata, err := namespace.NewMetadata(n.id, nopts)
+	if err != nil {
+		return err
+	}
+
+	n.nopts = nopts
+	n.metadata = metadata
+	n.seriesOpts = n.seriesOpts.SetRetentionOptions(ropts)
+
+	// NB: Shards keep flush states for the blocks the flush manager flushes,
+	// so they must be given the new retention too or the blocks flushed that
+	// fall outside the old retention would be flushed again every tick. Series
+	// already created keep the retention they were created with, which only
+	// decides when their blocks already flushed to disk are evicted from memory.
+	for _, shard := range n.shards {
+		if shard != nil {
+			shard.SetRetentionOptions(ropts)
+		}
+	}
+	return nil
 }
 
 func (n *dbNamespace) ID() ident.ID {
@@ -485,6 +530,9 @@ func (n *dbNamespace) Tick(c context.Cancellable, tickStart time.Time) error {
 			}
 
 			shardResult, err := shard.Tick(c, tickStart)
+			if err == nil && !c.IsCancelled() {
+				shardResult.releasedIdleBuffers = shard.ReleaseIdleBuffers(tickStart)
+			}
 
 			l.Lock()
 			r = r.merge(shardResult)
@@ -533,6 +581,7 @@ func (n *dbNamespace) Tick(c context.Cancellable, tickStart time.Time) error {
 	n.metrics.tick.madeExpiredBlocks.Inc(int64(r.madeExpiredBlocks))
 	n.metrics.tick.madeUnwiredBlocks.Inc(int64(r.madeUnwiredBlocks))
 	n.metrics.tick.mergedOutOfOrderBlocks.Inc(int64(r.mergedOutOfOrderBlocks))
+	n.metrics.tick.releasedIdleBuffers.Inc(int64(r.releasedIdleBuffers))
 	n.metrics.tick.index.numDocs.Update(float64(indexTickResults.NumTotalDocs))
 	n.metrics.tick.index.numBlocks.Update(float64(indexTickResults.NumBlocks))
 	n.metrics.tick.index.numSegments.Update(float64(indexTickResults.NumSegments))
@@ -586,6 +635,72 @@ func (n *dbNamespace) WriteTagged(
 	return err
 }
 
+func (n *dbNamespace) WriteBatch(
+	ctx context.Context,
+	points []DataPoint,
+) BatchError {
+	callStart := n.nowFn()
+	var errs BatchError
+	for _, batch := range n.shardBatches(points) {
+		if batch.err != nil {
+			for _, idx := range batch.indexes {
+				errs = append(errs, BatchWriteError{Index: idx, Err: batch.err})
+			}
+			continue
+		}
+		for _, shardErr := range batch.shard.WriteBatch(ctx, batch.points) {
+			errs = append(errs, BatchWriteError{
+				Index: batch.indexes[shardErr.Index],
+				Err:   shardErr.Err,
+			})
+		}
+	}
+
+	var err error
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool {
+			return errs[i].Index < errs[j].Index
+		})
+		err = errs
+	}
+	n.metrics.writeBatch.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
+	return errs
+}
+
+// shardBatches groups the data points of a batch by owning shard in order
+// of first appearance, hashing each distinct ID only once.
+func (n *dbNamespace) shardBatches(points []DataPoint) []shardBatch {
+	var (
+		batches      []shardBatch
+		batchByID    = make(map[string]int, len(points))
+		batchByShard = make(map[uint32]int)
+	)
+	n.RLock()
+	defer n.RUnlock()
+	for i := range points {
+		id := points[i].ID
+		idx, ok := batchByID[string(id.Bytes())]
+		if !ok {
+			shardID := n.shardSet.Lookup(id)
+			idx, ok = batchByShard[shardID]
+			if !ok {
+				shard, err := n.shardAtWithRLock(shardID)
+				idx = len(batches)
+				batches = append(batches, shardBatch{
+					shardID: shardID,
+					shard:   shard,
+					err:     err,
+				})
+				batchByShard[shardID] = idx
+			}
+			batchByID[string(id.Bytes())] = idx
+		}
+		batches[idx].indexes = append(batches[idx].indexes, i)
+		batches[idx].points = append(batches[idx].points, points[i])
+	}
+	return batches
+}
+
 func (n *dbNamespace) QueryIDs(
 	ctx context.Context,
 	query index.Query,
@@ -703,7 +818,7 @@ func (n *dbNamespace) Bootstrap(start time.Time, process bootstrap.Process) erro
 		n.metrics.bootstrapEnd.Inc(1)
 	}()
 
-	if !n.nopts.BootstrapEnabled() {
+	if !n.Options().BootstrapEnabled() {
 		success = true
 		n.metrics.bootstrap.ReportSuccess(n.nowFn().Sub(callStart))
 		return nil
@@ -729,7 +844,11 @@ func (n *dbNamespace) Bootstrap(start time.Time, process bootstrap.Process) erro
 		shardIDs[i] = shard.ID()
 	}
 
-	bootstrapResult, err := process.Run(start, n.metadata, shardIDs)
+	n.RLock()
+	metadata := n.metadata
+	n.RUnlock()
+
+	bootstrapResult, err := process.Run(start, metadata, shardIDs)
 	if err != nil {
 		n.log.Errorf("bootstrap for namespace %s aborted due to error: %v",
 			n.id.String(), err)
@@ -807,7 +926,55 @@ func (n *dbNamespace) Flush(
 	blockStart time.Time,
 	shardBootstrapStatesAtTickStart ShardBootstrapStates,
 	flush persist.DataFlush,
+) (namespaceFlushResult, error) {
+	var (
+		flushJitter = n.opts.FlushJitter()
+		now         = n.nowFn()
+	)
+	return n.flushShards(blockStart, flush, func(shard databaseShard) bool {
+		// This is different than calling shard.IsBootstrapped() because it was determined
+		// before the start of the tick that preceded this flush, meaning it can be reliably
+		// used to determine if all of the bootstrapped blocks have been merged / drained (ticked)
+		// and are ready to be flushed.
+		shardBootstrapStateBeforeTick, ok := shardBootstrapStatesAtTickStart[shard.ID()]
+		if !ok || shardBootstrapStateBeforeTick != Bootstrapped {
+			// We don't own this shard anymore (!ok) or the shard was not bootstrapped
+			// before the previous tick which means that we have no guarantee that all
+			// bootstrapped blocks have been rotated out of the series buffer buckets,
+			// so we wait until the next opportunity.
+			return false
+		}
+
+		// skip flushing if the shard's jittered flush time has not yet been reached,
+		// the block will be picked up again by a subsequent flush
+		if flushJitter > 0 {
+			eligibleAt := flushEligibleTime(n.Options().RetentionOptions(), shard.ID(), blockStart, flushJitter)
+			if now.Before(eligibleAt) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (n *dbNamespace) FlushNow(
+	blockStart time.Time,
+	flush persist.DataFlush,
 ) error {
+	// NB: Flushing on demand bypasses the flush jitter and the bootstrap state
+	// captured at tick start, the caller is responsible for ensuring the block
+	// is ready to be flushed.
+	_, err := n.flushShards(blockStart, flush, func(shard databaseShard) bool {
+		return shard.IsBootstrapped()
+	})
+	return err
+}
+
+func (n *dbNamespace) flushShards(
+	blockStart time.Time,
+	flush persist.DataFlush,
+	shouldFlushFn func(shard databaseShard) bool,
+) (namespaceFlushResult, error) {
 	// NB(rartoul): This value can be used for emitting metrics, but should not be used
 	// for business logic.
 	callStart := n.nowFn()
@@ -816,53 +983,99 @@ func (n *dbNamespace) Flush(
 	if n.bootstrapState != Bootstrapped {
 		n.RUnlock()
 		n.metrics.flush.ReportError(n.nowFn().Sub(callStart))
-		return errNamespaceNotBootstrapped
+		return namespaceFlushResult{}, errNamespaceNotBootstrapped
 	}
 	n.RUnlock()
 
-	if !n.nopts.FlushEnabled() {
+	if !n.Options().FlushEnabled() {
 		n.metrics.flush.ReportSuccess(n.nowFn().Sub(callStart))
-		return nil
+		return namespaceFlushResult{}, nil
 	}
 
 	// check if blockStart is aligned with the namespace's retention options
-	bs := n.nopts.RetentionOptions().BlockSize()
-	if t := blockStart.Truncate(bs); !blockStart.Equal(t) {
-		return fmt.Errorf("failed to flush at time %v, not aligned to blockSize", blockStart.String())
+	ropts := n.Options().RetentionOptions()
+	if t := retention.BlockStart(ropts, blockStart); !blockStart.Equal(t) {
+		return namespaceFlushResult{}, fmt.Errorf("failed to flush at time %v, not aligned to blockSize", blockStart.String())
 	}
 
-	multiErr := xerrors.NewMultiError()
-	shards := n.GetOwnedShards()
+	var (
+		result          namespaceFlushResult
+		multiErr        = xerrors.NewMultiError()
+		shards, missing = n.getOwnedShardsAndMissing()
+		timingFn        = n.opts.ShardFlushTimingFn()
+	)
+	for _, shard := range missing {
+		// NB: The block is flushed for the shard once it is assigned again,
+		// until then the flush fails so the block is not considered flushed.
+		n.metrics.shards.flushMissing.Inc(1)
+		n.log.WithFields(
+			xlog.NewField("namespace", n.id.String()),
+			xlog.NewField("shard", shard),
+			xlog.NewField("blockStart", blockStart.String()),
+		).Warn("skipping flush for owned shard that is missing")
+		multiErr = multiErr.Add(fmt.Errorf("shard %d is missing", shard))
+	}
+	if priorityFn := n.opts.ShardFlushPriorityFn(); priorityFn != nil {
+		sortShardsByFlushPriority(n.id, shards, priorityFn)
+	}
 	for _, shard := range shards {
-		// This is different than calling shard.IsBootstrapped() because it was determined
-		// before the start of the tick that preceded this flush, meaning it can be reliably
-		// used to determine if all of the bootstrapped blocks have been merged / drained (ticked)
-		// and are ready to be flushed.
-		shardBootstrapStateBeforeTick, ok := shardBootstrapStatesAtTickStart[shard.ID()]
-		if !ok || shardBootstrapStateBeforeTick != Bootstrapped {
-			// We don't own this shard anymore (!ok) or the shard was not bootstrapped
-			// before the previous tick which means that we have no guarantee that all
-			// bootstrapped blocks have been rotated out of the series buffer buckets,
-			// so we wait until the next opportunity.
+		// skip flushing if the shard has already flushed data for the `blockStart`
+		if s := shard.FlushState(blockStart); !s.NeedsFlushAttempt() {
 			continue
 		}
 
-		// skip flushing if the shard has already flushed data for the `blockStart`
-		if s := shard.FlushState(blockStart); s.Status == fileOpSuccess {
+		// the shard still needs to flush the block so it is deferred rather
+		// than skipped when it is not ready to flush it yet
+		if !shouldFlushFn(shard) {
+			result.deferredShards++
 			continue
 		}
+
 		// NB(xichen): we still want to proceed if a shard fails to flush its data.
 		// Probably want to emit a counter here, but for now just log it.
-		if err := shard.Flush(blockStart, flush); err != nil {
+		shardFlushStart := n.nowFn()
+		err := shard.Flush(blockStart, flush)
+		if timingFn != nil {
+			timingFn(n.id, shard.ID(), blockStart, n.nowFn().Sub(shardFlushStart))
+		}
+		if err != nil {
 			detailedErr := fmt.Errorf("shard %d failed to flush data: %v",
 				shard.ID(), err)
 			multiErr = multiErr.Add(detailedErr)
+			continue
 		}
+
+		// A dry run writes nothing to disk so the block still needs a flush
+		if n.opts.FlushDryRun() {
+			result.deferredShards++
+			continue
+		}
+		result.flushedShards++
 	}
 
 	res := multiErr.FinalError()
 	n.metrics.flush.ReportSuccessOrError(res, n.nowFn().Sub(callStart))
-	return res
+	return result, res
+}
+
+// sortShardsByFlushPriority orders the shards by descending flush priority,
+// shards of equal priority remain in ascending shard order.
+func sortShardsByFlushPriority(
+	namespace ident.ID,
+	shards []databaseShard,
+	priorityFn ShardFlushPriorityFn,
+) {
+	priorities := make(map[uint32]int, len(shards))
+	for _, shard := range shards {
+		priorities[shard.ID()] = priorityFn(namespace, shard.ID())
+	}
+	sort.SliceStable(shards, func(i, j int) bool {
+		pi, pj := priorities[shards[i].ID()], priorities[shards[j].ID()]
+		if pi != pj {
+			return pi > pj
+		}
+		return shards[i].ID() < shards[j].ID()
+	})
 }
 
 func (n *dbNamespace) FlushIndex(
@@ -877,7 +1090,7 @@ func (n *dbNamespace) FlushIndex(
 	}
 	n.RUnlock()
 
-	if !n.nopts.FlushEnabled() || !n.nopts.IndexOptions().Enabled() {
+	if !n.Options().FlushEnabled() || !n.Options().IndexOptions().Enabled() {
 		n.metrics.flush.ReportSuccess(n.nowFn().Sub(callStart))
 		return nil
 	}
@@ -900,7 +1113,7 @@ func (n *dbNamespace) Snapshot(blockStart, snapshotTime time.Time, flush persist
 	}
 	n.RUnlock()
 
-	if !n.nopts.SnapshotEnabled() {
+	if !n.Options().SnapshotEnabled() {
 		n.metrics.snapshot.ReportSuccess(n.nowFn().Sub(callStart))
 		return nil
 	}
@@ -944,13 +1157,76 @@ func (n *dbNamespace) NeedsFlush(
 	// any not started then we need to flush.
 	n.RLock()
 	defer n.RUnlock()
-	return n.needsFlushWithLock(alignedInclusiveStart, alignedInclusiveEnd)
+	return n.needsFlushWithLock(alignedInclusiveStart, alignedInclusiveEnd,
+		fileOpState.NeedsFlush)
+}
+
+func (n *dbNamespace) NeedsFlushAttempt(
+	alignedInclusiveStart time.Time, alignedInclusiveEnd time.Time) bool {
+	n.RLock()
+	defer n.RUnlock()
+	return n.needsFlushWithLock(alignedInclusiveStart, alignedInclusiveEnd,
+		fileOpState.NeedsFlushAttempt)
+}
+
+func (n *dbNamespace) MissingWithin(bounds xtime.Range) xtime.Ranges {
+	var (
+		rOpts     = n.Options().RetentionOptions()
+		blockSize = rOpts.BlockSize()
+		now       = n.nowFn()
+		earliest  = retention.FlushTimeStart(rOpts, now)
+		latest    = retention.FlushTimeEnd(rOpts, now)
+	)
+	if bounds.Start.Before(earliest) {
+		bounds.Start = earliest
+	}
+	if !bounds.Start.Before(bounds.End) {
+		return xtime.Ranges{}
+	}
+
+	var (
+		start   = retention.BlockStartForBlockSize(blockSize, bounds.Start)
+		end     = retention.BlockStartForBlockSize(blockSize, bounds.End.Add(-1))
+		missing = xtime.NewRanges(bounds)
+	)
+
+	// Blocks that can still receive writes are held in memory rather than
+	// flushed so they are covered as long as they are buffered.
+	if end.After(latest) {
+		missing = missing.RemoveRange(xtime.Range{
+			Start: latest.Add(blockSize),
+			End:   bounds.End,
+		})
+		end = latest
+	}
+
+	n.RLock()
+	defer n.RUnlock()
+	for _, blockStart := range timesInRange(start, end, blockSize) {
+		if !n.needsFlushWithLock(blockStart, blockStart, fileOpState.NeedsFlush) {
+			missing = missing.RemoveRange(xtime.Range{
+				Start: blockStart,
+				End:   blockStart.Add(blockSize),
+			})
+		}
+	}
+
+	return missing
+}
+
+func (n *dbNamespace) HasData(blockStart time.Time) bool {
+	for _, shard := range n.GetOwnedShards() {
+		if shard.HasData(blockStart) {
+			return true
+		}
+	}
+	return false
 }
 
 func (n *dbNamespace) IsCapturedBySnapshot(
 	alignedInclusiveStart, alignedInclusiveEnd, capturedUpTo time.Time) (bool, error) {
 	var (
-		blockSize      = n.nopts.RetentionOptions().BlockSize()
+		blockSize      = n.Options().RetentionOptions().BlockSize()
 		blockStarts    = timesInRange(alignedInclusiveStart, alignedInclusiveEnd, blockSize)
 		filePathPrefix = n.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
 	)
@@ -994,7 +1270,11 @@ func (n *dbNamespace) IsCapturedBySnapshot(
 	return true, nil
 }
 
-func (n *dbNamespace) needsFlushWithLock(alignedInclusiveStart time.Time, alignedInclusiveEnd time.Time) bool {
+func (n *dbNamespace) needsFlushWithLock(
+	alignedInclusiveStart time.Time,
+	alignedInclusiveEnd time.Time,
+	needsFlushFn func(fileOpState) bool,
+) bool {
 	var (
 		blockSize   = n.nopts.RetentionOptions().BlockSize()
 		blockStarts = timesInRange(alignedInclusiveStart, alignedInclusiveEnd, blockSize)
@@ -1003,13 +1283,22 @@ func (n *dbNamespace) needsFlushWithLock(alignedInclusiveStart time.Time, aligne
 	// NB(prateek): we do not check if any other flush is in progress in this method,
 	// instead relying on the databaseFlushManager to ensure atomicity of flushes.
 
+	// Blocks are not flushed for owned shards that are missing
+	if len(blockStarts) > 0 {
+		for _, id := range n.shardSet.AllIDs() {
+			if !n.hasShardWithLock(id) {
+				return true
+			}
+		}
+	}
+
 	// Check for not started or failed that might need a flush
 	for _, shard := range n.shards {
 		if shard == nil {
 			continue
 		}
 		for _, blockStart := range blockStarts {
-			if shard.FlushState(blockStart).Status != fileOpSuccess {
+			if needsFlushFn(shard.FlushState(blockStart)) {
 				return true
 			}
 		}
@@ -1043,7 +1332,7 @@ func (n *dbNamespace) Repair(
 	repairer databaseShardRepairer,
 	tr xtime.Range,
 ) error {
-	if !n.nopts.RepairEnabled() {
+	if !n.Options().RepairEnabled() {
 		return nil
 	}
 
@@ -1130,6 +1419,31 @@ func (n *dbNamespace) GetOwnedShards() []databaseShard {
 	return databaseShards
 }
 
+// getOwnedShardsAndMissing returns the owned shards along with the IDs of
+// owned shards that are missing, which can happen while shards are being
+// reassigned.
+func (n *dbNamespace) getOwnedShardsAndMissing() ([]databaseShard, []uint32) {
+	n.RLock()
+	defer n.RUnlock()
+	var (
+		ids     = n.shardSet.AllIDs()
+		shards  = make([]databaseShard, 0, len(ids))
+		missing []uint32
+	)
+	for _, id := range ids {
+		if !n.hasShardWithLock(id) {
+			missing = append(missing, id)
+			continue
+		}
+		shards = append(shards, n.shards[id])
+	}
+	return shards, missing
+}
+
+func (n *dbNamespace) hasShardWithLock(id uint32) bool {
+	return int(id) < len(n.shards) && n.shards[id] != nil
+}
+
 func (n *dbNamespace) GetIndex() (namespaceIndex, error) {
 	n.RLock()
 	defer n.RUnlock()
@@ -83,6 +83,13 @@ type fsFileSetExistsAtFn func(
 	blockStart time.Time,
 ) (bool, error)
 
+type fsFileSetAtFn func(
+	prefix string,
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+) (fs.FileSetFile, bool, error)
+
 type fsNewReaderFn func(
 	bytesPool pool.CheckedBytesPool,
 	opts fs.Options,
@@ -92,6 +99,7 @@ type namespaceReaderManager struct {
 	sync.Mutex
 
 	filesetExistsAtFn fsFileSetExistsAtFn
+	filesetAtFn       fsFileSetAtFn
 	newReaderFn       fsNewReaderFn
 
 	namespace namespace.Metadata
@@ -107,9 +115,10 @@ type namespaceReaderManager struct {
 }
 
 type cachedOpenReaderKey struct {
-	shard      uint32
-	blockStart xtime.UnixNano
-	position   readerPosition
+	shard       uint32
+	blockStart  xtime.UnixNano
+	volumeIndex int
+	position    readerPosition
 }
 
 type readerPosition struct {
@@ -150,6 +159,7 @@ func newNamespaceReaderManager(
 ) databaseNamespaceReaderManager {
 	return &namespaceReaderManager{
 		filesetExistsAtFn: fs.DataFileSetExistsAt,
+		filesetAtFn:       fs.FileSetAt,
 		newReaderFn:       fs.NewReader,
 		namespace:         namespace,
 		fsOpts:            opts.CommitLogOptions().FilesystemOptions(),
@@ -234,10 +244,23 @@ func (m *namespaceReaderManager) get(
 	blockStart time.Time,
 	position readerPosition,
 ) (fs.DataFileSetReader, error) {
+	// Read the latest complete volume of the block since a block flushed
+	// again is written to a new volume that supersedes its earlier volumes.
+	var volumeIndex int
+	fileset, ok, err := m.filesetAtFn(m.fsOpts.FilePathPrefix(),
+		m.namespace.ID(), shard, blockStart)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		volumeIndex = fileset.ID.VolumeIndex
+	}
+
 	key := cachedOpenReaderKey{
-		shard:      shard,
-		blockStart: xtime.ToUnixNano(blockStart),
-		position:   position,
+		shard:       shard,
+		blockStart:  xtime.ToUnixNano(blockStart),
+		volumeIndex: volumeIndex,
+		position:    position,
 	}
 
 	lookup, err := m.cachedReaderForKey(key)
@@ -253,9 +276,10 @@ func (m *namespaceReaderManager) get(
 	reader := lookup.closedReader
 	openOpts := fs.DataReaderOpenOptions{
 		Identifier: fs.FileSetFileIdentifier{
-			Namespace:  m.namespace.ID(),
-			Shard:      shard,
-			BlockStart: blockStart,
+			Namespace:   m.namespace.ID(),
+			Shard:       shard,
+			BlockStart:  blockStart,
+			VolumeIndex: volumeIndex,
 		},
 	}
 	if err := reader.Open(openOpts); err != nil {
@@ -301,8 +325,9 @@ func (m *namespaceReaderManager) put(reader fs.DataFileSetReader) {
 	}
 
 	key := cachedOpenReaderKey{
-		shard:      status.Shard,
-		blockStart: xtime.ToUnixNano(status.BlockStart),
+		shard:       status.Shard,
+		blockStart:  xtime.ToUnixNano(status.BlockStart),
+		volumeIndex: status.VolumeIndex,
 		position: readerPosition{
 			dataIdx:     reader.EntriesRead(),
 			metadataIdx: reader.MetadataRead(),
@@ -23,10 +23,12 @@ package storage
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/runtime"
@@ -37,6 +39,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/namespace"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
+	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/metrics"
 	"github.com/m3db/m3cluster/shard"
 	"github.com/m3db/m3x/context"
@@ -93,6 +96,53 @@ func TestNamespaceName(t *testing.T) {
 	require.True(t, defaultTestNs1ID.Equal(ns.ID()))
 }
 
+func TestNamespaceSetRetentionPeriod(t *testing.T) {
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	var (
+		now       = time.Unix(0, 0).Add(100 * 24 * time.Hour)
+		blockSize = ns.Options().RetentionOptions().BlockSize()
+		before    = retention.FlushTimeStart(ns.Options().RetentionOptions(), now)
+	)
+
+	// The flush window derived from the retention period adjusts immediately
+	require.NoError(t, ns.SetRetentionPeriod(24*time.Hour))
+	require.Equal(t, 24*time.Hour, ns.Options().RetentionOptions().RetentionPeriod())
+	require.Equal(t, before.Add(24*time.Hour), retention.FlushTimeStart(ns.Options().RetentionOptions(), now))
+	require.Equal(t, 24*time.Hour, ns.seriesOpts.RetentionOptions().RetentionPeriod())
+
+	require.NoError(t, ns.SetRetentionPeriod(4*24*time.Hour))
+	require.Equal(t, before.Add(-2*24*time.Hour), retention.FlushTimeStart(ns.Options().RetentionOptions(), now))
+
+	// Retention cannot be shorter than a single block
+	require.Equal(t, errRetentionPeriodBelowBlockSize, ns.SetRetentionPeriod(blockSize-time.Minute))
+	require.Equal(t, 4*24*time.Hour, ns.Options().RetentionOptions().RetentionPeriod())
+}
+
+func TestNamespaceSetRetentionPeriodKeepsShardFlushStates(t *testing.T) {
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	var (
+		now        = time.Unix(0, 0).Add(100 * 24 * time.Hour)
+		blockSize  = ns.Options().RetentionOptions().BlockSize()
+		blockStart = retention.FlushTimeStart(ns.Options().RetentionOptions(), now).Add(-blockSize)
+		shard      = ns.shards[testShardIDs[0].ID()].(*dbShard)
+	)
+
+	// The block is only within retention once it has been extended
+	require.NoError(t, ns.SetRetentionPeriod(4*24*time.Hour))
+	require.Equal(t, 4*24*time.Hour, shard.retentionOptions().RetentionPeriod())
+	shard.markFlushStateSuccess(blockStart)
+
+	// Ticks must keep the flush state or the block would be flushed again
+	for i := 0; i < 2; i++ {
+		require.NoError(t, ns.Tick(context.NewNoOpCanncellable(), now))
+		require.Equal(t, fileOpSuccess, shard.FlushState(blockStart).Status)
+	}
+}
+
 func TestNamespaceTick(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -102,6 +152,7 @@ func TestNamespaceTick(t *testing.T) {
 	for i := range testShardIDs {
 		shard := NewMockdatabaseShard(ctrl)
 		shard.EXPECT().Tick(context.NewNoOpCanncellable(), gomock.Any()).Return(tickResult{}, nil)
+		shard.EXPECT().ReleaseIdleBuffers(gomock.Any()).Return(0)
 		ns.shards[testShardIDs[i].ID()] = shard
 	}
 
@@ -109,6 +160,24 @@ func TestNamespaceTick(t *testing.T) {
 	require.NoError(t, ns.Tick(context.NewNoOpCanncellable(), time.Now()))
 }
 
+func TestNamespaceTickReleasesIdleBuffers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	tickStart := time.Now()
+	for i := range testShardIDs {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().Tick(context.NewNoOpCanncellable(), tickStart).Return(tickResult{}, nil)
+		shard.EXPECT().ReleaseIdleBuffers(tickStart).Return(i + 1)
+		ns.shards[testShardIDs[i].ID()] = shard
+	}
+
+	require.NoError(t, ns.Tick(context.NewNoOpCanncellable(), tickStart))
+}
+
 func TestNamespaceTickError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -123,6 +192,7 @@ func TestNamespaceTickError(t *testing.T) {
 			shard.EXPECT().Tick(context.NewNoOpCanncellable(), gomock.Any()).Return(tickResult{}, fakeErr)
 		} else {
 			shard.EXPECT().Tick(context.NewNoOpCanncellable(), gomock.Any()).Return(tickResult{}, nil)
+			shard.EXPECT().ReleaseIdleBuffers(gomock.Any()).Return(0)
 		}
 		ns.shards[testShardIDs[i].ID()] = shard
 	}
@@ -169,6 +239,78 @@ func TestNamespaceWriteShardOwned(t *testing.T) {
 	require.NoError(t, ns.Write(ctx, id, ts, val, unit, ant))
 }
 
+func TestNamespaceWriteBatchGroupsByShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	hashes := make(map[string]int)
+	hashFn := func(id ident.ID) uint32 {
+		hashes[id.String()]++
+		if strings.HasPrefix(id.String(), "foo") {
+			return testShardIDs[0].ID()
+		}
+		return testShardIDs[1].ID()
+	}
+	shardSet, err := sharding.NewShardSet(testShardIDs, hashFn)
+	require.NoError(t, err)
+	ns.shardSet = shardSet
+
+	ts := time.Now()
+	points := []DataPoint{
+		{ID: ident.StringID("foo.a"), Timestamp: ts, Value: 1, Unit: xtime.Second},
+		{ID: ident.StringID("bar.a"), Timestamp: ts, Value: 2, Unit: xtime.Second},
+		{ID: ident.StringID("foo.b"), Timestamp: ts, Value: 3, Unit: xtime.Second},
+		{ID: ident.StringID("foo.a"), Timestamp: ts.Add(time.Second), Value: 4, Unit: xtime.Second},
+	}
+
+	writeErr := errors.New("write error")
+	shard0 := NewMockdatabaseShard(ctrl)
+	shard0.EXPECT().
+		WriteBatch(ctx, []DataPoint{points[0], points[2], points[3]}).
+		Return(BatchError{{Index: 1, Err: writeErr}})
+	shard1 := NewMockdatabaseShard(ctrl)
+	shard1.EXPECT().
+		WriteBatch(ctx, []DataPoint{points[1]}).
+		Return(nil)
+	ns.shards[testShardIDs[0].ID()] = shard0
+	ns.shards[testShardIDs[1].ID()] = shard1
+
+	errs := ns.WriteBatch(ctx, points)
+	require.Equal(t, BatchError{{Index: 2, Err: writeErr}}, errs)
+
+	// Each distinct ID is only hashed once
+	require.Equal(t, map[string]int{"foo.a": 1, "bar.a": 1, "foo.b": 1}, hashes)
+}
+
+func TestNamespaceWriteBatchShardNotOwned(t *testing.T) {
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+	for i := range ns.shards {
+		ns.shards[i] = nil
+	}
+
+	points := []DataPoint{
+		{ID: ident.StringID("foo"), Timestamp: time.Now(), Unit: xtime.Second},
+		{ID: ident.StringID("bar"), Timestamp: time.Now(), Unit: xtime.Second},
+	}
+	errs := ns.WriteBatch(ctx, points)
+	require.Len(t, errs, 2)
+	for i, err := range errs {
+		require.Equal(t, i, err.Index)
+		require.True(t, xerrors.IsRetryableError(err.Err))
+		require.Equal(t, "not responsible for shard 0", err.Err.Error())
+	}
+}
+
 func TestNamespaceReadEncodedShardNotOwned(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
@@ -411,7 +553,8 @@ func TestNamespaceBootstrapOnlyNonBootstrappedShards(t *testing.T) {
 func TestNamespaceFlushNotBootstrapped(t *testing.T) {
 	ns, closer := newTestNamespace(t)
 	defer closer()
-	require.Equal(t, errNamespaceNotBootstrapped, ns.Flush(time.Now(), nil, nil))
+	_, err := ns.Flush(time.Now(), nil, nil)
+	require.Equal(t, errNamespaceNotBootstrapped, err)
 }
 
 func TestNamespaceFlushDontNeedFlush(t *testing.T) {
@@ -420,7 +563,9 @@ func TestNamespaceFlushDontNeedFlush(t *testing.T) {
 	defer close()
 
 	ns.bootstrapState = Bootstrapped
-	require.NoError(t, ns.Flush(time.Now(), nil, nil))
+	result, err := ns.Flush(time.Now(), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, namespaceFlushResult{}, result)
 }
 
 func TestNamespaceFlushSkipFlushed(t *testing.T) {
@@ -455,7 +600,189 @@ func TestNamespaceFlushSkipFlushed(t *testing.T) {
 		ShardBootstrapStates[testShardIDs[i].ID()] = Bootstrapped
 	}
 
-	require.NoError(t, ns.Flush(blockStart, ShardBootstrapStates, nil))
+	result, err := ns.Flush(blockStart, ShardBootstrapStates, nil)
+	require.NoError(t, err)
+	require.Equal(t, namespaceFlushResult{flushedShards: 1}, result)
+}
+
+func TestNamespaceFlushDryRunDefersShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+	ns.opts = ns.opts.SetFlushDryRun(true)
+
+	ns.bootstrapState = Bootstrapped
+	blockStart := time.Now().Truncate(ns.Options().RetentionOptions().BlockSize())
+
+	shardBootstrapStates := ShardBootstrapStates{}
+	for _, s := range testShardIDs {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().ID().Return(s.ID()).AnyTimes()
+		shard.EXPECT().FlushState(blockStart).Return(fileOpState{Status: fileOpNotStarted})
+		shard.EXPECT().Flush(blockStart, nil).Return(nil)
+		ns.shards[s.ID()] = shard
+		shardBootstrapStates[s.ID()] = Bootstrapped
+	}
+
+	// Nothing is written to disk by a dry run so the block still needs a flush
+	result, err := ns.Flush(blockStart, shardBootstrapStates, nil)
+	require.NoError(t, err)
+	require.Equal(t, namespaceFlushResult{deferredShards: len(testShardIDs)}, result)
+}
+
+func TestNamespaceFlushShardFlushTiming(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	type shardFlushTiming struct {
+		shard      uint32
+		blockStart time.Time
+		duration   time.Duration
+	}
+
+	var timings []shardFlushTiming
+	ns, closer := newTestNamespace(t)
+	defer closer()
+	ns.opts = ns.opts.SetShardFlushTimingFn(func(
+		id ident.ID,
+		shard uint32,
+		blockStart time.Time,
+		duration time.Duration,
+	) {
+		require.True(t, id.Equal(ns.ID()))
+		timings = append(timings, shardFlushTiming{
+			shard:      shard,
+			blockStart: blockStart,
+			duration:   duration,
+		})
+	})
+
+	// Each call to now advances the clock by a second so that each shard
+	// flush is measured as taking one second
+	now := time.Now()
+	ns.nowFn = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	ns.bootstrapState = Bootstrapped
+	blockStart := time.Now().Truncate(ns.Options().RetentionOptions().BlockSize())
+
+	shardBootstrapStates := ShardBootstrapStates{}
+	for _, s := range testShardIDs {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().ID().Return(s.ID()).AnyTimes()
+		shard.EXPECT().FlushState(blockStart).Return(fileOpState{Status: fileOpNotStarted})
+		shard.EXPECT().Flush(blockStart, nil).Return(nil)
+		ns.shards[s.ID()] = shard
+		shardBootstrapStates[s.ID()] = Bootstrapped
+	}
+
+	_, err := ns.Flush(blockStart, shardBootstrapStates, nil)
+	require.NoError(t, err)
+
+	require.Equal(t, len(testShardIDs), len(timings))
+	for i, s := range testShardIDs {
+		require.Equal(t, shardFlushTiming{
+			shard:      s.ID(),
+			blockStart: blockStart,
+			duration:   time.Second,
+		}, timings[i])
+	}
+}
+
+func TestNamespaceFlushShardFlushPriority(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	// Flush the highest shard first
+	ns.opts = ns.opts.SetShardFlushPriorityFn(func(id ident.ID, shard uint32) int {
+		require.True(t, id.Equal(ns.ID()))
+		return int(shard)
+	})
+
+	ns.bootstrapState = Bootstrapped
+	blockStart := time.Now().Truncate(ns.Options().RetentionOptions().BlockSize())
+
+	var (
+		flushes              []*gomock.Call
+		shardBootstrapStates = ShardBootstrapStates{}
+	)
+	for i := len(testShardIDs) - 1; i >= 0; i-- {
+		id := testShardIDs[i].ID()
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().ID().Return(id).AnyTimes()
+		shard.EXPECT().FlushState(blockStart).Return(fileOpState{Status: fileOpNotStarted})
+		flushes = append(flushes, shard.EXPECT().Flush(blockStart, nil).Return(nil))
+		ns.shards[id] = shard
+		shardBootstrapStates[id] = Bootstrapped
+	}
+	gomock.InOrder(flushes...)
+
+	_, err := ns.Flush(blockStart, shardBootstrapStates, nil)
+	require.NoError(t, err)
+}
+
+func TestNamespaceFlushSkipsMissingShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	ns.bootstrapState = Bootstrapped
+	blockStart := time.Now().Truncate(ns.Options().RetentionOptions().BlockSize())
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().ID().Return(testShardIDs[0].ID()).AnyTimes()
+	shard.EXPECT().FlushState(blockStart).Return(fileOpState{Status: fileOpNotStarted})
+	shard.EXPECT().Flush(blockStart, nil).Return(nil)
+	ns.shards[testShardIDs[0].ID()] = shard
+
+	// The second shard is owned but missing, e.g. while being reassigned
+	missing := testShardIDs[1].ID()
+	ns.shards[missing] = nil
+	shardBootstrapStates := ShardBootstrapStates{
+		testShardIDs[0].ID(): Bootstrapped,
+		missing:              Bootstrapped,
+	}
+
+	_, err := ns.Flush(blockStart, shardBootstrapStates, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), fmt.Sprintf("shard %d is missing", missing))
+
+	// The block still needs a flush once the present shard has flushed
+	shard.EXPECT().FlushState(blockStart).Return(fileOpState{Status: fileOpSuccess}).AnyTimes()
+	require.True(t, ns.NeedsFlush(blockStart, blockStart))
+}
+
+func TestSortShardsByFlushPriority(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var shards []databaseShard
+	for _, id := range []uint32{3, 0, 2, 1} {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().ID().Return(id).AnyTimes()
+		shards = append(shards, shard)
+	}
+
+	// Shards 1 and 3 are critical, ties flush in shard order
+	priorities := map[uint32]int{0: 0, 1: 10, 2: 0, 3: 10}
+	sortShardsByFlushPriority(ident.StringID("ns"), shards, func(_ ident.ID, shard uint32) int {
+		return priorities[shard]
+	})
+
+	var order []uint32
+	for _, shard := range shards {
+		order = append(order, shard.ID())
+	}
+	require.Equal(t, []uint32{1, 3, 0, 2}, order)
 }
 
 func TestNamespaceFlushSkipShardNotBootstrappedBeforeTick(t *testing.T) {
@@ -473,12 +800,78 @@ func TestNamespaceFlushSkipShardNotBootstrappedBeforeTick(t *testing.T) {
 
 	shard := NewMockdatabaseShard(ctrl)
 	shard.EXPECT().ID().Return(testShardIDs[0].ID())
+	shard.EXPECT().FlushState(blockStart).Return(fileOpState{Status: fileOpNotStarted})
 	ns.shards[testShardIDs[0].ID()] = shard
 
 	ShardBootstrapStates := ShardBootstrapStates{}
 	ShardBootstrapStates[testShardIDs[0].ID()] = Bootstrapping
 
-	require.NoError(t, ns.Flush(blockStart, ShardBootstrapStates, nil))
+	// The shard is left to flush the block once it has been bootstrapped
+	result, err := ns.Flush(blockStart, ShardBootstrapStates, nil)
+	require.NoError(t, err)
+	require.Equal(t, namespaceFlushResult{deferredShards: 1}, result)
+}
+
+func TestNamespaceFlushNowIgnoresFlushJitter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	// A flush jitter larger than the block size means no shard is eligible
+	// for a regular flush of the current block
+	blockSize := ns.Options().RetentionOptions().BlockSize()
+	ns.opts = ns.opts.SetFlushJitter(10 * blockSize)
+	ns.bootstrapState = Bootstrapped
+	blockStart := time.Now().Truncate(blockSize)
+
+	shardBootstrapStates := ShardBootstrapStates{}
+	for _, s := range testShardIDs {
+		shard := ns.shards[s.ID()].(*dbShard)
+		shard.bootstrapState = Bootstrapped
+		shardBootstrapStates[s.ID()] = Bootstrapped
+	}
+
+	// Every shard is left to flush the block once its jitter has elapsed
+	flush := persist.NewMockDataFlush(ctrl)
+	result, err := ns.Flush(blockStart, shardBootstrapStates, flush)
+	require.NoError(t, err)
+	require.Equal(t, namespaceFlushResult{deferredShards: len(testShardIDs)}, result)
+	for _, s := range testShardIDs {
+		require.Equal(t, fileOpNotStarted, ns.shards[s.ID()].FlushState(blockStart).Status)
+	}
+
+	flush.EXPECT().PrepareData(gomock.Any()).Return(persist.PreparedDataPersist{
+		Persist: func(ident.ID, ident.Tags, ts.Segment, uint32) error { return nil },
+		Close:   func() error { return nil },
+	}, nil).Times(len(testShardIDs))
+	require.NoError(t, ns.FlushNow(blockStart, flush))
+	for _, s := range testShardIDs {
+		require.Equal(t, fileOpSuccess, ns.shards[s.ID()].FlushState(blockStart).Status)
+	}
+
+	// Blocks that have already been flushed are not flushed again
+	require.NoError(t, ns.FlushNow(blockStart, flush))
+}
+
+func TestNamespaceFlushNowSkipShardNotBootstrapped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	ns.bootstrapState = Bootstrapped
+	blockStart := time.Now().Truncate(ns.Options().RetentionOptions().BlockSize())
+
+	for _, s := range testShardIDs {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().IsBootstrapped().Return(false)
+		ns.shards[s.ID()] = shard
+	}
+
+	require.NoError(t, ns.FlushNow(blockStart, nil))
 }
 
 type snapshotTestCase struct {
@@ -925,6 +1318,48 @@ func TestNamespaceNeedsFlushAllSuccess(t *testing.T) {
 	assert.False(t, ns.NeedsFlush(blockStart, blockStart))
 }
 
+func TestNamespaceNeedsFlushPermanentlyFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		shards = sharding.NewShards([]uint32{0, 2}, shard.Available)
+		dopts  = testDatabaseOptions()
+		hashFn = func(identifier ident.ID) uint32 { return shards[0].ID() }
+	)
+	metadata, err := namespace.NewMetadata(defaultTestNs1ID, defaultTestNs1Opts)
+	require.NoError(t, err)
+	shardSet, err := sharding.NewShardSet(shards, hashFn)
+	require.NoError(t, err)
+
+	ropts := metadata.Options().RetentionOptions()
+	at := time.Unix(0, 0).Add(2 * ropts.RetentionPeriod())
+	dopts = dopts.SetClockOptions(dopts.ClockOptions().SetNowFn(func() time.Time {
+		return at
+	}))
+
+	blockStart := retention.FlushTimeEnd(ropts, at)
+
+	oNs, err := newDatabaseNamespace(metadata, shardSet, nil, nil, nil, dopts)
+	require.NoError(t, err)
+	ns := oNs.(*dbNamespace)
+
+	states := map[uint32]fileOpState{
+		0: {Status: fileOpSuccess},
+		2: {Status: fileOpPermanentlyFailed},
+	}
+	for _, s := range shards {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().ID().Return(s.ID()).AnyTimes()
+		shard.EXPECT().FlushState(blockStart).Return(states[s.ID()]).AnyTimes()
+		ns.shards[s.ID()] = shard
+	}
+
+	// The block is never flushed again but is still not durably flushed
+	assert.True(t, ns.NeedsFlush(blockStart, blockStart))
+	assert.False(t, ns.NeedsFlushAttempt(blockStart, blockStart))
+}
+
 func TestNamespaceNeedsFlushAnyFailed(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1028,6 +1463,75 @@ func TestNamespaceNeedsFlushAnyNotStarted(t *testing.T) {
 	assert.True(t, ns.NeedsFlush(blockStart, blockStart))
 }
 
+func TestNamespaceMissingWithinGapInMiddle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		shards = sharding.NewShards([]uint32{0, 2}, shard.Available)
+		dopts  = testDatabaseOptions()
+	)
+	testNs, err := namespace.NewMetadata(defaultTestNs1ID, defaultTestNs1Opts)
+	require.NoError(t, err)
+
+	var (
+		ropts     = testNs.Options().RetentionOptions()
+		blockSize = ropts.BlockSize()
+		hashFn    = func(identifier ident.ID) uint32 { return shards[0].ID() }
+	)
+	shardSet, err := sharding.NewShardSet(shards, hashFn)
+	require.NoError(t, err)
+
+	at := time.Unix(0, 0).Add(2 * ropts.RetentionPeriod())
+	dopts = dopts.SetClockOptions(dopts.ClockOptions().SetNowFn(func() time.Time {
+		return at
+	}))
+
+	var (
+		last   = retention.FlushTimeEnd(ropts, at)
+		middle = last.Add(-blockSize)
+		first  = middle.Add(-blockSize)
+		bounds = xtime.Range{Start: first, End: last.Add(blockSize)}
+	)
+
+	oNs, err := newDatabaseNamespace(testNs, shardSet, nil, nil, nil, dopts)
+	require.NoError(t, err)
+	ns := oNs.(*dbNamespace)
+	for _, s := range shards {
+		shard := NewMockdatabaseShard(ctrl)
+		shard.EXPECT().ID().Return(s.ID()).AnyTimes()
+		shard.EXPECT().FlushState(first).Return(fileOpState{
+			Status: fileOpSuccess,
+		}).AnyTimes()
+		shard.EXPECT().FlushState(last).Return(fileOpState{
+			Status: fileOpSuccess,
+		}).AnyTimes()
+		middleStatus := fileOpSuccess
+		if s.ID() == shards[1].ID() {
+			middleStatus = fileOpFailed
+		}
+		shard.EXPECT().FlushState(middle).Return(fileOpState{
+			Status: middleStatus,
+		}).AnyTimes()
+		ns.shards[s.ID()] = shard
+	}
+
+	expected := xtime.Ranges{}.
+		AddRange(xtime.Range{Start: middle, End: middle.Add(blockSize)})
+	require.Equal(t, expected, ns.MissingWithin(bounds))
+
+	// Blocks that can still receive writes are held in memory and covered
+	buffered := xtime.Range{Start: first, End: at.Add(blockSize)}
+	require.Equal(t, expected, ns.MissingWithin(buffered))
+
+	// Ensure bounds entirely outside of retention are clamped away
+	outside := xtime.Range{
+		Start: at.Add(-3 * ropts.RetentionPeriod()),
+		End:   at.Add(-2 * ropts.RetentionPeriod()),
+	}
+	require.True(t, ns.MissingWithin(outside).IsEmpty())
+}
+
 func TestNamespaceCloseWillCloseShard(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -71,6 +71,25 @@ const (
 
 	// defaultMinSnapshotInterval is the default minimum interval that must elapse between snapshots
 	defaultMinSnapshotInterval = time.Minute
+
+	// defaultFlushJitter is the default flush jitter, disabled by default
+	defaultFlushJitter = time.Duration(0)
+
+	// defaultFlushDryRun disables flush dry runs by default
+	defaultFlushDryRun = false
+
+	// defaultFlushNowTimeout is the default time a flush on demand waits for
+	// file operations already in progress to complete
+	defaultFlushNowTimeout = 5 * time.Minute
+
+	// defaultSkipFlushEmptyBlocks flushes empty blocks by default
+	defaultSkipFlushEmptyBlocks = false
+
+	// defaultFlushRetryBackoff is the default flush retry backoff, disabled by default
+	defaultFlushRetryBackoff = time.Duration(0)
+
+	// defaultReadOnly accepts writes and flushes data by default
+	defaultReadOnly = false
 )
 
 var (
@@ -88,6 +107,9 @@ var (
 	errRepairOptionsNotSet        = errors.New("repair enabled but repair options are not set")
 	errIndexOptionsNotSet         = errors.New("index enabled but index options are not set")
 	errPersistManagerNotSet       = errors.New("persist manager is not set")
+	errFlushJitterNegative        = errors.New("flush jitter must not be negative")
+	errFlushNowTimeoutNegative    = errors.New("flush now timeout must not be negative")
+	errFlushRetryBackoffNegative  = errors.New("flush retry backoff must not be negative")
 )
 
 // NewSeriesOptionsFromOptions creates a new set of database series options from provided options.
@@ -142,6 +164,15 @@ type options struct {
 	fetchBlockMetadataResultsPool  block.FetchBlockMetadataResultsPool
 	fetchBlocksMetadataResultsPool block.FetchBlocksMetadataResultsPool
 	queryIDsWorkerPool             xsync.WorkerPool
+	flushJitter                    time.Duration
+	flushDryRun                    bool
+	flushNowTimeout                time.Duration
+	shardFlushTimingFn             ShardFlushTimingFn
+	shardFlushPriorityFn           ShardFlushPriorityFn
+	flushErrorRetryableFn          FlushErrorRetryableFn
+	skipFlushEmptyBlocks           bool
+	flushRetryBackoff              time.Duration
+	readOnly                       bool
 }
 
 // NewOptions creates a new set of storage options with defaults
@@ -195,6 +226,12 @@ func newOptions(poolOpts pool.ObjectPoolOptions) Options {
 		fetchBlockMetadataResultsPool:  block.NewFetchBlockMetadataResultsPool(poolOpts, 0),
 		fetchBlocksMetadataResultsPool: block.NewFetchBlocksMetadataResultsPool(poolOpts, 0),
 		queryIDsWorkerPool:             queryIDsWorkerPool,
+		flushJitter:                    defaultFlushJitter,
+		flushDryRun:                    defaultFlushDryRun,
+		flushNowTimeout:                defaultFlushNowTimeout,
+		skipFlushEmptyBlocks:           defaultSkipFlushEmptyBlocks,
+		flushRetryBackoff:              defaultFlushRetryBackoff,
+		readOnly:                       defaultReadOnly,
 	}
 	return o.SetEncodingM3TSZPooled()
 }
@@ -239,6 +276,21 @@ func (o *options) Validate() error {
 		return errPersistManagerNotSet
 	}
 
+	// validate flush jitter
+	if o.flushJitter < 0 {
+		return errFlushJitterNegative
+	}
+
+	// validate flush now timeout
+	if o.flushNowTimeout < 0 {
+		return errFlushNowTimeoutNegative
+	}
+
+	// validate flush retry backoff
+	if o.flushRetryBackoff < 0 {
+		return errFlushRetryBackoffNegative
+	}
+
 	// validate series cache policy
 	return series.ValidateCachePolicy(o.seriesCachePolicy)
 }
@@ -622,3 +674,93 @@ func (o *options) SetQueryIDsWorkerPool(value xsync.WorkerPool) Options {
 func (o *options) QueryIDsWorkerPool() xsync.WorkerPool {
 	return o.queryIDsWorkerPool
 }
+
+func (o *options) SetFlushJitter(value time.Duration) Options {
+	opts := *o
+	opts.flushJitter = value
+	return &opts
+}
+
+func (o *options) FlushJitter() time.Duration {
+	return o.flushJitter
+}
+
+func (o *options) SetFlushDryRun(value bool) Options {
+	opts := *o
+	opts.flushDryRun = value
+	return &opts
+}
+
+func (o *options) FlushDryRun() bool {
+	return o.flushDryRun
+}
+
+func (o *options) SetFlushNowTimeout(value time.Duration) Options {
+	opts := *o
+	opts.flushNowTimeout = value
+	return &opts
+}
+
+func (o *options) FlushNowTimeout() time.Duration {
+	return o.flushNowTimeout
+}
+
+func (o *options) SetShardFlushTimingFn(value ShardFlushTimingFn) Options {
+	opts := *o
+	opts.shardFlushTimingFn = value
+	return &opts
+}
+
+func (o *options) ShardFlushTimingFn() ShardFlushTimingFn {
+	return o.shardFlushTimingFn
+}
+
+func (o *options) SetShardFlushPriorityFn(value ShardFlushPriorityFn) Options {
+	opts := *o
+	opts.shardFlushPriorityFn = value
+	return &opts
+}
+
+func (o *options) ShardFlushPriorityFn() ShardFlushPriorityFn {
+	return o.shardFlushPriorityFn
+}
+
+func (o *options) SetFlushErrorRetryableFn(value FlushErrorRetryableFn) Options {
+	opts := *o
+	opts.flushErrorRetryableFn = value
+	return &opts
+}
+
+func (o *options) FlushErrorRetryableFn() FlushErrorRetryableFn {
+	return o.flushErrorRetryableFn
+}
+
+func (o *options) SetSkipFlushEmptyBlocks(value bool) Options {
+	opts := *o
+	opts.skipFlushEmptyBlocks = value
+	return &opts
+}
+
+func (o *options) SkipFlushEmptyBlocks() bool {
+	return o.skipFlushEmptyBlocks
+}
+
+func (o *options) SetFlushRetryBackoff(value time.Duration) Options {
+	opts := *o
+	opts.flushRetryBackoff = value
+	return &opts
+}
+
+func (o *options) FlushRetryBackoff() time.Duration {
+	return o.flushRetryBackoff
+}
+
+func (o *options) SetReadOnly(value bool) Options {
+	opts := *o
+	opts.readOnly = value
+	return &opts
+}
+
+func (o *options) ReadOnly() bool {
+	return o.readOnly
+}
@@ -32,6 +32,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
@@ -329,8 +330,8 @@ func (r *dbRepairer) namespaceRepairTimeRanges(ns databaseNamespace) xtime.Range
 		now       = r.nowFn()
 		rtopts    = ns.Options().RetentionOptions()
 		blockSize = rtopts.BlockSize()
-		start     = now.Add(-rtopts.RetentionPeriod()).Truncate(blockSize)
-		end       = now.Add(-rtopts.BufferPast()).Truncate(blockSize)
+		start     = retention.BlockStartForBlockSize(blockSize, now.Add(-rtopts.RetentionPeriod()))
+		end       = retention.BlockStartForBlockSize(blockSize, now.Add(-rtopts.BufferPast()))
 	)
 
 	targetRanges := xtime.NewRanges(xtime.Range{Start: start, End: end})
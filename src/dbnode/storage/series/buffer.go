@@ -28,6 +28,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/ts"
@@ -87,6 +88,10 @@ type databaseBuffer interface {
 
 	IsEmpty() bool
 
+	// HasData returns whether the buffer holds any readable data for the
+	// block starting at blockStart.
+	HasData(blockStart time.Time) bool
+
 	Stats() bufferStats
 
 	// MinMax returns the minimum and maximum blockstarts for the buckets
@@ -96,6 +101,10 @@ type databaseBuffer interface {
 
 	Tick() bufferTickResult
 
+	// ReleaseIdle releases the memory held by buckets that have no readable
+	// data and have not been written to for the buffer drain duration.
+	ReleaseIdle(now time.Time) int
+
 	NeedsDrain() bool
 
 	DrainAndReset() drainAndResetResult
@@ -108,6 +117,7 @@ type databaseBuffer interface {
 type bufferStats struct {
 	openBlocks  int
 	wiredBlocks int
+	datapoints  int
 }
 
 type drainAndResetResult struct {
@@ -127,6 +137,7 @@ type dbBuffer struct {
 	blockSize         time.Duration
 	bufferPast        time.Duration
 	bufferFuture      time.Duration
+	bufferDrain       time.Duration
 }
 
 type databaseBufferDrainFn func(b block.DatabaseBlock)
@@ -147,6 +158,7 @@ func (b *dbBuffer) Reset(opts Options) {
 	b.blockSize = ropts.BlockSize()
 	b.bufferPast = ropts.BufferPast()
 	b.bufferFuture = ropts.BufferFuture()
+	b.bufferDrain = opts.BufferDrain()
 	// Avoid capturing any variables with callback
 	b.computedForEachBucketAsc(computeAndResetBucketIdx, bucketResetStart)
 }
@@ -154,6 +166,7 @@ func (b *dbBuffer) Reset(opts Options) {
 func bucketResetStart(now time.Time, b *dbBuffer, idx int, start time.Time) int {
 	b.buckets[idx].opts = b.opts
 	b.buckets[idx].resetTo(start)
+	b.buckets[idx].lastActive = now
 	return 1
 }
 
@@ -192,18 +205,27 @@ func (b *dbBuffer) Write(
 		return m3dberrors.ErrTooPast
 	}
 
-	bucketStart := timestamp.Truncate(b.blockSize)
+	bucketStart := b.blockStart(timestamp)
 	idx := b.writableBucketIdx(timestamp)
 	if b.buckets[idx].needsReset(bucketStart) {
 		// Needs reset
 		b.DrainAndReset()
 	}
 
-	return b.buckets[idx].write(timestamp, value, unit, annotation)
+	if err := b.buckets[idx].write(timestamp, value, unit, annotation); err != nil {
+		return err
+	}
+	b.buckets[idx].lastActive = now
+	return nil
+}
+
+// blockStart returns the start of the block containing the given time.
+func (b *dbBuffer) blockStart(t time.Time) time.Time {
+	return retention.BlockStartForBlockSize(b.blockSize, t)
 }
 
 func (b *dbBuffer) writableBucketIdx(t time.Time) int {
-	return int(t.Truncate(b.blockSize).UnixNano() / int64(b.blockSize) % bucketsLen)
+	return int(b.blockStart(t).UnixNano() / int64(b.blockSize) % bucketsLen)
 }
 
 func (b *dbBuffer) IsEmpty() bool {
@@ -214,6 +236,15 @@ func (b *dbBuffer) IsEmpty() bool {
 	return !canReadAny
 }
 
+func (b *dbBuffer) HasData(blockStart time.Time) bool {
+	for i := range b.buckets {
+		if b.buckets[i].canRead() && b.buckets[i].start.Equal(blockStart) {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *dbBuffer) Stats() bufferStats {
 	var stats bufferStats
 	writableIdx := b.writableBucketIdx(b.nowFn())
@@ -225,6 +256,7 @@ func (b *dbBuffer) Stats() bufferStats {
 			stats.openBlocks++
 		}
 		stats.wiredBlocks++
+		stats.datapoints += b.buckets[i].numDatapoints
 	}
 	return stats
 }
@@ -266,6 +298,16 @@ func bucketTick(now time.Time, b *dbBuffer, idx int, start time.Time) int {
 	return mergedOutOfOrderBlocks
 }
 
+func (b *dbBuffer) ReleaseIdle(now time.Time) int {
+	released := 0
+	for i := range b.buckets {
+		if b.buckets[i].releaseIdle(now, b.bufferDrain) {
+			released++
+		}
+	}
+	return released
+}
+
 func (b *dbBuffer) DrainAndReset() drainAndResetResult {
 	// Avoid capturing any variables with callback
 	mergedOutOfOrder := b.computedForEachBucketAsc(computeAndResetBucketIdx, bucketDrainAndReset)
@@ -306,6 +348,7 @@ func bucketDrainAndReset(now time.Time, b *dbBuffer, idx int, start time.Time) i
 	if b.buckets[idx].needsReset(start) {
 		// Reset bucket
 		b.buckets[idx].resetTo(start)
+		b.buckets[idx].lastActive = now
 	}
 
 	return mergedOutOfOrderBlocks
@@ -349,7 +392,7 @@ func (b *dbBuffer) computedForEachBucketAsc(
 	fn func(now time.Time, b *dbBuffer, idx int, bucketStart time.Time) int,
 ) int {
 	now := b.nowFn()
-	pastMostBucketStart := now.Truncate(b.blockSize).Add(-1 * b.blockSize)
+	pastMostBucketStart := b.blockStart(now).Add(-1 * b.blockSize)
 	bucketNum := (pastMostBucketStart.UnixNano() / int64(b.blockSize)) % bucketsLen
 	result := 0
 	for i := int64(0); i < bucketsLen; i++ {
@@ -511,8 +554,10 @@ type dbBufferBucket struct {
 	encoders          []inOrderEncoder
 	bootstrapped      []block.DatabaseBlock
 	lastReadUnixNanos int64
+	numDatapoints     int
 	empty             bool
 	drained           bool
+	lastActive        time.Time
 }
 
 type inOrderEncoder struct {
@@ -537,6 +582,7 @@ func (b *dbBufferBucket) resetTo(
 	})
 	b.bootstrapped = nil
 	atomic.StoreInt64(&b.lastReadUnixNanos, 0)
+	b.numDatapoints = 0
 	b.empty = true
 	b.drained = false
 }
@@ -546,6 +592,20 @@ func (b *dbBufferBucket) finalize() {
 	b.resetBootstrapped()
 }
 
+// releaseIdle releases the encoders and bootstrapped blocks held by the bucket
+// if it has no readable data and has not been active for at least bufferDrain,
+// a subsequent write allocates a new encoder as needed.
+func (b *dbBufferBucket) releaseIdle(now time.Time, bufferDrain time.Duration) bool {
+	if b.canRead() || (len(b.encoders) == 0 && len(b.bootstrapped) == 0) {
+		return false
+	}
+	if now.Sub(b.lastActive) < bufferDrain {
+		return false
+	}
+	b.finalize()
+	return true
+}
+
 func (b *dbBufferBucket) canRead() bool {
 	return !b.drained && !b.empty
 }
@@ -596,10 +656,10 @@ func (b *dbBufferBucket) write(
 	if idx == -1 {
 		b.opts.Stats().IncCreatedEncoders()
 		bopts := b.opts.DatabaseBlockOptions()
-		blockSize := b.opts.RetentionOptions().BlockSize()
+		blockStart := retention.BlockStart(b.opts.RetentionOptions(), timestamp)
 		blockAllocSize := bopts.DatabaseBlockAllocSize()
 		encoder := bopts.EncoderPool().Get()
-		encoder.Reset(timestamp.Truncate(blockSize), blockAllocSize)
+		encoder.Reset(blockStart, blockAllocSize)
 		next := inOrderEncoder{encoder: encoder}
 		b.encoders = append(b.encoders, next)
 		idx = len(b.encoders) - 1
@@ -612,6 +672,7 @@ func (b *dbBufferBucket) write(
 		return err
 	}
 	b.encoders[idx].lastWriteAt = timestamp
+	b.numDatapoints++
 	b.empty = false
 	return nil
 }
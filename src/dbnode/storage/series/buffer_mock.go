@@ -132,6 +132,18 @@ func (mr *MockdatabaseBufferMockRecorder) IsEmpty() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEmpty", reflect.TypeOf((*MockdatabaseBuffer)(nil).IsEmpty))
 }
 
+// HasData mocks base method
+func (m *MockdatabaseBuffer) HasData(blockStart time.Time) bool {
+	ret := m.ctrl.Call(m, "HasData", blockStart)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasData indicates an expected call of HasData
+func (mr *MockdatabaseBufferMockRecorder) HasData(blockStart interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasData", reflect.TypeOf((*MockdatabaseBuffer)(nil).HasData), blockStart)
+}
+
 // Stats mocks base method
 func (m *MockdatabaseBuffer) Stats() bufferStats {
 	ret := m.ctrl.Call(m, "Stats")
@@ -170,6 +182,18 @@ func (mr *MockdatabaseBufferMockRecorder) Tick() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tick", reflect.TypeOf((*MockdatabaseBuffer)(nil).Tick))
 }
 
+// ReleaseIdle mocks base method
+func (m *MockdatabaseBuffer) ReleaseIdle(now time.Time) int {
+	ret := m.ctrl.Call(m, "ReleaseIdle", now)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ReleaseIdle indicates an expected call of ReleaseIdle
+func (mr *MockdatabaseBufferMockRecorder) ReleaseIdle(now interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdle", reflect.TypeOf((*MockdatabaseBuffer)(nil).ReleaseIdle), now)
+}
+
 // NeedsDrain mocks base method
 func (m *MockdatabaseBuffer) NeedsDrain() bool {
 	ret := m.ctrl.Call(m, "NeedsDrain")
@@ -134,6 +134,26 @@ func TestBufferWriteRead(t *testing.T) {
 	assertValuesEqual(t, data, results, opts)
 }
 
+func TestBufferHasData(t *testing.T) {
+	opts := newBufferTestOptions()
+	rops := opts.RetentionOptions()
+	curr := time.Now().Truncate(rops.BlockSize())
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return curr
+	}))
+	buffer := newDatabaseBuffer(nil).(*dbBuffer)
+	buffer.Reset(opts)
+
+	require.False(t, buffer.HasData(curr))
+
+	ctx := context.NewContext()
+	require.NoError(t, buffer.Write(ctx, curr.Add(secs(1)), 1, xtime.Second, nil))
+	ctx.Close()
+
+	require.True(t, buffer.HasData(curr))
+	require.False(t, buffer.HasData(curr.Add(-rops.BlockSize())))
+}
+
 func TestBufferReadOnlyMatchingBuckets(t *testing.T) {
 	opts := newBufferTestOptions()
 	rops := opts.RetentionOptions()
@@ -227,6 +247,39 @@ func TestBufferDrain(t *testing.T) {
 	assertValuesEqual(t, data[4:], results, opts)
 }
 
+func TestBufferReleaseIdle(t *testing.T) {
+	drainFn := func(b block.DatabaseBlock) {}
+
+	bufferDrain := 10 * time.Minute
+	opts := newBufferTestOptions().SetBufferDrain(bufferDrain)
+	rops := opts.RetentionOptions()
+	start := time.Now().Truncate(rops.BlockSize())
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return start
+	}))
+	buffer := newDatabaseBuffer(drainFn).(*dbBuffer)
+	buffer.Reset(opts)
+
+	// Buckets are not released before the buffer drain duration has passed
+	require.Equal(t, 0, buffer.ReleaseIdle(start.Add(bufferDrain-time.Nanosecond)))
+	require.Equal(t, bucketsLen, buffer.ReleaseIdle(start.Add(bufferDrain)))
+	for i := range buffer.buckets {
+		require.Equal(t, 0, len(buffer.buckets[i].encoders))
+	}
+
+	// Writing to a released bucket allocates a new encoder
+	ctx := context.NewContext()
+	defer ctx.Close()
+	data := []value{{start.Add(secs(1)), 1, xtime.Second, nil}}
+	require.NoError(t, buffer.Write(ctx, data[0].timestamp, data[0].value, data[0].unit, data[0].annotation))
+
+	// Buckets with readable data are never released
+	require.Equal(t, 0, buffer.ReleaseIdle(start.Add(2*bufferDrain)))
+
+	results := buffer.ReadEncoded(ctx, timeZero, timeDistantFuture)
+	assertValuesEqual(t, data, results, opts)
+}
+
 func TestBufferMinMax(t *testing.T) {
 	// Setup
 	drainFn := func(b block.DatabaseBlock) {}
@@ -431,6 +484,59 @@ func TestBufferWriteOutOfOrder(t *testing.T) {
 	assertValuesEqual(t, data, mergedResults, opts)
 }
 
+func TestBufferWriteOutOfOrderWithinBufferPastAcrossBlocks(t *testing.T) {
+	var drained []block.DatabaseBlock
+	drainFn := func(b block.DatabaseBlock) {
+		drained = append(drained, b)
+	}
+
+	opts := newBufferTestOptions()
+	rops := opts.RetentionOptions()
+	blockStart := time.Now().Truncate(rops.BlockSize())
+	prevBlockStart := blockStart.Add(-rops.BlockSize())
+	curr := blockStart.Add(secs(2))
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return curr
+	}))
+	buffer := newDatabaseBuffer(drainFn).(*dbBuffer)
+	buffer.Reset(opts)
+
+	// Each write is earlier than the one before it but still within
+	// buffer past, alternating between the current and previous block
+	data := []value{
+		{blockStart.Add(secs(1)), 1, xtime.Second, nil},
+		{blockStart.Add(-secs(3)), 2, xtime.Second, nil},
+		{blockStart, 3, xtime.Second, nil},
+		{blockStart.Add(-secs(6)), 4, xtime.Second, nil},
+	}
+
+	for _, v := range data {
+		ctx := context.NewContext()
+		require.NoError(t, buffer.Write(ctx, v.timestamp, v.value, v.unit, v.annotation))
+		ctx.Close()
+	}
+
+	// Move past buffer past for the previous block so that it drains
+	curr = blockStart.Add(rops.BufferPast()).Add(time.Second)
+	require.True(t, buffer.NeedsDrain())
+	buffer.DrainAndReset()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	require.Equal(t, 1, len(drained))
+	require.Equal(t, prevBlockStart, drained[0].StartTime())
+	assertValuesEqual(t, []value{data[3], data[1]}, [][]xio.BlockReader{[]xio.BlockReader{
+		xio.BlockReader{
+			SegmentReader: requireDrainedStream(ctx, t, drained[0]),
+		},
+	}}, opts)
+
+	results := buffer.ReadEncoded(ctx, timeZero, timeDistantFuture)
+	require.NotNil(t, results)
+	assertValuesEqual(t, []value{data[2], data[0]}, results, opts)
+}
+
 func newTestBufferBucketWithData(t *testing.T) (*dbBufferBucket, Options, []value) {
 	opts := newBufferTestOptions()
 	rops := opts.RetentionOptions()
@@ -892,3 +998,31 @@ func TestBufferSnapshot(t *testing.T) {
 	// Ensure single encoder again
 	assert.Equal(t, 1, len(encoders))
 }
+
+func TestBufferStatsDatapoints(t *testing.T) {
+	opts := newBufferTestOptions()
+	rops := opts.RetentionOptions()
+	curr := time.Now().Truncate(rops.BlockSize())
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return curr
+	}))
+	buffer := newDatabaseBuffer(nil).(*dbBuffer)
+	buffer.Reset(opts)
+
+	require.Equal(t, 0, buffer.Stats().datapoints)
+
+	data := []value{
+		{curr.Add(secs(1)), 1, xtime.Second, nil},
+		{curr.Add(secs(2)), 2, xtime.Second, nil},
+		// Duplicate timestamps are discarded and should not be counted
+		{curr.Add(secs(2)), 3, xtime.Second, nil},
+		{curr.Add(secs(3)), 4, xtime.Second, nil},
+	}
+	for _, v := range data {
+		ctx := context.NewContext()
+		require.NoError(t, buffer.Write(ctx, v.timestamp, v.value, v.unit, v.annotation))
+		ctx.Close()
+	}
+
+	require.Equal(t, 3, buffer.Stats().datapoints)
+}
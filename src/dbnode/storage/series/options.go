@@ -21,6 +21,9 @@
 package series
 
 import (
+	"errors"
+	"time"
+
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/retention"
@@ -31,12 +34,23 @@ import (
 	"github.com/m3db/m3x/pool"
 )
 
+const (
+	// defaultBufferDrain is the default duration a buffer bucket with no
+	// readable data must go without writes before its memory is released
+	defaultBufferDrain = 10 * time.Minute
+)
+
+var (
+	errBufferDrainNegative = errors.New("buffer drain must be non-negative")
+)
+
 type options struct {
 	clockOpts                     clock.Options
 	instrumentOpts                instrument.Options
 	retentionOpts                 retention.Options
 	blockOpts                     block.Options
 	cachePolicy                   CachePolicy
+	bufferDrain                   time.Duration
 	contextPool                   context.Pool
 	encoderPool                   encoding.EncoderPool
 	multiReaderIteratorPool       encoding.MultiReaderIteratorPool
@@ -60,6 +74,7 @@ func NewOptions() Options {
 		retentionOpts:                 retention.NewOptions(),
 		blockOpts:                     block.NewOptions(),
 		cachePolicy:                   DefaultCachePolicy,
+		bufferDrain:                   defaultBufferDrain,
 		contextPool:                   context.NewPool(context.NewOptions()),
 		encoderPool:                   encoding.NewEncoderPool(nil),
 		multiReaderIteratorPool:       encoding.NewMultiReaderIteratorPool(nil),
@@ -73,6 +88,9 @@ func (o *options) Validate() error {
 	if err := o.retentionOpts.Validate(); err != nil {
 		return err
 	}
+	if o.bufferDrain < 0 {
+		return errBufferDrainNegative
+	}
 	return ValidateCachePolicy(o.cachePolicy)
 }
 
@@ -126,6 +144,16 @@ func (o *options) CachePolicy() CachePolicy {
 	return o.cachePolicy
 }
 
+func (o *options) SetBufferDrain(value time.Duration) Options {
+	opts := *o
+	opts.bufferDrain = value
+	return &opts
+}
+
+func (o *options) BufferDrain() time.Duration {
+	return o.bufferDrain
+}
+
 func (o *options) SetContextPool(value context.Pool) Options {
 	opts := *o
 	opts.contextPool = value
@@ -96,8 +96,8 @@ func (r Reader) readersWithBlocksMapAndBuffer(
 		cachePolicy  = r.opts.CachePolicy()
 		ropts        = r.opts.RetentionOptions()
 		size         = ropts.BlockSize()
-		alignedStart = start.Truncate(size)
-		alignedEnd   = end.Truncate(size)
+		alignedStart = retention.BlockStartForBlockSize(size, start)
+		alignedEnd   = retention.BlockStartForBlockSize(size, end)
 	)
 
 	if alignedEnd.Equal(end) {
@@ -110,7 +110,7 @@ func (r Reader) readersWithBlocksMapAndBuffer(
 	if alignedStart.Before(earliest) {
 		alignedStart = earliest
 	}
-	latest := now.Add(ropts.BufferFuture()).Truncate(size)
+	latest := retention.BlockStart(ropts, now.Add(ropts.BufferFuture()))
 	if alignedEnd.After(latest) {
 		alignedEnd = latest
 	}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
@@ -143,6 +144,13 @@ func (s *dbSeries) Tick() (TickResult, error) {
 	return r, nil
 }
 
+func (s *dbSeries) ReleaseIdleBuffers(now time.Time) int {
+	s.Lock()
+	released := s.buffer.ReleaseIdle(now)
+	s.Unlock()
+	return released
+}
+
 type updateBlocksResult struct {
 	TickStatus
 	madeExpiredBlocks int
@@ -156,7 +164,7 @@ func (s *dbSeries) updateBlocksWithLock() (updateBlocksResult, error) {
 		ropts        = s.opts.RetentionOptions()
 		retriever    = s.blockRetriever
 		cachePolicy  = s.opts.CachePolicy()
-		expireCutoff = now.Add(-ropts.RetentionPeriod()).Truncate(ropts.BlockSize())
+		expireCutoff = retention.BlockStart(ropts, now.Add(-ropts.RetentionPeriod()))
 		wiredTimeout = ropts.BlockDataExpiryAfterNotAccessedPeriod()
 	)
 	for startNano, currBlock := range s.blocks.AllBlocks() {
@@ -290,6 +298,15 @@ func (s *dbSeries) IsEmpty() bool {
 	return false
 }
 
+func (s *dbSeries) HasData(blockStart time.Time) bool {
+	s.RLock()
+	defer s.RUnlock()
+	if _, exists := s.blocks.BlockAt(blockStart); exists {
+		return true
+	}
+	return s.buffer.HasData(blockStart)
+}
+
 func (s *dbSeries) NumActiveBlocks() int {
 	s.RLock()
 	value := s.blocks.Len() + s.buffer.Stats().wiredBlocks
@@ -297,6 +314,13 @@ func (s *dbSeries) NumActiveBlocks() int {
 	return value
 }
 
+func (s *dbSeries) NumBufferedDatapoints() int {
+	s.RLock()
+	value := s.buffer.Stats().datapoints
+	s.RUnlock()
+	return value
+}
+
 func (s *dbSeries) IsBootstrapped() bool {
 	s.RLock()
 	state := s.bs
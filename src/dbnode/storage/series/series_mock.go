@@ -160,6 +160,18 @@ func (mr *MockDatabaseSeriesMockRecorder) IsEmpty() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEmpty", reflect.TypeOf((*MockDatabaseSeries)(nil).IsEmpty))
 }
 
+// HasData mocks base method
+func (m *MockDatabaseSeries) HasData(blockStart time.Time) bool {
+	ret := m.ctrl.Call(m, "HasData", blockStart)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasData indicates an expected call of HasData
+func (mr *MockDatabaseSeriesMockRecorder) HasData(blockStart interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasData", reflect.TypeOf((*MockDatabaseSeries)(nil).HasData), blockStart)
+}
+
 // NumActiveBlocks mocks base method
 func (m *MockDatabaseSeries) NumActiveBlocks() int {
 	ret := m.ctrl.Call(m, "NumActiveBlocks")
@@ -172,6 +184,18 @@ func (mr *MockDatabaseSeriesMockRecorder) NumActiveBlocks() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NumActiveBlocks", reflect.TypeOf((*MockDatabaseSeries)(nil).NumActiveBlocks))
 }
 
+// NumBufferedDatapoints mocks base method
+func (m *MockDatabaseSeries) NumBufferedDatapoints() int {
+	ret := m.ctrl.Call(m, "NumBufferedDatapoints")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// NumBufferedDatapoints indicates an expected call of NumBufferedDatapoints
+func (mr *MockDatabaseSeriesMockRecorder) NumBufferedDatapoints() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NumBufferedDatapoints", reflect.TypeOf((*MockDatabaseSeries)(nil).NumBufferedDatapoints))
+}
+
 // OnEvictedFromWiredList mocks base method
 func (m *MockDatabaseSeries) OnEvictedFromWiredList(arg0 ident.ID, arg1 time.Time) {
 	m.ctrl.Call(m, "OnEvictedFromWiredList", arg0, arg1)
@@ -252,6 +276,18 @@ func (mr *MockDatabaseSeriesMockRecorder) Tick() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tick", reflect.TypeOf((*MockDatabaseSeries)(nil).Tick))
 }
 
+// ReleaseIdleBuffers mocks base method
+func (m *MockDatabaseSeries) ReleaseIdleBuffers(now time.Time) int {
+	ret := m.ctrl.Call(m, "ReleaseIdleBuffers", now)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ReleaseIdleBuffers indicates an expected call of ReleaseIdleBuffers
+func (mr *MockDatabaseSeriesMockRecorder) ReleaseIdleBuffers(now interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdleBuffers", reflect.TypeOf((*MockDatabaseSeries)(nil).ReleaseIdleBuffers), now)
+}
+
 // Write mocks base method
 func (m *MockDatabaseSeries) Write(arg0 context.Context, arg1 time.Time, arg2 float64, arg3 time0.Unit, arg4 []byte) error {
 	ret := m.ctrl.Call(m, "Write", arg0, arg1, arg2, arg3, arg4)
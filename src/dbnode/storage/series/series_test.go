@@ -175,6 +175,55 @@ func TestSeriesWriteFlushRead(t *testing.T) {
 	assertValuesEqual(t, data, results, opts)
 }
 
+func TestSeriesReadYourWritesBeforeFlush(t *testing.T) {
+	opts := newSeriesTestOptions()
+	curr := time.Now().Truncate(opts.RetentionOptions().BlockSize())
+	start := curr
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return curr
+	}))
+	series := NewDatabaseSeries(ident.StringID("foo"), ident.Tags{}, opts).(*dbSeries)
+	_, err := series.Bootstrap(nil)
+	assert.NoError(t, err)
+
+	data := []value{
+		{curr, 1, xtime.Second, nil},
+		{curr.Add(mins(1)), 2, xtime.Second, nil},
+		{curr.Add(mins(2)), 3, xtime.Second, nil},
+		{curr.Add(mins(3)), 4, xtime.Second, nil},
+	}
+
+	for _, v := range data {
+		curr = v.timestamp
+		ctx := context.NewContext()
+		assert.NoError(t, series.Write(ctx, v.timestamp, v.value, xtime.Second, v.annotation))
+		ctx.Close()
+	}
+
+	// Drain the first block so that reads span both the series blocks and
+	// the buffer
+	_, err = series.Tick()
+	assert.NoError(t, err)
+	require.Equal(t, 1, series.blocks.Len())
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	// A write to the current block window is visible to an immediate read
+	latest := value{curr.Add(30 * time.Second), 5, xtime.Second, nil}
+	curr = latest.timestamp
+	assert.NoError(t, series.Write(ctx, latest.timestamp, latest.value, xtime.Second, nil))
+	data = append(data, latest)
+
+	results, err := series.ReadEncoded(ctx, start, curr.Add(time.Second))
+	require.NoError(t, err)
+	assertValuesEqual(t, data, results, opts)
+
+	// The write has not been drained from the buffer
+	require.Equal(t, 1, series.blocks.Len())
+	require.False(t, series.buffer.IsEmpty())
+}
+
 func TestSeriesReadEndBeforeStart(t *testing.T) {
 	opts := newSeriesTestOptions()
 	series := NewDatabaseSeries(ident.StringID("foo"), ident.Tags{}, opts).(*dbSeries)
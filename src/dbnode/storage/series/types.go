@@ -51,6 +51,11 @@ type DatabaseSeries interface {
 	// Tick executes any updates to ensure buffer drains, blocks are flushed, etc
 	Tick() (TickResult, error)
 
+	// ReleaseIdleBuffers releases the memory held by buffer buckets that have
+	// no readable data and have not been written to for the buffer drain
+	// duration, returning the number of buckets released
+	ReleaseIdleBuffers(now time.Time) int
+
 	// Write writes a new value
 	Write(
 		ctx context.Context,
@@ -82,9 +87,17 @@ type DatabaseSeries interface {
 	// IsEmpty returns whether series is empty
 	IsEmpty() bool
 
+	// HasData returns whether the series holds any data, either in a block or
+	// in the buffer, for the block starting at blockStart
+	HasData(blockStart time.Time) bool
+
 	// NumActiveBlocks returns the number of active blocks the series currently holds
 	NumActiveBlocks() int
 
+	// NumBufferedDatapoints returns the number of datapoints held in the
+	// series buffer that have not yet been drained to blocks
+	NumBufferedDatapoints() int
+
 	// IsBootstrapped returns whether the series is bootstrapped or not
 	IsBootstrapped() bool
 
@@ -226,6 +239,16 @@ type Options interface {
 	// CachePolicy returns the series cache policy
 	CachePolicy() CachePolicy
 
+	// SetBufferDrain sets the duration after which the memory held by a buffer
+	// bucket with no readable data, such as one already drained to a block,
+	// is released if the bucket has not been written to
+	SetBufferDrain(value time.Duration) Options
+
+	// BufferDrain returns the duration after which the memory held by a buffer
+	// bucket with no readable data, such as one already drained to a block,
+	// is released if the bucket has not been written to
+	BufferDrain() time.Duration
+
 	// SetContextPool sets the contextPool
 	SetContextPool(value context.Pool) Options
 
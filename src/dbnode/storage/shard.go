@@ -81,6 +81,12 @@ type filesetBeforeFn func(
 	t time.Time,
 ) ([]string, error)
 
+type filesetsSupersededFn func(
+	filePathPrefix string,
+	namespace ident.ID,
+	shardID uint32,
+) ([]string, error)
+
 type snapshotFilesFn func(filePathPrefix string, namespace ident.ID, shard uint32) (fs.FileSetFilesSlice, error)
 
 type tickPolicy int
@@ -153,6 +159,7 @@ type dbShard struct {
 	list                     *list.List
 	bootstrapState           BootstrapState
 	filesetBeforeFn          filesetBeforeFn
+	filesetsSupersededFn     filesetsSupersededFn
 	deleteFilesFn            deleteFilesFn
 	snapshotFilesFn          snapshotFilesFn
 	sleepFn                  func(time.Duration)
@@ -163,6 +170,7 @@ type dbShard struct {
 	tickWg                   *sync.WaitGroup
 	runtimeOptsListenClosers []xclose.SimpleCloser
 	currRuntimeOptions       dbShardRuntimeOptions
+	retentionOpts            retention.Options
 	logger                   xlog.Logger
 	metrics                  dbShardMetrics
 	newSeriesBootstrapped    bool
@@ -191,6 +199,8 @@ type dbShardMetrics struct {
 	insertAsyncWriteErrors        tally.Counter
 	seriesBootstrapBlocksToBuffer tally.Counter
 	seriesBootstrapBlocksMerged   tally.Counter
+	flushDryRun                   tally.Counter
+	flushPermanentFailures        tally.Counter
 }
 
 func newDatabaseShardMetrics(scope tally.Scope) dbShardMetrics {
@@ -211,6 +221,8 @@ func newDatabaseShardMetrics(scope tally.Scope) dbShardMetrics {
 		}).Counter("insert-async.errors"),
 		seriesBootstrapBlocksToBuffer: seriesBootstrapScope.Counter("blocks-to-buffer"),
 		seriesBootstrapBlocksMerged:   seriesBootstrapScope.Counter("blocks-merged"),
+		flushDryRun:                   scope.Counter("flush-dry-run"),
+		flushPermanentFailures:        scope.Counter("flush-permanent-failures"),
 	}
 }
 
@@ -223,11 +235,16 @@ type shardListElement *list.Element
 type shardFlushState struct {
 	sync.RWMutex
 	statesByTime map[xtime.UnixNano]fileOpState
+	// dryRunsByTime counts the dry run flushes of each block, these are kept
+	// apart from the flush states since no file set is written by a dry run
+	// and so cleanup and retrieval must not treat the block as flushed.
+	dryRunsByTime map[xtime.UnixNano]int
 }
 
 func newShardFlushState() shardFlushState {
 	return shardFlushState{
-		statesByTime: make(map[xtime.UnixNano]fileOpState),
+		statesByTime:  make(map[xtime.UnixNano]fileOpState),
+		dryRunsByTime: make(map[xtime.UnixNano]int),
 	}
 }
 
@@ -253,29 +270,31 @@ func newDatabaseShard(
 		SubScope("dbshard")
 
 	s := &dbShard{
-		opts:               opts,
-		seriesOpts:         seriesOpts,
-		nowFn:              opts.ClockOptions().NowFn(),
-		state:              dbShardStateOpen,
-		namespace:          namespaceMetadata,
-		shard:              shard,
-		namespaceReaderMgr: namespaceReaderMgr,
-		increasingIndex:    increasingIndex,
-		seriesPool:         opts.DatabaseSeriesPool(),
-		commitLogWriter:    commitLogWriter,
-		reverseIndex:       reverseIndex,
-		lookup:             newShardMap(shardMapOptions{}),
-		list:               list.New(),
-		filesetBeforeFn:    fs.DataFileSetsBefore,
-		deleteFilesFn:      fs.DeleteFiles,
-		snapshotFilesFn:    fs.SnapshotFiles,
-		sleepFn:            time.Sleep,
-		identifierPool:     opts.IdentifierPool(),
-		contextPool:        opts.ContextPool(),
-		flushState:         newShardFlushState(),
-		tickWg:             &sync.WaitGroup{},
-		logger:             opts.InstrumentOptions().Logger(),
-		metrics:            newDatabaseShardMetrics(scope),
+		opts:                 opts,
+		seriesOpts:           seriesOpts,
+		nowFn:                opts.ClockOptions().NowFn(),
+		state:                dbShardStateOpen,
+		namespace:            namespaceMetadata,
+		retentionOpts:        namespaceMetadata.Options().RetentionOptions(),
+		shard:                shard,
+		namespaceReaderMgr:   namespaceReaderMgr,
+		increasingIndex:      increasingIndex,
+		seriesPool:           opts.DatabaseSeriesPool(),
+		commitLogWriter:      commitLogWriter,
+		reverseIndex:         reverseIndex,
+		lookup:               newShardMap(shardMapOptions{}),
+		list:                 list.New(),
+		filesetBeforeFn:      fs.DataFileSetsBefore,
+		filesetsSupersededFn: fs.DataFileSetsSuperseded,
+		deleteFilesFn:        fs.DeleteFiles,
+		snapshotFilesFn:      fs.SnapshotFiles,
+		sleepFn:              time.Sleep,
+		identifierPool:       opts.IdentifierPool(),
+		contextPool:          opts.ContextPool(),
+		flushState:           newShardFlushState(),
+		tickWg:               &sync.WaitGroup{},
+		logger:               opts.InstrumentOptions().Logger(),
+		metrics:              newDatabaseShardMetrics(scope),
 	}
 	s.insertQueue = newDatabaseShardInsertQueue(s.insertSeriesBatch,
 		s.nowFn, scope)
@@ -324,6 +343,21 @@ func (s *dbShard) SetRuntimeOptions(value runtime.Options) {
 	s.Unlock()
 }
 
+func (s *dbShard) SetRetentionOptions(value retention.Options) {
+	s.Lock()
+	s.retentionOpts = value
+	s.Unlock()
+}
+
+// retentionOptions returns the retention options of the namespace, unlike the
+// rest of the namespace options these can be updated at runtime.
+func (s *dbShard) retentionOptions() retention.Options {
+	s.RLock()
+	ropts := s.retentionOpts
+	s.RUnlock()
+	return ropts
+}
+
 func (s *dbShard) ID() uint32 {
 	return s.shard
 }
@@ -335,6 +369,24 @@ func (s *dbShard) NumSeries() int64 {
 	return int64(n)
 }
 
+func (s *dbShard) ReleaseIdleBuffers(now time.Time) int {
+	released := 0
+	s.forEachShardEntry(func(entry *lookup.Entry) bool {
+		released += entry.Series.ReleaseIdleBuffers(now)
+		return true
+	})
+	return released
+}
+
+func (s *dbShard) NumBufferedDatapoints() int64 {
+	var n int64
+	s.forEachShardEntry(func(entry *lookup.Entry) bool {
+		n += int64(entry.Series.NumBufferedDatapoints())
+		return true
+	})
+	return n
+}
+
 // Stream implements series.QueryableBlockRetriever
 func (s *dbShard) Stream(
 	ctx context.Context,
@@ -349,7 +401,7 @@ func (s *dbShard) Stream(
 func (s *dbShard) IsBlockRetrievable(blockStart time.Time) bool {
 	flushState := s.FlushState(blockStart)
 	switch flushState.Status {
-	case fileOpNotStarted, fileOpInProgress, fileOpFailed:
+	case fileOpNotStarted, fileOpInProgress, fileOpFailed, fileOpPermanentlyFailed:
 		return false
 	case fileOpSuccess:
 		return true
@@ -770,6 +822,22 @@ func (s *dbShard) Write(
 		value, unit, annotation, false)
 }
 
+func (s *dbShard) WriteBatch(
+	ctx context.Context,
+	points []DataPoint,
+) BatchError {
+	var errs BatchError
+	for i := range points {
+		p := points[i]
+		err := s.writeAndIndex(ctx, p.ID, ident.EmptyTagIterator, p.Timestamp,
+			p.Value, p.Unit, p.Annotation, false)
+		if err != nil {
+			errs = append(errs, BatchWriteError{Index: i, Err: err})
+		}
+	}
+	return errs
+}
+
 func (s *dbShard) writeAndIndex(
 	ctx context.Context,
 	id ident.ID,
@@ -1590,10 +1658,10 @@ func (s *dbShard) FetchBlocksMetadataV2(
 	// flushed block and work backwards.
 	var (
 		result    = s.opts.FetchBlocksMetadataResultsPool().Get()
-		ropts     = s.namespace.Options().RetentionOptions()
+		ropts     = s.retentionOptions()
 		blockSize = ropts.BlockSize()
 		// Subtract one blocksize because all fetch requests are exclusive on the end side
-		blockStart      = end.Truncate(blockSize).Add(-1 * blockSize)
+		blockStart      = retention.BlockStart(ropts, end).Add(-1 * blockSize)
 		tokenBlockStart time.Time
 		numResults      int64
 	)
@@ -1829,6 +1897,16 @@ func (s *dbShard) Flush(
 	}
 	s.RUnlock()
 
+	// In dry run mode only record the flush so that flush scheduling can be
+	// observed without writing any data to disk, the flush state is left
+	// untouched as the block still needs to be flushed.
+	if s.opts.FlushDryRun() {
+		s.markFlushDryRun(blockStart)
+		return nil
+	}
+
+	s.markFlushStateInProgress(blockStart)
+
 	prepareOpts := persist.DataPrepareOptions{
 		NamespaceMetadata: s.namespace,
 		Shard:             s.ID(),
@@ -1839,12 +1917,23 @@ func (s *dbShard) Flush(
 		// racing competing processes.
 		DeleteIfExists: false,
 	}
+
 	prepared, err := flush.PrepareData(prepareOpts)
 	if err != nil {
-		return s.markFlushStateSuccessOrError(blockStart, err)
+		return s.markFlushStateSuccessOrError(blockStart, err,
+			s.isRetryableFlushError(err))
 	}
 
-	var multiErr xerrors.MultiError
+	var (
+		multiErr xerrors.MultiError
+		// NB: Errors are classified as they are added since the retryable fn
+		// must be called with the original errors rather than the multi error.
+		retryable = true
+		addErr    = func(err error) {
+			multiErr = multiErr.Add(err)
+			retryable = retryable && s.isRetryableFlushError(err)
+		}
+	)
 	tmpCtx := context.NewContext()
 
 	flushResult := dbShardFlushResult{}
@@ -1857,7 +1946,7 @@ func (s *dbShard) Flush(
 		tmpCtx.BlockingClose()
 
 		if err != nil {
-			multiErr = multiErr.Add(err)
+			addErr(err)
 			// If we encounter an error when persisting a series, don't continue as
 			// the file on disk could be in a corrupt state.
 			return false
@@ -1871,10 +1960,10 @@ func (s *dbShard) Flush(
 	s.logFlushResult(flushResult)
 
 	if err := prepared.Close(); err != nil {
-		multiErr = multiErr.Add(err)
+		addErr(err)
 	}
 
-	return s.markFlushStateSuccessOrError(blockStart, multiErr.FinalError())
+	return s.markFlushStateSuccessOrError(blockStart, multiErr.FinalError(), retryable)
 }
 
 func (s *dbShard) Snapshot(
@@ -1944,6 +2033,16 @@ func (s *dbShard) Snapshot(
 	return multiErr.FinalError()
 }
 
+func (s *dbShard) HasData(blockStart time.Time) bool {
+	hasData := false
+	s.forEachShardEntry(func(entry *lookup.Entry) bool {
+		hasData = entry.Series.HasData(blockStart)
+		// Stop iterating as soon as any series has data
+		return !hasData
+	})
+	return hasData
+}
+
 func (s *dbShard) FlushState(blockStart time.Time) fileOpState {
 	s.flushState.RLock()
 	state, ok := s.flushState.statesByTime[xtime.ToUnixNano(blockStart)]
@@ -1955,22 +2054,61 @@ func (s *dbShard) FlushState(blockStart time.Time) fileOpState {
 	return state
 }
 
-func (s *dbShard) markFlushStateSuccessOrError(blockStart time.Time, err error) error {
-	// Track flush state for block state
-	if err == nil {
+func (s *dbShard) markFlushDryRun(blockStart time.Time) {
+	s.flushState.Lock()
+	s.flushState.dryRunsByTime[xtime.ToUnixNano(blockStart)]++
+	s.flushState.Unlock()
+	s.metrics.flushDryRun.Inc(1)
+}
+
+func (s *dbShard) numFlushDryRuns(blockStart time.Time) int {
+	s.flushState.RLock()
+	defer s.flushState.RUnlock()
+	return s.flushState.dryRunsByTime[xtime.ToUnixNano(blockStart)]
+}
+
+func (s *dbShard) markFlushStateSuccessOrError(
+	blockStart time.Time,
+	err error,
+	retryable bool,
+) error {
+	// Track flush state for block state, errors that are not retryable
+	// permanently fail the block rather than counting towards its failures
+	switch {
+	case err == nil:
 		s.markFlushStateSuccess(blockStart)
-	} else {
+	case retryable:
 		s.markFlushStateFail(blockStart)
+	default:
+		s.markFlushStatePermanentlyFailed(blockStart, err)
 	}
 	return err
 }
 
+// isRetryableFlushError returns whether a flush that failed with the error
+// should be retried, the error must be an original error rather than a multi
+// error wrapping it so that the retryable fn can compare it.
+func (s *dbShard) isRetryableFlushError(err error) bool {
+	retryableFn := s.opts.FlushErrorRetryableFn()
+	return retryableFn == nil || retryableFn(err)
+}
+
 func (s *dbShard) markFlushStateSuccess(blockStart time.Time) {
 	s.flushState.Lock()
 	s.flushState.statesByTime[xtime.ToUnixNano(blockStart)] = fileOpState{Status: fileOpSuccess}
 	s.flushState.Unlock()
 }
 
+// markFlushStateInProgress marks the block as being flushed, retaining the
+// number of times the block previously failed to flush.
+func (s *dbShard) markFlushStateInProgress(blockStart time.Time) {
+	s.flushState.Lock()
+	state := s.flushState.statesByTime[xtime.ToUnixNano(blockStart)]
+	state.Status = fileOpInProgress
+	s.flushState.statesByTime[xtime.ToUnixNano(blockStart)] = state
+	s.flushState.Unlock()
+}
+
 func (s *dbShard) markFlushStateFail(blockStart time.Time) {
 	s.flushState.Lock()
 	state := s.flushState.statesByTime[xtime.ToUnixNano(blockStart)]
@@ -1980,14 +2118,34 @@ func (s *dbShard) markFlushStateFail(blockStart time.Time) {
 	s.flushState.Unlock()
 }
 
+func (s *dbShard) markFlushStatePermanentlyFailed(blockStart time.Time, err error) {
+	s.flushState.Lock()
+	state := s.flushState.statesByTime[xtime.ToUnixNano(blockStart)]
+	state.Status = fileOpPermanentlyFailed
+	s.flushState.statesByTime[xtime.ToUnixNano(blockStart)] = state
+	s.flushState.Unlock()
+
+	s.metrics.flushPermanentFailures.Inc(1)
+	s.logger.WithFields(
+		xlog.NewField("shard", s.ID()),
+		xlog.NewField("blockStart", blockStart.String()),
+		xlog.NewField("error", err.Error()),
+	).Error("flush failed with an error that is not retryable, block will not be flushed again")
+}
+
 func (s *dbShard) removeAnyFlushStatesTooEarly(tickStart time.Time) {
+	earliestFlush := retention.FlushTimeStart(s.retentionOptions(), tickStart)
 	s.flushState.Lock()
-	earliestFlush := retention.FlushTimeStart(s.namespace.Options().RetentionOptions(), tickStart)
 	for t := range s.flushState.statesByTime {
 		if t.ToTime().Before(earliestFlush) {
 			delete(s.flushState.statesByTime, t)
 		}
 	}
+	for t := range s.flushState.dryRunsByTime {
+		if t.ToTime().Before(earliestFlush) {
+			delete(s.flushState.dryRunsByTime, t)
+		}
+	}
 	s.flushState.Unlock()
 }
 
@@ -2049,7 +2207,7 @@ func (s *dbShard) CleanupSnapshots(earliestToRetain time.Time) error {
 			continue
 		}
 
-		if s.FlushState(curr.ID.BlockStart).Status == fileOpSuccess {
+		if !s.FlushState(curr.ID.BlockStart).NeedsFlush() {
 			// Delete snapshot files for any block starts that have been
 			// successfully flushed.
 			filesToDelete = append(filesToDelete, curr.AbsoluteFilepaths...)
@@ -2081,7 +2239,16 @@ func (s *dbShard) CleanupExpiredFileSets(earliestToRetain time.Time) error {
 				filePathPrefix, s.namespace.ID(), s.ID(), err)
 		multiErr = multiErr.Add(detailedErr)
 	}
-	if err := s.deleteFilesFn(expired); err != nil {
+	// Volumes superseded by a later volume of the same block are no longer
+	// read once the later volume is complete.
+	superseded, err := s.filesetsSupersededFn(filePathPrefix, s.namespace.ID(), s.ID())
+	if err != nil {
+		detailedErr :=
+			fmt.Errorf("encountered errors when getting superseded fileset files for prefix %s namespace %s shard %d: %v",
+				filePathPrefix, s.namespace.ID(), s.ID(), err)
+		multiErr = multiErr.Add(detailedErr)
+	}
+	if err := s.deleteFilesFn(append(expired, superseded...)); err != nil {
 		multiErr = multiErr.Add(err)
 	}
 	return multiErr.FinalError()
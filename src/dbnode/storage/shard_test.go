@@ -44,6 +44,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3x/checked"
 	"github.com/m3db/m3x/context"
+	xerrors "github.com/m3db/m3x/errors"
 	"github.com/m3db/m3x/ident"
 	xtest "github.com/m3db/m3x/test"
 	xtime "github.com/m3db/m3x/time"
@@ -251,6 +252,87 @@ func TestShardFlushSeriesFlushError(t *testing.T) {
 	}, flushState)
 }
 
+func TestShardFlushSeriesFlushNonRetryableError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockStart := time.Unix(21600, 0)
+	errDiskFull := errors.New("disk full")
+
+	opts := testDatabaseOptions().SetFlushErrorRetryableFn(func(err error) bool {
+		return err != errDiskFull
+	})
+	s := testDatabaseShard(t, opts)
+	defer s.Close()
+	s.bootstrapState = Bootstrapped
+	s.flushState.statesByTime[xtime.ToUnixNano(blockStart)] = fileOpState{
+		Status:      fileOpFailed,
+		NumFailures: 1,
+	}
+
+	flush := persist.NewMockDataFlush(ctrl)
+	prepared := persist.PreparedDataPersist{
+		Persist: func(ident.ID, ident.Tags, ts.Segment, uint32) error { return nil },
+		Close:   func() error { return nil },
+	}
+	flush.EXPECT().PrepareData(gomock.Any()).Return(prepared, nil)
+
+	curr := series.NewMockDatabaseSeries(ctrl)
+	curr.EXPECT().ID().Return(ident.StringID("foo")).AnyTimes()
+	curr.EXPECT().IsEmpty().Return(false).AnyTimes()
+	curr.EXPECT().
+		Flush(gomock.Any(), blockStart, gomock.Any()).
+		Return(series.FlushOutcomeErr, errDiskFull)
+	s.list.PushBack(lookup.NewEntry(curr, 0))
+
+	err := s.Flush(blockStart, flush)
+	require.Error(t, err)
+	require.Equal(t, errDiskFull.Error(), err.Error())
+
+	// The block is not retried and the error does not count as a failure
+	flushState := s.FlushState(blockStart)
+	require.Equal(t, fileOpState{
+		Status:      fileOpPermanentlyFailed,
+		NumFailures: 1,
+	}, flushState)
+	require.False(t, flushState.NeedsFlushAttempt())
+	require.False(t, s.IsBlockRetrievable(blockStart))
+
+	// The block still needs a flush so that the commit logs and snapshots
+	// holding its data are not cleaned up
+	require.True(t, flushState.NeedsFlush())
+}
+
+func TestShardFlushPrepareNonRetryableError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockStart := time.Unix(21600, 0)
+	errDiskFull := errors.New("disk full")
+
+	opts := testDatabaseOptions().SetFlushErrorRetryableFn(func(err error) bool {
+		return err != errDiskFull
+	})
+	s := testDatabaseShard(t, opts)
+	defer s.Close()
+	s.bootstrapState = Bootstrapped
+
+	flush := persist.NewMockDataFlush(ctrl)
+	flush.EXPECT().PrepareData(gomock.Any()).Return(persist.PreparedDataPersist{}, errDiskFull)
+
+	require.Equal(t, errDiskFull, s.Flush(blockStart, flush))
+	require.Equal(t, fileOpPermanentlyFailed, s.FlushState(blockStart).Status)
+
+	// Other errors are retried
+	flush.EXPECT().PrepareData(gomock.Any()).Return(persist.PreparedDataPersist{}, errors.New("retry"))
+	blockStart = blockStart.Add(2 * time.Hour)
+	require.Error(t, s.Flush(blockStart, flush))
+	require.Equal(t, fileOpState{
+		Status:      fileOpFailed,
+		NumFailures: 1,
+	}, s.FlushState(blockStart))
+}
+
 func TestShardFlushSeriesFlushSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -312,6 +394,179 @@ func TestShardFlushSeriesFlushSuccess(t *testing.T) {
 	}, flushState)
 }
 
+// TestShardFlushDoesNotBlockOtherShards verifies that flush state and locking
+// are per shard, so reads and writes to a shard proceed while another shard
+// is in the middle of a flush. Run with the race detector.
+func TestShardFlushDoesNotBlockOtherShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		opts       = testDatabaseOptions()
+		blockStart = time.Unix(21600, 0)
+		flushing   = make(chan struct{})
+		release    = make(chan struct{})
+	)
+
+	flushShard := testDatabaseShard(t, opts)
+	defer flushShard.Close()
+	flushShard.bootstrapState = Bootstrapped
+
+	otherShard := testDatabaseShard(t, opts)
+	defer otherShard.Close()
+	otherShard.bootstrapState = Bootstrapped
+
+	flush := persist.NewMockDataFlush(ctrl)
+	flush.EXPECT().PrepareData(gomock.Any()).Return(persist.PreparedDataPersist{
+		Persist: func(ident.ID, ident.Tags, ts.Segment, uint32) error { return nil },
+		Close:   func() error { return nil },
+	}, nil)
+
+	// Block the flush of the first shard part way through
+	curr := series.NewMockDatabaseSeries(ctrl)
+	curr.EXPECT().ID().Return(ident.StringID("foo")).AnyTimes()
+	curr.EXPECT().IsEmpty().Return(false).AnyTimes()
+	curr.EXPECT().
+		Flush(gomock.Any(), blockStart, gomock.Any()).
+		Do(func(context.Context, time.Time, persist.DataFn) {
+			close(flushing)
+			<-release
+		}).
+		Return(series.FlushOutcomeFlushedToDisk, nil)
+	flushShard.list.PushBack(lookup.NewEntry(curr, 0))
+
+	var (
+		wg       sync.WaitGroup
+		flushErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		flushErr = flushShard.Flush(blockStart, flush)
+	}()
+	<-flushing
+
+	// Reads and writes to the other shard proceed while the flush is blocked
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	now := time.Now()
+	id := ident.StringID("bar")
+	require.NoError(t, otherShard.Write(ctx, id, now, 1.0, xtime.Second, nil))
+	results, err := otherShard.ReadEncoded(ctx, id, now.Add(-time.Minute), now.Add(time.Minute))
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+
+	// The flush state of the flushing shard can be read during the flush
+	require.Equal(t, fileOpInProgress, flushShard.FlushState(blockStart).Status)
+
+	close(release)
+	wg.Wait()
+	require.NoError(t, flushErr)
+	require.Equal(t, fileOpSuccess, flushShard.FlushState(blockStart).Status)
+}
+
+func TestShardWriteDuringFlushIsRejected(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := testDatabaseShard(t, testDatabaseOptions())
+	defer s.Close()
+	s.bootstrapState = Bootstrapped
+	s.newSeriesBootstrapped = true
+
+	// Blocks are only flushed once they are further in the past than the
+	// buffer past allows writes for
+	var (
+		blockSize  = s.namespace.Options().RetentionOptions().BlockSize()
+		blockStart = time.Now().Truncate(blockSize).Add(-2 * blockSize)
+		flushing   = make(chan struct{})
+		release    = make(chan struct{})
+	)
+
+	flush := persist.NewMockDataFlush(ctrl)
+	flush.EXPECT().PrepareData(gomock.Any()).Return(persist.PreparedDataPersist{
+		Persist: func(ident.ID, ident.Tags, ts.Segment, uint32) error { return nil },
+		Close:   func() error { return nil },
+	}, nil)
+
+	// Block the flush part way through
+	curr := series.NewMockDatabaseSeries(ctrl)
+	curr.EXPECT().ID().Return(ident.StringID("foo")).AnyTimes()
+	curr.EXPECT().IsEmpty().Return(false).AnyTimes()
+	curr.EXPECT().
+		Flush(gomock.Any(), blockStart, gomock.Any()).
+		Do(func(context.Context, time.Time, persist.DataFn) {
+			close(flushing)
+			<-release
+		}).
+		Return(series.FlushOutcomeFlushedToDisk, nil)
+	s.list.PushBack(lookup.NewEntry(curr, 0))
+
+	var (
+		wg       sync.WaitGroup
+		flushErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		flushErr = s.Flush(blockStart, flush)
+	}()
+	<-flushing
+
+	// A write to the block being flushed is rejected rather than silently
+	// left out of the flushed fileset
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	err := s.Write(ctx, ident.StringID("bar"), blockStart.Add(time.Second),
+		1.0, xtime.Second, nil)
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+
+	close(release)
+	wg.Wait()
+	require.NoError(t, flushErr)
+	require.Equal(t, fileOpState{Status: fileOpSuccess}, s.FlushState(blockStart))
+}
+
+func TestShardFlushDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	blockStart := time.Unix(21600, 0)
+
+	s := testDatabaseShard(t, testDatabaseOptions().SetFlushDryRun(true))
+	defer s.Close()
+	s.bootstrapState = Bootstrapped
+	s.flushState.statesByTime[xtime.ToUnixNano(blockStart)] = fileOpState{
+		Status:      fileOpFailed,
+		NumFailures: 1,
+	}
+
+	// No expectations are set on the flush or the series so the test will
+	// fail if the shard attempts to persist anything
+	flush := persist.NewMockDataFlush(ctrl)
+	curr := series.NewMockDatabaseSeries(ctrl)
+	curr.EXPECT().ID().Return(ident.StringID("foo")).AnyTimes()
+	curr.EXPECT().IsEmpty().Return(false).AnyTimes()
+	s.list.PushBack(lookup.NewEntry(curr, 0))
+
+	require.NoError(t, s.Flush(blockStart, flush))
+	require.NoError(t, s.Flush(blockStart, flush))
+	require.Equal(t, 2, s.numFlushDryRuns(blockStart))
+
+	// The flush state is untouched so that the block is neither cleaned up
+	// nor read from disk as though it was flushed
+	flushState := s.FlushState(blockStart)
+	require.Equal(t, fileOpState{
+		Status:      fileOpFailed,
+		NumFailures: 1,
+	}, flushState)
+	require.True(t, flushState.NeedsFlush())
+	require.False(t, s.IsBlockRetrievable(blockStart))
+}
+
 func TestShardSnapshotShardNotBootstrapped(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -868,13 +1123,16 @@ func TestShardCleanupExpiredFileSets(t *testing.T) {
 	shard.filesetBeforeFn = func(_ string, namespace ident.ID, shardID uint32, t time.Time) ([]string, error) {
 		return []string{namespace.String(), strconv.Itoa(int(shardID))}, nil
 	}
+	shard.filesetsSupersededFn = func(_ string, namespace ident.ID, shardID uint32) ([]string, error) {
+		return []string{"superseded"}, nil
+	}
 	var deletedFiles []string
 	shard.deleteFilesFn = func(files []string) error {
 		deletedFiles = append(deletedFiles, files...)
 		return nil
 	}
 	require.NoError(t, shard.CleanupExpiredFileSets(time.Now()))
-	require.Equal(t, []string{defaultTestNs1ID.String(), "0"}, deletedFiles)
+	require.Equal(t, []string{defaultTestNs1ID.String(), "0", "superseded"}, deletedFiles)
 }
 
 func TestShardCleanupSnapshot(t *testing.T) {
@@ -33,6 +33,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
@@ -160,6 +161,18 @@ func (mr *MockDatabaseMockRecorder) ShardSet() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardSet", reflect.TypeOf((*MockDatabase)(nil).ShardSet))
 }
 
+// Shards mocks base method
+func (m *MockDatabase) Shards() []uint32 {
+	ret := m.ctrl.Call(m, "Shards")
+	ret0, _ := ret[0].([]uint32)
+	return ret0
+}
+
+// Shards indicates an expected call of Shards
+func (mr *MockDatabaseMockRecorder) Shards() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shards", reflect.TypeOf((*MockDatabase)(nil).Shards))
+}
+
 // Terminate mocks base method
 func (m *MockDatabase) Terminate() error {
 	ret := m.ctrl.Call(m, "Terminate")
@@ -196,6 +209,18 @@ func (mr *MockDatabaseMockRecorder) WriteTagged(ctx, namespace, id, tags, timest
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTagged", reflect.TypeOf((*MockDatabase)(nil).WriteTagged), ctx, namespace, id, tags, timestamp, value, unit, annotation)
 }
 
+// WriteBatch mocks base method
+func (m *MockDatabase) WriteBatch(ctx context.Context, namespace ident.ID, points []DataPoint) BatchError {
+	ret := m.ctrl.Call(m, "WriteBatch", ctx, namespace, points)
+	ret0, _ := ret[0].(BatchError)
+	return ret0
+}
+
+// WriteBatch indicates an expected call of WriteBatch
+func (mr *MockDatabaseMockRecorder) WriteBatch(ctx, namespace, points interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteBatch", reflect.TypeOf((*MockDatabase)(nil).WriteBatch), ctx, namespace, points)
+}
+
 // QueryIDs mocks base method
 func (m *MockDatabase) QueryIDs(ctx context.Context, namespace ident.ID, query index.Query, opts index.QueryOptions) (index.QueryResults, error) {
 	ret := m.ctrl.Call(m, "QueryIDs", ctx, namespace, query, opts)
@@ -311,6 +336,55 @@ func (mr *MockDatabaseMockRecorder) Repair() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Repair", reflect.TypeOf((*MockDatabase)(nil).Repair))
 }
 
+// Snapshot mocks base method
+func (m *MockDatabase) Snapshot(snapshotTime time.Time) error {
+	ret := m.ctrl.Call(m, "Snapshot", snapshotTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Snapshot indicates an expected call of Snapshot
+func (mr *MockDatabaseMockRecorder) Snapshot(snapshotTime interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockDatabase)(nil).Snapshot), snapshotTime)
+}
+
+// FlushNow mocks base method
+func (m *MockDatabase) FlushNow(blockStart time.Time) error {
+	ret := m.ctrl.Call(m, "FlushNow", blockStart)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushNow indicates an expected call of FlushNow
+func (mr *MockDatabaseMockRecorder) FlushNow(blockStart interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushNow", reflect.TypeOf((*MockDatabase)(nil).FlushNow), blockStart)
+}
+
+// Tick mocks base method
+func (m *MockDatabase) Tick() (TickResult, error) {
+	ret := m.ctrl.Call(m, "Tick")
+	ret0, _ := ret[0].(TickResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tick indicates an expected call of Tick
+func (mr *MockDatabaseMockRecorder) Tick() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tick", reflect.TypeOf((*MockDatabase)(nil).Tick))
+}
+
+// SetRetentionPeriod mocks base method
+func (m *MockDatabase) SetRetentionPeriod(namespace ident.ID, value time.Duration) error {
+	ret := m.ctrl.Call(m, "SetRetentionPeriod", namespace, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRetentionPeriod indicates an expected call of SetRetentionPeriod
+func (mr *MockDatabaseMockRecorder) SetRetentionPeriod(namespace, value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRetentionPeriod", reflect.TypeOf((*MockDatabase)(nil).SetRetentionPeriod), namespace, value)
+}
+
 // Truncate mocks base method
 func (m *MockDatabase) Truncate(namespace ident.ID) (int64, error) {
 	ret := m.ctrl.Call(m, "Truncate", namespace)
@@ -324,6 +398,19 @@ func (mr *MockDatabaseMockRecorder) Truncate(namespace interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Truncate", reflect.TypeOf((*MockDatabase)(nil).Truncate), namespace)
 }
 
+// MissingWithin mocks base method
+func (m *MockDatabase) MissingWithin(namespace ident.ID, bounds time0.Range) (time0.Ranges, error) {
+	ret := m.ctrl.Call(m, "MissingWithin", namespace, bounds)
+	ret0, _ := ret[0].(time0.Ranges)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MissingWithin indicates an expected call of MissingWithin
+func (mr *MockDatabaseMockRecorder) MissingWithin(namespace, bounds interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MissingWithin", reflect.TypeOf((*MockDatabase)(nil).MissingWithin), namespace, bounds)
+}
+
 // BootstrapState mocks base method
 func (m *MockDatabase) BootstrapState() DatabaseBootstrapState {
 	ret := m.ctrl.Call(m, "BootstrapState")
@@ -336,6 +423,18 @@ func (mr *MockDatabaseMockRecorder) BootstrapState() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BootstrapState", reflect.TypeOf((*MockDatabase)(nil).BootstrapState))
 }
 
+// Stats mocks base method
+func (m *MockDatabase) Stats() DatabaseStats {
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(DatabaseStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats
+func (mr *MockDatabaseMockRecorder) Stats() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockDatabase)(nil).Stats))
+}
+
 // Mockdatabase is a mock of database interface
 type Mockdatabase struct {
 	ctrl     *gomock.Controller
@@ -442,6 +541,18 @@ func (mr *MockdatabaseMockRecorder) ShardSet() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardSet", reflect.TypeOf((*Mockdatabase)(nil).ShardSet))
 }
 
+// Shards mocks base method
+func (m *Mockdatabase) Shards() []uint32 {
+	ret := m.ctrl.Call(m, "Shards")
+	ret0, _ := ret[0].([]uint32)
+	return ret0
+}
+
+// Shards indicates an expected call of Shards
+func (mr *MockdatabaseMockRecorder) Shards() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shards", reflect.TypeOf((*Mockdatabase)(nil).Shards))
+}
+
 // Terminate mocks base method
 func (m *Mockdatabase) Terminate() error {
 	ret := m.ctrl.Call(m, "Terminate")
@@ -478,6 +589,18 @@ func (mr *MockdatabaseMockRecorder) WriteTagged(ctx, namespace, id, tags, timest
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTagged", reflect.TypeOf((*Mockdatabase)(nil).WriteTagged), ctx, namespace, id, tags, timestamp, value, unit, annotation)
 }
 
+// WriteBatch mocks base method
+func (m *Mockdatabase) WriteBatch(ctx context.Context, namespace ident.ID, points []DataPoint) BatchError {
+	ret := m.ctrl.Call(m, "WriteBatch", ctx, namespace, points)
+	ret0, _ := ret[0].(BatchError)
+	return ret0
+}
+
+// WriteBatch indicates an expected call of WriteBatch
+func (mr *MockdatabaseMockRecorder) WriteBatch(ctx, namespace, points interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteBatch", reflect.TypeOf((*Mockdatabase)(nil).WriteBatch), ctx, namespace, points)
+}
+
 // QueryIDs mocks base method
 func (m *Mockdatabase) QueryIDs(ctx context.Context, namespace ident.ID, query index.Query, opts index.QueryOptions) (index.QueryResults, error) {
 	ret := m.ctrl.Call(m, "QueryIDs", ctx, namespace, query, opts)
@@ -593,6 +716,55 @@ func (mr *MockdatabaseMockRecorder) Repair() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Repair", reflect.TypeOf((*Mockdatabase)(nil).Repair))
 }
 
+// Snapshot mocks base method
+func (m *Mockdatabase) Snapshot(snapshotTime time.Time) error {
+	ret := m.ctrl.Call(m, "Snapshot", snapshotTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Snapshot indicates an expected call of Snapshot
+func (mr *MockdatabaseMockRecorder) Snapshot(snapshotTime interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*Mockdatabase)(nil).Snapshot), snapshotTime)
+}
+
+// FlushNow mocks base method
+func (m *Mockdatabase) FlushNow(blockStart time.Time) error {
+	ret := m.ctrl.Call(m, "FlushNow", blockStart)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushNow indicates an expected call of FlushNow
+func (mr *MockdatabaseMockRecorder) FlushNow(blockStart interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushNow", reflect.TypeOf((*Mockdatabase)(nil).FlushNow), blockStart)
+}
+
+// Tick mocks base method
+func (m *Mockdatabase) Tick() (TickResult, error) {
+	ret := m.ctrl.Call(m, "Tick")
+	ret0, _ := ret[0].(TickResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tick indicates an expected call of Tick
+func (mr *MockdatabaseMockRecorder) Tick() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tick", reflect.TypeOf((*Mockdatabase)(nil).Tick))
+}
+
+// SetRetentionPeriod mocks base method
+func (m *Mockdatabase) SetRetentionPeriod(namespace ident.ID, value time.Duration) error {
+	ret := m.ctrl.Call(m, "SetRetentionPeriod", namespace, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRetentionPeriod indicates an expected call of SetRetentionPeriod
+func (mr *MockdatabaseMockRecorder) SetRetentionPeriod(namespace, value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRetentionPeriod", reflect.TypeOf((*Mockdatabase)(nil).SetRetentionPeriod), namespace, value)
+}
+
 // Truncate mocks base method
 func (m *Mockdatabase) Truncate(namespace ident.ID) (int64, error) {
 	ret := m.ctrl.Call(m, "Truncate", namespace)
@@ -606,6 +778,19 @@ func (mr *MockdatabaseMockRecorder) Truncate(namespace interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Truncate", reflect.TypeOf((*Mockdatabase)(nil).Truncate), namespace)
 }
 
+// MissingWithin mocks base method
+func (m *Mockdatabase) MissingWithin(namespace ident.ID, bounds time0.Range) (time0.Ranges, error) {
+	ret := m.ctrl.Call(m, "MissingWithin", namespace, bounds)
+	ret0, _ := ret[0].(time0.Ranges)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MissingWithin indicates an expected call of MissingWithin
+func (mr *MockdatabaseMockRecorder) MissingWithin(namespace, bounds interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MissingWithin", reflect.TypeOf((*Mockdatabase)(nil).MissingWithin), namespace, bounds)
+}
+
 // BootstrapState mocks base method
 func (m *Mockdatabase) BootstrapState() DatabaseBootstrapState {
 	ret := m.ctrl.Call(m, "BootstrapState")
@@ -618,6 +803,18 @@ func (mr *MockdatabaseMockRecorder) BootstrapState() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BootstrapState", reflect.TypeOf((*Mockdatabase)(nil).BootstrapState))
 }
 
+// Stats mocks base method
+func (m *Mockdatabase) Stats() DatabaseStats {
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(DatabaseStats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats
+func (mr *MockdatabaseMockRecorder) Stats() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*Mockdatabase)(nil).Stats))
+}
+
 // GetOwnedNamespaces mocks base method
 func (m *Mockdatabase) GetOwnedNamespaces() ([]databaseNamespace, error) {
 	ret := m.ctrl.Call(m, "GetOwnedNamespaces")
@@ -832,6 +1029,18 @@ func (mr *MockdatabaseNamespaceMockRecorder) GetIndex() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIndex", reflect.TypeOf((*MockdatabaseNamespace)(nil).GetIndex))
 }
 
+// SetRetentionPeriod mocks base method
+func (m *MockdatabaseNamespace) SetRetentionPeriod(value time.Duration) error {
+	ret := m.ctrl.Call(m, "SetRetentionPeriod", value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRetentionPeriod indicates an expected call of SetRetentionPeriod
+func (mr *MockdatabaseNamespaceMockRecorder) SetRetentionPeriod(value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRetentionPeriod", reflect.TypeOf((*MockdatabaseNamespace)(nil).SetRetentionPeriod), value)
+}
+
 // Tick mocks base method
 func (m *MockdatabaseNamespace) Tick(c context.Cancellable, tickStart time.Time) error {
 	ret := m.ctrl.Call(m, "Tick", c, tickStart)
@@ -868,6 +1077,18 @@ func (mr *MockdatabaseNamespaceMockRecorder) WriteTagged(ctx, id, tags, timestam
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTagged", reflect.TypeOf((*MockdatabaseNamespace)(nil).WriteTagged), ctx, id, tags, timestamp, value, unit, annotation)
 }
 
+// WriteBatch mocks base method
+func (m *MockdatabaseNamespace) WriteBatch(ctx context.Context, points []DataPoint) BatchError {
+	ret := m.ctrl.Call(m, "WriteBatch", ctx, points)
+	ret0, _ := ret[0].(BatchError)
+	return ret0
+}
+
+// WriteBatch indicates an expected call of WriteBatch
+func (mr *MockdatabaseNamespaceMockRecorder) WriteBatch(ctx, points interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteBatch", reflect.TypeOf((*MockdatabaseNamespace)(nil).WriteBatch), ctx, points)
+}
+
 // QueryIDs mocks base method
 func (m *MockdatabaseNamespace) QueryIDs(ctx context.Context, query index.Query, opts index.QueryOptions) (index.QueryResults, error) {
 	ret := m.ctrl.Call(m, "QueryIDs", ctx, query, opts)
@@ -948,10 +1169,11 @@ func (mr *MockdatabaseNamespaceMockRecorder) Bootstrap(start, process interface{
 }
 
 // Flush mocks base method
-func (m *MockdatabaseNamespace) Flush(blockStart time.Time, ShardBootstrapStates ShardBootstrapStates, flush persist.DataFlush) error {
+func (m *MockdatabaseNamespace) Flush(blockStart time.Time, ShardBootstrapStates ShardBootstrapStates, flush persist.DataFlush) (namespaceFlushResult, error) {
 	ret := m.ctrl.Call(m, "Flush", blockStart, ShardBootstrapStates, flush)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(namespaceFlushResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Flush indicates an expected call of Flush
@@ -959,6 +1181,18 @@ func (mr *MockdatabaseNamespaceMockRecorder) Flush(blockStart, ShardBootstrapSta
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockdatabaseNamespace)(nil).Flush), blockStart, ShardBootstrapStates, flush)
 }
 
+// FlushNow mocks base method
+func (m *MockdatabaseNamespace) FlushNow(blockStart time.Time, flush persist.DataFlush) error {
+	ret := m.ctrl.Call(m, "FlushNow", blockStart, flush)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushNow indicates an expected call of FlushNow
+func (mr *MockdatabaseNamespaceMockRecorder) FlushNow(blockStart, flush interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushNow", reflect.TypeOf((*MockdatabaseNamespace)(nil).FlushNow), blockStart, flush)
+}
+
 // FlushIndex mocks base method
 func (m *MockdatabaseNamespace) FlushIndex(flush persist.IndexFlush) error {
 	ret := m.ctrl.Call(m, "FlushIndex", flush)
@@ -995,6 +1229,42 @@ func (mr *MockdatabaseNamespaceMockRecorder) NeedsFlush(alignedInclusiveStart, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NeedsFlush", reflect.TypeOf((*MockdatabaseNamespace)(nil).NeedsFlush), alignedInclusiveStart, alignedInclusiveEnd)
 }
 
+// NeedsFlushAttempt mocks base method
+func (m *MockdatabaseNamespace) NeedsFlushAttempt(alignedInclusiveStart time.Time, alignedInclusiveEnd time.Time) bool {
+	ret := m.ctrl.Call(m, "NeedsFlushAttempt", alignedInclusiveStart, alignedInclusiveEnd)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// NeedsFlushAttempt indicates an expected call of NeedsFlushAttempt
+func (mr *MockdatabaseNamespaceMockRecorder) NeedsFlushAttempt(alignedInclusiveStart, alignedInclusiveEnd interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NeedsFlushAttempt", reflect.TypeOf((*MockdatabaseNamespace)(nil).NeedsFlushAttempt), alignedInclusiveStart, alignedInclusiveEnd)
+}
+
+// MissingWithin mocks base method
+func (m *MockdatabaseNamespace) MissingWithin(bounds time0.Range) time0.Ranges {
+	ret := m.ctrl.Call(m, "MissingWithin", bounds)
+	ret0, _ := ret[0].(time0.Ranges)
+	return ret0
+}
+
+// MissingWithin indicates an expected call of MissingWithin
+func (mr *MockdatabaseNamespaceMockRecorder) MissingWithin(bounds interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MissingWithin", reflect.TypeOf((*MockdatabaseNamespace)(nil).MissingWithin), bounds)
+}
+
+// HasData mocks base method
+func (m *MockdatabaseNamespace) HasData(blockStart time.Time) bool {
+	ret := m.ctrl.Call(m, "HasData", blockStart)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasData indicates an expected call of HasData
+func (mr *MockdatabaseNamespaceMockRecorder) HasData(blockStart interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasData", reflect.TypeOf((*MockdatabaseNamespace)(nil).HasData), blockStart)
+}
+
 // IsCapturedBySnapshot mocks base method
 func (m *MockdatabaseNamespace) IsCapturedBySnapshot(alignedInclusiveStart, alignedInclusiveEnd, t time.Time) (bool, error) {
 	ret := m.ctrl.Call(m, "IsCapturedBySnapshot", alignedInclusiveStart, alignedInclusiveEnd, t)
@@ -1197,6 +1467,18 @@ func (mr *MockdatabaseShardMockRecorder) OnEvictedFromWiredList(id, blockStart i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnEvictedFromWiredList", reflect.TypeOf((*MockdatabaseShard)(nil).OnEvictedFromWiredList), id, blockStart)
 }
 
+// NumBufferedDatapoints mocks base method
+func (m *MockdatabaseShard) NumBufferedDatapoints() int64 {
+	ret := m.ctrl.Call(m, "NumBufferedDatapoints")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// NumBufferedDatapoints indicates an expected call of NumBufferedDatapoints
+func (mr *MockdatabaseShardMockRecorder) NumBufferedDatapoints() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NumBufferedDatapoints", reflect.TypeOf((*MockdatabaseShard)(nil).NumBufferedDatapoints))
+}
+
 // Close mocks base method
 func (m *MockdatabaseShard) Close() error {
 	ret := m.ctrl.Call(m, "Close")
@@ -1222,6 +1504,28 @@ func (mr *MockdatabaseShardMockRecorder) Tick(c, tickStart interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tick", reflect.TypeOf((*MockdatabaseShard)(nil).Tick), c, tickStart)
 }
 
+// ReleaseIdleBuffers mocks base method
+func (m *MockdatabaseShard) ReleaseIdleBuffers(now time.Time) int {
+	ret := m.ctrl.Call(m, "ReleaseIdleBuffers", now)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ReleaseIdleBuffers indicates an expected call of ReleaseIdleBuffers
+func (mr *MockdatabaseShardMockRecorder) ReleaseIdleBuffers(now interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseIdleBuffers", reflect.TypeOf((*MockdatabaseShard)(nil).ReleaseIdleBuffers), now)
+}
+
+// SetRetentionOptions mocks base method
+func (m *MockdatabaseShard) SetRetentionOptions(value retention.Options) {
+	m.ctrl.Call(m, "SetRetentionOptions", value)
+}
+
+// SetRetentionOptions indicates an expected call of SetRetentionOptions
+func (mr *MockdatabaseShardMockRecorder) SetRetentionOptions(value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRetentionOptions", reflect.TypeOf((*MockdatabaseShard)(nil).SetRetentionOptions), value)
+}
+
 // Write mocks base method
 func (m *MockdatabaseShard) Write(ctx context.Context, id ident.ID, timestamp time.Time, value float64, unit time0.Unit, annotation []byte) error {
 	ret := m.ctrl.Call(m, "Write", ctx, id, timestamp, value, unit, annotation)
@@ -1246,6 +1550,18 @@ func (mr *MockdatabaseShardMockRecorder) WriteTagged(ctx, id, tags, timestamp, v
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTagged", reflect.TypeOf((*MockdatabaseShard)(nil).WriteTagged), ctx, id, tags, timestamp, value, unit, annotation)
 }
 
+// WriteBatch mocks base method
+func (m *MockdatabaseShard) WriteBatch(ctx context.Context, points []DataPoint) BatchError {
+	ret := m.ctrl.Call(m, "WriteBatch", ctx, points)
+	ret0, _ := ret[0].(BatchError)
+	return ret0
+}
+
+// WriteBatch indicates an expected call of WriteBatch
+func (mr *MockdatabaseShardMockRecorder) WriteBatch(ctx, points interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteBatch", reflect.TypeOf((*MockdatabaseShard)(nil).WriteBatch), ctx, points)
+}
+
 // ReadEncoded mocks base method
 func (m *MockdatabaseShard) ReadEncoded(ctx context.Context, id ident.ID, start, end time.Time) ([][]xio.BlockReader, error) {
 	ret := m.ctrl.Call(m, "ReadEncoded", ctx, id, start, end)
@@ -1348,6 +1664,18 @@ func (mr *MockdatabaseShardMockRecorder) FlushState(blockStart interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushState", reflect.TypeOf((*MockdatabaseShard)(nil).FlushState), blockStart)
 }
 
+// HasData mocks base method
+func (m *MockdatabaseShard) HasData(blockStart time.Time) bool {
+	ret := m.ctrl.Call(m, "HasData", blockStart)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasData indicates an expected call of HasData
+func (mr *MockdatabaseShardMockRecorder) HasData(blockStart interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasData", reflect.TypeOf((*MockdatabaseShard)(nil).HasData), blockStart)
+}
+
 // SnapshotState mocks base method
 func (m *MockdatabaseShard) SnapshotState() (bool, time.Time) {
 	ret := m.ctrl.Call(m, "SnapshotState")
@@ -1660,10 +1988,11 @@ func (m *MockdatabaseFlushManager) EXPECT() *MockdatabaseFlushManagerMockRecorde
 }
 
 // Flush mocks base method
-func (m *MockdatabaseFlushManager) Flush(tickStart time.Time, dbBootstrapStateAtTickStart DatabaseBootstrapState) error {
+func (m *MockdatabaseFlushManager) Flush(tickStart time.Time, dbBootstrapStateAtTickStart DatabaseBootstrapState) (flushResult, error) {
 	ret := m.ctrl.Call(m, "Flush", tickStart, dbBootstrapStateAtTickStart)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(flushResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Flush indicates an expected call of Flush
@@ -1671,6 +2000,30 @@ func (mr *MockdatabaseFlushManagerMockRecorder) Flush(tickStart, dbBootstrapStat
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockdatabaseFlushManager)(nil).Flush), tickStart, dbBootstrapStateAtTickStart)
 }
 
+// Snapshot mocks base method
+func (m *MockdatabaseFlushManager) Snapshot(snapshotTime time.Time) error {
+	ret := m.ctrl.Call(m, "Snapshot", snapshotTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Snapshot indicates an expected call of Snapshot
+func (mr *MockdatabaseFlushManagerMockRecorder) Snapshot(snapshotTime interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockdatabaseFlushManager)(nil).Snapshot), snapshotTime)
+}
+
+// FlushNow mocks base method
+func (m *MockdatabaseFlushManager) FlushNow(blockStart time.Time) error {
+	ret := m.ctrl.Call(m, "FlushNow", blockStart)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushNow indicates an expected call of FlushNow
+func (mr *MockdatabaseFlushManagerMockRecorder) FlushNow(blockStart interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushNow", reflect.TypeOf((*MockdatabaseFlushManager)(nil).FlushNow), blockStart)
+}
+
 // Report mocks base method
 func (m *MockdatabaseFlushManager) Report() {
 	m.ctrl.Call(m, "Report")
@@ -1762,10 +2115,11 @@ func (mr *MockdatabaseFileSystemManagerMockRecorder) Cleanup(t interface{}) *gom
 }
 
 // Flush mocks base method
-func (m *MockdatabaseFileSystemManager) Flush(t time.Time, dbBootstrapStateAtTickStart DatabaseBootstrapState) error {
+func (m *MockdatabaseFileSystemManager) Flush(t time.Time, dbBootstrapStateAtTickStart DatabaseBootstrapState) (flushResult, error) {
 	ret := m.ctrl.Call(m, "Flush", t, dbBootstrapStateAtTickStart)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(flushResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Flush indicates an expected call of Flush
@@ -1773,6 +2127,42 @@ func (mr *MockdatabaseFileSystemManagerMockRecorder) Flush(t, dbBootstrapStateAt
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockdatabaseFileSystemManager)(nil).Flush), t, dbBootstrapStateAtTickStart)
 }
 
+// Snapshot mocks base method
+func (m *MockdatabaseFileSystemManager) Snapshot(snapshotTime time.Time) error {
+	ret := m.ctrl.Call(m, "Snapshot", snapshotTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Snapshot indicates an expected call of Snapshot
+func (mr *MockdatabaseFileSystemManagerMockRecorder) Snapshot(snapshotTime interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockdatabaseFileSystemManager)(nil).Snapshot), snapshotTime)
+}
+
+// FlushNow mocks base method
+func (m *MockdatabaseFileSystemManager) FlushNow(blockStart time.Time) error {
+	ret := m.ctrl.Call(m, "FlushNow", blockStart)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushNow indicates an expected call of FlushNow
+func (mr *MockdatabaseFileSystemManagerMockRecorder) FlushNow(blockStart interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushNow", reflect.TypeOf((*MockdatabaseFileSystemManager)(nil).FlushNow), blockStart)
+}
+
+// CleanupNow mocks base method
+func (m *MockdatabaseFileSystemManager) CleanupNow(t time.Time) error {
+	ret := m.ctrl.Call(m, "CleanupNow", t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CleanupNow indicates an expected call of CleanupNow
+func (mr *MockdatabaseFileSystemManagerMockRecorder) CleanupNow(t interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanupNow", reflect.TypeOf((*MockdatabaseFileSystemManager)(nil).CleanupNow), t)
+}
+
 // Disable mocks base method
 func (m *MockdatabaseFileSystemManager) Disable() fileOpStatus {
 	ret := m.ctrl.Call(m, "Disable")
@@ -1810,10 +2200,11 @@ func (mr *MockdatabaseFileSystemManagerMockRecorder) Status() *gomock.Call {
 }
 
 // Run mocks base method
-func (m *MockdatabaseFileSystemManager) Run(t time.Time, dbBootstrapStateAtTickStart DatabaseBootstrapState, runType runType, forceType forceType) bool {
+func (m *MockdatabaseFileSystemManager) Run(t time.Time, dbBootstrapStateAtTickStart DatabaseBootstrapState, runType runType, forceType forceType) (flushResult, bool) {
 	ret := m.ctrl.Call(m, "Run", t, dbBootstrapStateAtTickStart, runType, forceType)
-	ret0, _ := ret[0].(bool)
-	return ret0
+	ret0, _ := ret[0].(flushResult)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
 }
 
 // Run indicates an expected call of Run
@@ -2059,10 +2450,11 @@ func (mr *MockdatabaseMediatorMockRecorder) EnableFileOps() *gomock.Call {
 }
 
 // Tick mocks base method
-func (m *MockdatabaseMediator) Tick(runType runType, forceType forceType) error {
+func (m *MockdatabaseMediator) Tick(runType runType, forceType forceType) (TickResult, error) {
 	ret := m.ctrl.Call(m, "Tick", runType, forceType)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(TickResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Tick indicates an expected call of Tick
@@ -2082,6 +2474,42 @@ func (mr *MockdatabaseMediatorMockRecorder) Repair() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Repair", reflect.TypeOf((*MockdatabaseMediator)(nil).Repair))
 }
 
+// Snapshot mocks base method
+func (m *MockdatabaseMediator) Snapshot(snapshotTime time.Time) error {
+	ret := m.ctrl.Call(m, "Snapshot", snapshotTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Snapshot indicates an expected call of Snapshot
+func (mr *MockdatabaseMediatorMockRecorder) Snapshot(snapshotTime interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockdatabaseMediator)(nil).Snapshot), snapshotTime)
+}
+
+// FlushNow mocks base method
+func (m *MockdatabaseMediator) FlushNow(blockStart time.Time) error {
+	ret := m.ctrl.Call(m, "FlushNow", blockStart)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushNow indicates an expected call of FlushNow
+func (mr *MockdatabaseMediatorMockRecorder) FlushNow(blockStart interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushNow", reflect.TypeOf((*MockdatabaseMediator)(nil).FlushNow), blockStart)
+}
+
+// CleanupNow mocks base method
+func (m *MockdatabaseMediator) CleanupNow(t time.Time) error {
+	ret := m.ctrl.Call(m, "CleanupNow", t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CleanupNow indicates an expected call of CleanupNow
+func (mr *MockdatabaseMediatorMockRecorder) CleanupNow(t interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanupNow", reflect.TypeOf((*MockdatabaseMediator)(nil).CleanupNow), t)
+}
+
 // Close mocks base method
 func (m *MockdatabaseMediator) Close() error {
 	ret := m.ctrl.Call(m, "Close")
@@ -2905,3 +3333,219 @@ func (m *MockOptions) QueryIDsWorkerPool() sync0.WorkerPool {
 func (mr *MockOptionsMockRecorder) QueryIDsWorkerPool() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryIDsWorkerPool", reflect.TypeOf((*MockOptions)(nil).QueryIDsWorkerPool))
 }
+
+// SetFlushJitter mocks base method
+func (m *MockOptions) SetFlushJitter(value time.Duration) Options {
+	ret := m.ctrl.Call(m, "SetFlushJitter", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetFlushJitter indicates an expected call of SetFlushJitter
+func (mr *MockOptionsMockRecorder) SetFlushJitter(value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFlushJitter", reflect.TypeOf((*MockOptions)(nil).SetFlushJitter), value)
+}
+
+// FlushJitter mocks base method
+func (m *MockOptions) FlushJitter() time.Duration {
+	ret := m.ctrl.Call(m, "FlushJitter")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// FlushJitter indicates an expected call of FlushJitter
+func (mr *MockOptionsMockRecorder) FlushJitter() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushJitter", reflect.TypeOf((*MockOptions)(nil).FlushJitter))
+}
+
+// SetFlushDryRun mocks base method
+func (m *MockOptions) SetFlushDryRun(value bool) Options {
+	ret := m.ctrl.Call(m, "SetFlushDryRun", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetFlushDryRun indicates an expected call of SetFlushDryRun
+func (mr *MockOptionsMockRecorder) SetFlushDryRun(value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFlushDryRun", reflect.TypeOf((*MockOptions)(nil).SetFlushDryRun), value)
+}
+
+// FlushDryRun mocks base method
+func (m *MockOptions) FlushDryRun() bool {
+	ret := m.ctrl.Call(m, "FlushDryRun")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// FlushDryRun indicates an expected call of FlushDryRun
+func (mr *MockOptionsMockRecorder) FlushDryRun() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushDryRun", reflect.TypeOf((*MockOptions)(nil).FlushDryRun))
+}
+
+// SetFlushNowTimeout mocks base method
+func (m *MockOptions) SetFlushNowTimeout(value time.Duration) Options {
+	ret := m.ctrl.Call(m, "SetFlushNowTimeout", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetFlushNowTimeout indicates an expected call of SetFlushNowTimeout
+func (mr *MockOptionsMockRecorder) SetFlushNowTimeout(value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFlushNowTimeout", reflect.TypeOf((*MockOptions)(nil).SetFlushNowTimeout), value)
+}
+
+// FlushNowTimeout mocks base method
+func (m *MockOptions) FlushNowTimeout() time.Duration {
+	ret := m.ctrl.Call(m, "FlushNowTimeout")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// FlushNowTimeout indicates an expected call of FlushNowTimeout
+func (mr *MockOptionsMockRecorder) FlushNowTimeout() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushNowTimeout", reflect.TypeOf((*MockOptions)(nil).FlushNowTimeout))
+}
+
+// SetShardFlushTimingFn mocks base method
+func (m *MockOptions) SetShardFlushTimingFn(value ShardFlushTimingFn) Options {
+	ret := m.ctrl.Call(m, "SetShardFlushTimingFn", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetShardFlushTimingFn indicates an expected call of SetShardFlushTimingFn
+func (mr *MockOptionsMockRecorder) SetShardFlushTimingFn(value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetShardFlushTimingFn", reflect.TypeOf((*MockOptions)(nil).SetShardFlushTimingFn), value)
+}
+
+// ShardFlushTimingFn mocks base method
+func (m *MockOptions) ShardFlushTimingFn() ShardFlushTimingFn {
+	ret := m.ctrl.Call(m, "ShardFlushTimingFn")
+	ret0, _ := ret[0].(ShardFlushTimingFn)
+	return ret0
+}
+
+// ShardFlushTimingFn indicates an expected call of ShardFlushTimingFn
+func (mr *MockOptionsMockRecorder) ShardFlushTimingFn() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardFlushTimingFn", reflect.TypeOf((*MockOptions)(nil).ShardFlushTimingFn))
+}
+
+// SetShardFlushPriorityFn mocks base method
+func (m *MockOptions) SetShardFlushPriorityFn(value ShardFlushPriorityFn) Options {
+	ret := m.ctrl.Call(m, "SetShardFlushPriorityFn", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetShardFlushPriorityFn indicates an expected call of SetShardFlushPriorityFn
+func (mr *MockOptionsMockRecorder) SetShardFlushPriorityFn(value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetShardFlushPriorityFn", reflect.TypeOf((*MockOptions)(nil).SetShardFlushPriorityFn), value)
+}
+
+// ShardFlushPriorityFn mocks base method
+func (m *MockOptions) ShardFlushPriorityFn() ShardFlushPriorityFn {
+	ret := m.ctrl.Call(m, "ShardFlushPriorityFn")
+	ret0, _ := ret[0].(ShardFlushPriorityFn)
+	return ret0
+}
+
+// ShardFlushPriorityFn indicates an expected call of ShardFlushPriorityFn
+func (mr *MockOptionsMockRecorder) ShardFlushPriorityFn() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardFlushPriorityFn", reflect.TypeOf((*MockOptions)(nil).ShardFlushPriorityFn))
+}
+
+// SetFlushErrorRetryableFn mocks base method
+func (m *MockOptions) SetFlushErrorRetryableFn(value FlushErrorRetryableFn) Options {
+	ret := m.ctrl.Call(m, "SetFlushErrorRetryableFn", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetFlushErrorRetryableFn indicates an expected call of SetFlushErrorRetryableFn
+func (mr *MockOptionsMockRecorder) SetFlushErrorRetryableFn(value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFlushErrorRetryableFn", reflect.TypeOf((*MockOptions)(nil).SetFlushErrorRetryableFn), value)
+}
+
+// FlushErrorRetryableFn mocks base method
+func (m *MockOptions) FlushErrorRetryableFn() FlushErrorRetryableFn {
+	ret := m.ctrl.Call(m, "FlushErrorRetryableFn")
+	ret0, _ := ret[0].(FlushErrorRetryableFn)
+	return ret0
+}
+
+// FlushErrorRetryableFn indicates an expected call of FlushErrorRetryableFn
+func (mr *MockOptionsMockRecorder) FlushErrorRetryableFn() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushErrorRetryableFn", reflect.TypeOf((*MockOptions)(nil).FlushErrorRetryableFn))
+}
+
+// SetSkipFlushEmptyBlocks mocks base method
+func (m *MockOptions) SetSkipFlushEmptyBlocks(value bool) Options {
+	ret := m.ctrl.Call(m, "SetSkipFlushEmptyBlocks", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetSkipFlushEmptyBlocks indicates an expected call of SetSkipFlushEmptyBlocks
+func (mr *MockOptionsMockRecorder) SetSkipFlushEmptyBlocks(value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSkipFlushEmptyBlocks", reflect.TypeOf((*MockOptions)(nil).SetSkipFlushEmptyBlocks), value)
+}
+
+// SkipFlushEmptyBlocks mocks base method
+func (m *MockOptions) SkipFlushEmptyBlocks() bool {
+	ret := m.ctrl.Call(m, "SkipFlushEmptyBlocks")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// SkipFlushEmptyBlocks indicates an expected call of SkipFlushEmptyBlocks
+func (mr *MockOptionsMockRecorder) SkipFlushEmptyBlocks() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SkipFlushEmptyBlocks", reflect.TypeOf((*MockOptions)(nil).SkipFlushEmptyBlocks))
+}
+
+// SetFlushRetryBackoff mocks base method
+func (m *MockOptions) SetFlushRetryBackoff(value time.Duration) Options {
+	ret := m.ctrl.Call(m, "SetFlushRetryBackoff", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetFlushRetryBackoff indicates an expected call of SetFlushRetryBackoff
+func (mr *MockOptionsMockRecorder) SetFlushRetryBackoff(value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFlushRetryBackoff", reflect.TypeOf((*MockOptions)(nil).SetFlushRetryBackoff), value)
+}
+
+// FlushRetryBackoff mocks base method
+func (m *MockOptions) FlushRetryBackoff() time.Duration {
+	ret := m.ctrl.Call(m, "FlushRetryBackoff")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// FlushRetryBackoff indicates an expected call of FlushRetryBackoff
+func (mr *MockOptionsMockRecorder) FlushRetryBackoff() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushRetryBackoff", reflect.TypeOf((*MockOptions)(nil).FlushRetryBackoff))
+}
+
+// SetReadOnly mocks base method
+func (m *MockOptions) SetReadOnly(value bool) Options {
+	ret := m.ctrl.Call(m, "SetReadOnly", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetReadOnly indicates an expected call of SetReadOnly
+func (mr *MockOptionsMockRecorder) SetReadOnly(value interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReadOnly", reflect.TypeOf((*MockOptions)(nil).SetReadOnly), value)
+}
+
+// ReadOnly mocks base method
+func (m *MockOptions) ReadOnly() bool {
+	ret := m.ctrl.Call(m, "ReadOnly")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// ReadOnly indicates an expected call of ReadOnly
+func (mr *MockOptionsMockRecorder) ReadOnly() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadOnly", reflect.TypeOf((*MockOptions)(nil).ReadOnly))
+}
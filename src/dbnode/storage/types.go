@@ -29,6 +29,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
@@ -75,6 +76,10 @@ type Database interface {
 	// ShardSet returns the set of shards currently associated with this namespace
 	ShardSet() sharding.ShardSet
 
+	// Shards returns the IDs of the shards currently owned by the database in
+	// ascending order
+	Shards() []uint32
+
 	// Terminate will close the database for writing and reading. Terminate does
 	// NOT release any resources held by owned namespaces, instead relying upon
 	// the GC to do so.
@@ -103,6 +108,16 @@ type Database interface {
 		annotation []byte,
 	) error
 
+	// WriteBatch writes a batch of data points to the database for a
+	// namespace, routing each ID to its owning shard once and handing each
+	// shard its data points in a single call. The returned BatchError holds
+	// an error for each data point that failed to be written.
+	WriteBatch(
+		ctx context.Context,
+		namespace ident.ID,
+		points []DataPoint,
+	) BatchError
+
 	// QueryIDs resolves the given query into known IDs.
 	QueryIDs(
 		ctx context.Context,
@@ -166,13 +181,83 @@ type Database interface {
 	// Repair will issue a repair and return nil on success or error on error.
 	Repair() error
 
+	// Snapshot snapshots the data buffered in memory by every owned shard as
+	// of the snapshot time, regardless of block boundaries, so that a
+	// consistent point in time copy of the data is written to snapshot files.
+	// The snapshot is rejected while a flush or other file operations are in
+	// progress.
+	Snapshot(snapshotTime time.Time) error
+
+	// FlushNow synchronously flushes the given block for every owned shard
+	// of every namespace and returns once the flush has completed or failed,
+	// without waiting for the flush jitter of the shards. Only blocks that can
+	// no longer receive writes are flushed. File operations already in
+	// progress, such as a flush started by a tick, are waited on for up to the
+	// flush now timeout, after which the flush is rejected with an error that
+	// can be retried.
+	FlushNow(blockStart time.Time) error
+
+	// Tick synchronously performs a tick followed by any file operations that
+	// are due and returns the work performed, it returns an error if another
+	// tick is already in progress.
+	Tick() (TickResult, error)
+
+	// SetRetentionPeriod updates the retention period of the given namespace
+	// at runtime without a restart, data files that fall outside a shortened
+	// retention period are cleaned up immediately unless other file operations
+	// are in progress, in which case the next file operations run cleans them up.
+	SetRetentionPeriod(namespace ident.ID, value time.Duration) error
+
 	// Truncate truncates data for the given namespace
 	Truncate(namespace ident.ID) (int64, error)
 
+	// MissingWithin returns the time ranges within bounds, clamped to the
+	// retention period of the given namespace, for which data is not
+	// available. Blocks that can still receive writes are held in memory and
+	// always available, the other blocks are only available once every owned
+	// shard has successfully flushed them to disk.
+	MissingWithin(namespace ident.ID, bounds xtime.Range) (xtime.Ranges, error)
+
 	// BootstrapState captures and returns a snapshot of the databases' bootstrap state.
 	BootstrapState() DatabaseBootstrapState
+
+	// Stats returns the number of series and buffered datapoints held across
+	// all shards of all owned namespaces.
+	Stats() DatabaseStats
+}
+
+// DatabaseStats is a point in time summary of the data held by the database.
+type DatabaseStats struct {
+	// NumSeries is the number of series across all owned shards.
+	NumSeries int64
+
+	// NumBufferedDatapoints is the number of datapoints held in series
+	// buffers that have not yet been drained to blocks.
+	NumBufferedDatapoints int64
+}
+
+// DataPoint is a data point for an ID written as part of a batch.
+type DataPoint struct {
+	ID         ident.ID
+	Timestamp  time.Time
+	Value      float64
+	Unit       xtime.Unit
+	Annotation []byte
+}
+
+// BatchWriteError is the error encountered writing a single data point of
+// a batch, Index is the position of the data point within the batch.
+type BatchWriteError struct {
+	Index int
+	Err   error
 }
 
+// BatchError is the set of errors encountered writing a batch of data
+// points ordered by index, it is empty if every data point was written
+// successfully so callers should check its length rather than comparing
+// it with nil once converted to an error.
+type BatchError []BatchWriteError
+
 // database is the internal database interface
 type database interface {
 	Database
@@ -223,6 +308,10 @@ type databaseNamespace interface {
 	// GetIndex returns the reverse index backing the namespace, if it exists.
 	GetIndex() (namespaceIndex, error)
 
+	// SetRetentionPeriod updates the retention period of the namespace, the
+	// flush and cleanup windows derived from it take effect immediately.
+	SetRetentionPeriod(value time.Duration) error
+
 	// Tick performs any regular maintenance operations
 	Tick(c context.Cancellable, tickStart time.Time) error
 
@@ -247,6 +336,13 @@ type databaseNamespace interface {
 		annotation []byte,
 	) error
 
+	// WriteBatch writes a batch of data points to the namespace, grouping
+	// the data points by owning shard.
+	WriteBatch(
+		ctx context.Context,
+		points []DataPoint,
+	) BatchError
+
 	// QueryIDs resolves the given query into known IDs.
 	QueryIDs(
 		ctx context.Context,
@@ -292,12 +388,17 @@ type databaseNamespace interface {
 	// Bootstrap performs bootstrapping
 	Bootstrap(start time.Time, process bootstrap.Process) error
 
-	// Flush flushes in-memory data
+	// Flush flushes in-memory data, returning how many shards flushed the
+	// block and how many were left to flush it later
 	Flush(
 		blockStart time.Time,
 		ShardBootstrapStates ShardBootstrapStates,
 		flush persist.DataFlush,
-	) error
+	) (namespaceFlushResult, error)
+
+	// FlushNow flushes in-memory data for the given block of every
+	// bootstrapped shard, regardless of the flush jitter.
+	FlushNow(blockStart time.Time, flush persist.DataFlush) error
 
 	// FlushIndex flushes in-memory index data.
 	FlushIndex(
@@ -312,6 +413,23 @@ type databaseNamespace interface {
 	// NB: The start/end times are assumed to be aligned to block size boundary.
 	NeedsFlush(alignedInclusiveStart time.Time, alignedInclusiveEnd time.Time) bool
 
+	// NeedsFlushAttempt returns true if the namespace needs a flush that
+	// should be attempted for the period: [start, end] (both inclusive),
+	// unlike NeedsFlush blocks that permanently failed to flush are excluded.
+	// NB: The start/end times are assumed to be aligned to block size boundary.
+	NeedsFlushAttempt(alignedInclusiveStart time.Time, alignedInclusiveEnd time.Time) bool
+
+	// MissingWithin returns the time ranges within bounds, clamped to the
+	// namespace retention period, for which data is not available. Blocks
+	// that can still receive writes are held in memory and always available,
+	// the other blocks are only available once every owned shard has
+	// successfully flushed them to disk.
+	MissingWithin(bounds xtime.Range) xtime.Ranges
+
+	// HasData returns whether any owned shard holds data for the block
+	// starting at blockStart.
+	HasData(blockStart time.Time) bool
+
 	// IsCapturedBySnapshot accepts a time t (system time, not datapoint timestamp time)
 	// as well as a [start, end] range (inclusive on both sides) and determines if all of
 	// the data for all of its shards in the namespace blocks contained within the range
@@ -355,12 +473,25 @@ type databaseShard interface {
 	// https://github.com/golang/mock/issues/10
 	OnEvictedFromWiredList(id ident.ID, blockStart time.Time)
 
+	// NumBufferedDatapoints returns the number of datapoints held in the
+	// buffers of the series in the shard
+	NumBufferedDatapoints() int64
+
 	// Close will release the shard resources and close the shard
 	Close() error
 
 	// Tick performs any updates to ensure series drain their buffers and blocks are flushed, etc
 	Tick(c context.Cancellable, tickStart time.Time) (tickResult, error)
 
+	// ReleaseIdleBuffers releases the memory held by series buffers for blocks
+	// with no readable data that have not been written to for the buffer drain
+	// duration, returning the number of buffers released
+	ReleaseIdleBuffers(now time.Time) int
+
+	// SetRetentionOptions updates the retention used to decide which blocks
+	// the shard keeps flush state and serves flushed metadata for
+	SetRetentionOptions(value retention.Options)
+
 	Write(
 		ctx context.Context,
 		id ident.ID,
@@ -381,6 +512,13 @@ type databaseShard interface {
 		annotation []byte,
 	) error
 
+	// WriteBatch writes a batch of data points owned by the shard, the
+	// indexes of the returned errors are relative to the given data points.
+	WriteBatch(
+		ctx context.Context,
+		points []DataPoint,
+	) BatchError
+
 	ReadEncoded(
 		ctx context.Context,
 		id ident.ID,
@@ -429,13 +567,18 @@ type databaseShard interface {
 	// FlushState returns the flush state for this shard at block start.
 	FlushState(blockStart time.Time) fileOpState
 
+	// HasData returns whether any series in this shard holds data for the
+	// block starting at blockStart.
+	HasData(blockStart time.Time) bool
+
 	// SnapshotState returns the snapshot state for this shard.
 	SnapshotState() (isSnapshotting bool, lastSuccessfulSnapshot time.Time)
 
 	// CleanupSnapshots cleans up snapshot files.
 	CleanupSnapshots(earliestToRetain time.Time) error
 
-	// CleanupExpiredFileSets removes expired fileset files.
+	// CleanupExpiredFileSets removes expired fileset files and fileset
+	// volumes superseded by a later volume of the same block.
 	CleanupExpiredFileSets(earliestToRetain time.Time) error
 
 	// Repair repairs the shard data for a given time.
@@ -528,10 +671,48 @@ type databaseBootstrapManager interface {
 	Report()
 }
 
+// namespaceFlushResult describes the shards considered when flushing a
+// block of a namespace.
+type namespaceFlushResult struct {
+	// flushedShards is the number of shards that flushed the block.
+	flushedShards int
+
+	// deferredShards is the number of shards that need to flush the block
+	// but were left to flush it later, e.g. shards whose flush jitter has
+	// not elapsed yet or that only dry ran the flush.
+	deferredShards int
+}
+
+// flushResult describes the namespace blocks considered during a flush.
+type flushResult struct {
+	// flushedBlocks is the number of blocks flushed successfully.
+	flushedBlocks int
+
+	// failedBlocks is the number of blocks that failed to flush.
+	failedBlocks int
+
+	// deferredBlocks is the number of blocks that need flushing but were
+	// left for a later flush, e.g. blocks selected within the retry backoff
+	// or blocks that shards deferred flushing as their flush jitter has not
+	// elapsed yet or the flush was a dry run.
+	deferredBlocks int
+
+	// emptyBlocks is the number of blocks without data that were left
+	// unflushed until data arrives for them.
+	emptyBlocks int
+}
+
 // databaseFlushManager manages flushing in-memory data to persistent storage.
 type databaseFlushManager interface {
 	// Flush flushes in-memory data to persistent storage.
-	Flush(tickStart time.Time, dbBootstrapStateAtTickStart DatabaseBootstrapState) error
+	Flush(tickStart time.Time, dbBootstrapStateAtTickStart DatabaseBootstrapState) (flushResult, error)
+
+	// Snapshot snapshots on demand the blocks that can still hold buffered data.
+	Snapshot(snapshotTime time.Time) error
+
+	// FlushNow flushes on demand the block starting at the given time if the
+	// block can no longer receive writes.
+	FlushNow(blockStart time.Time) error
 
 	// Report reports runtime information
 	Report()
@@ -552,7 +733,20 @@ type databaseFileSystemManager interface {
 	Cleanup(t time.Time) error
 
 	// Flush flushes in-memory data to persistent storage.
-	Flush(t time.Time, dbBootstrapStateAtTickStart DatabaseBootstrapState) error
+	Flush(t time.Time, dbBootstrapStateAtTickStart DatabaseBootstrapState) (flushResult, error)
+
+	// Snapshot snapshots on demand the blocks that can still hold buffered
+	// data, it is rejected while other file operations are in progress.
+	Snapshot(snapshotTime time.Time) error
+
+	// FlushNow flushes on demand the block starting at the given time if the
+	// block can no longer receive writes, it is rejected while other file
+	// operations are in progress.
+	FlushNow(blockStart time.Time) error
+
+	// CleanupNow cleans up on demand the data not needed in the persistent
+	// storage, it is rejected while other file operations are in progress.
+	CleanupNow(t time.Time) error
 
 	// Disable disables the filesystem manager and prevents it from
 	// performing file operations, returns the current file operation status
@@ -565,13 +759,14 @@ type databaseFileSystemManager interface {
 	Status() fileOpStatus
 
 	// Run attempts to perform all filesystem-related operations,
-	// returning true if those operations are performed, and false otherwise
+	// returning true if those operations are performed, and false otherwise.
+	// The flush result is only populated for synchronous runs.
 	Run(
 		t time.Time,
 		dbBootstrapStateAtTickStart DatabaseBootstrapState,
 		runType runType,
 		forceType forceType,
-	) bool
+	) (flushResult, bool)
 
 	// Report reports runtime information
 	Report()
@@ -614,6 +809,30 @@ type databaseTickManager interface {
 	Tick(forceType forceType, tickStart time.Time) error
 }
 
+// TickResult describes the work performed by a database tick.
+type TickResult struct {
+	// FlushedBlocks is the number of blocks flushed successfully.
+	FlushedBlocks int
+
+	// FailedBlocks is the number of blocks that failed to flush and will be
+	// retried, blocks that permanently failed to flush are not retried.
+	FailedBlocks int
+
+	// DeferredBlocks is the number of blocks that need flushing but were
+	// left for a later flush.
+	DeferredBlocks int
+
+	// EmptyBlocks is the number of blocks without data that were left
+	// unflushed until data arrives for them.
+	EmptyBlocks int
+
+	// MoreWork is true if blocks that failed or were deferred can be flushed
+	// by a subsequent tick, so the caller may want to tick again sooner.
+	// Empty blocks are not counted as they only need flushing once data
+	// arrives for them.
+	MoreWork bool
+}
+
 // databaseMediator mediates actions among various database managers
 type databaseMediator interface {
 	// Open opens the mediator
@@ -632,11 +851,24 @@ type databaseMediator interface {
 	EnableFileOps()
 
 	// Tick performs a tick
-	Tick(runType runType, forceType forceType) error
+	Tick(runType runType, forceType forceType) (TickResult, error)
 
 	// Repair repairs the database
 	Repair() error
 
+	// Snapshot snapshots on demand the blocks that can still hold buffered
+	// data, it is rejected while other file operations are in progress.
+	Snapshot(snapshotTime time.Time) error
+
+	// FlushNow flushes on demand the block starting at the given time if the
+	// block can no longer receive writes, it waits for up to the flush now
+	// timeout for other file operations in progress to complete.
+	FlushNow(blockStart time.Time) error
+
+	// CleanupNow cleans up on demand the data not needed in the persistent
+	// storage, it is rejected while other file operations are in progress.
+	CleanupNow(t time.Time) error
+
 	// Close closes the mediator
 	Close() error
 
@@ -848,8 +1080,101 @@ type Options interface {
 
 	// QueryIDsWorkerPool returns the QueryIDs worker pool.
 	QueryIDsWorkerPool() xsync.WorkerPool
+
+	// SetFlushJitter sets the maximum amount of time by which each shard's
+	// eligibility to flush a block is randomly delayed, to avoid every shard
+	// hitting disk at the same moment when block boundaries align.
+	SetFlushJitter(value time.Duration) Options
+
+	// FlushJitter returns the maximum flush jitter.
+	FlushJitter() time.Duration
+
+	// SetFlushDryRun sets whether flushes are performed as a dry run, in which
+	// case shards only count the flushes without writing any data to disk or
+	// advancing their flush state.
+	SetFlushDryRun(value bool) Options
+
+	// FlushDryRun returns whether flushes are performed as a dry run.
+	FlushDryRun() bool
+
+	// SetFlushNowTimeout sets how long a flush on demand waits for file
+	// operations already in progress, such as a flush started by a tick, to
+	// complete before it is rejected.
+	SetFlushNowTimeout(value time.Duration) Options
+
+	// FlushNowTimeout returns how long a flush on demand waits for file
+	// operations already in progress to complete.
+	FlushNowTimeout() time.Duration
+
+	// SetShardFlushTimingFn sets the function called with the time taken by
+	// each shard to flush a block.
+	SetShardFlushTimingFn(value ShardFlushTimingFn) Options
+
+	// ShardFlushTimingFn returns the function called with the time taken by
+	// each shard to flush a block.
+	ShardFlushTimingFn() ShardFlushTimingFn
+
+	// SetShardFlushPriorityFn sets the function that returns the flush priority
+	// of each shard, shards with a higher priority flush a block before shards
+	// with a lower priority, nil flushes shards in shard order.
+	SetShardFlushPriorityFn(value ShardFlushPriorityFn) Options
+
+	// ShardFlushPriorityFn returns the function that returns the flush
+	// priority of each shard.
+	ShardFlushPriorityFn() ShardFlushPriorityFn
+
+	// SetFlushErrorRetryableFn sets the function that classifies flush errors,
+	// a block that fails to flush with an error that is not retryable is
+	// marked as permanently failed and never retried, nil retries all errors.
+	SetFlushErrorRetryableFn(value FlushErrorRetryableFn) Options
+
+	// FlushErrorRetryableFn returns the function that classifies flush errors.
+	FlushErrorRetryableFn() FlushErrorRetryableFn
+
+	// SetSkipFlushEmptyBlocks sets whether to skip scheduling flushes for
+	// blocks that no shard holds any data for.
+	SetSkipFlushEmptyBlocks(value bool) Options
+
+	// SkipFlushEmptyBlocks returns whether to skip scheduling flushes for
+	// blocks that no shard holds any data for.
+	SkipFlushEmptyBlocks() bool
+
+	// SetFlushRetryBackoff sets how long after a block is selected for a
+	// flush it is backed off from being selected again when it still needs a
+	// flush, e.g. as the flush failed, so that frequent ticks do not retry the
+	// same block on every tick, zero retries blocks on the next flush.
+	SetFlushRetryBackoff(value time.Duration) Options
+
+	// FlushRetryBackoff returns how long after a block is selected for a
+	// flush it is backed off from being selected again.
+	FlushRetryBackoff() time.Duration
+
+	// SetReadOnly sets whether the database is read only, in which case writes
+	// are rejected and no data is flushed or snapshotted, for instance for
+	// replicas that receive their data files from elsewhere.
+	SetReadOnly(value bool) Options
+
+	// ReadOnly returns whether the database is read only.
+	ReadOnly() bool
 }
 
+// ShardFlushTimingFn is called with the time taken by a shard to flush the
+// block starting at blockStart.
+type ShardFlushTimingFn func(
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	duration time.Duration,
+)
+
+// ShardFlushPriorityFn returns the flush priority of a shard of a namespace,
+// shards with a higher priority are flushed first.
+type ShardFlushPriorityFn func(namespace ident.ID, shard uint32) int
+
+// FlushErrorRetryableFn returns whether a block that failed to flush with
+// the error should be flushed again.
+type FlushErrorRetryableFn func(err error) bool
+
 // DatabaseBootstrapState stores a snapshot of the bootstrap state for all shards across all
 // namespaces at a given moment in time.
 type DatabaseBootstrapState struct {
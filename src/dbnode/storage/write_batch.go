@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Error returns the errors for the data points of the batch that failed.
+func (e BatchError) Error() string {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("%d batch write errors: [", len(e)))
+	for i := range e {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(fmt.Sprintf("%d: %v", e[i].Index, e[i].Err))
+	}
+	buf.WriteString("]")
+	return buf.String()
+}
+
+// newBatchErrorForAll returns a batch error attributing err to every data
+// point of a batch, used when the batch fails before any routing.
+func newBatchErrorForAll(numPoints int, err error) BatchError {
+	if numPoints == 0 {
+		return nil
+	}
+	errs := make(BatchError, 0, numPoints)
+	for i := 0; i < numPoints; i++ {
+		errs = append(errs, BatchWriteError{Index: i, Err: err})
+	}
+	return errs
+}
+
+// shardBatch is the data points of a batch owned by a single shard along
+// with their indexes in the batch, err is set if the shard is not owned.
+type shardBatch struct {
+	shardID uint32
+	shard   databaseShard
+	err     error
+	indexes []int
+	points  []DataPoint
+}
@@ -20,17 +20,75 @@
 
 package storage
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/m3db/m3metrics/policy"
+)
+
+var (
+	errMetricsTypeNameEmpty = errors.New("metrics type name must not be empty")
+	errMetricsTypeRequired  = errors.New("metrics type is required but was not set")
+
+	errStoragePolicyResolutionNotPositive = errors.New("storage policy resolution must be positive")
+	errStoragePolicyRetentionTooShort     = errors.New("storage policy retention must exceed resolution")
+	errStoragePolicyResolutionNotDivisor  = errors.New("storage policy resolution must divide retention evenly")
+)
+
+// RegisterMetricsType registers a custom stored metrics type with a name,
+// returning the new metrics type which is then accepted as valid and can be
+// unmarshalled from YAML by its name.
+func RegisterMetricsType(name string) (MetricsType, error) {
+	if name == "" {
+		return 0, errMetricsTypeNameEmpty
+	}
+
+	validMetricsTypesLock.Lock()
+	defer validMetricsTypesLock.Unlock()
+
+	var next MetricsType
+	for _, valid := range validMetricsTypes {
+		if name == metricsTypeNameWithLock(valid) {
+			return 0, fmt.Errorf("MetricsType '%s' is already registered", name)
+		}
+		if valid >= next {
+			next = valid + 1
+		}
+	}
+
+	validMetricsTypes = append(validMetricsTypes, next)
+	registeredMetricsTypeNames[next] = name
+	return next, nil
+}
+
+func metricsTypeNameWithLock(t MetricsType) string {
+	// NB: Valid types are either built in or registered so String never
+	// needs to acquire the lock that is already held here.
+	if name, ok := registeredMetricsTypeNames[t]; ok {
+		return name
+	}
+	return t.String()
+}
+
+// ValidMetricsTypes returns the valid stored metrics types.
+func ValidMetricsTypes() []MetricsType {
+	validMetricsTypesLock.RLock()
+	defer validMetricsTypesLock.RUnlock()
+	return append([]MetricsType(nil), validMetricsTypes...)
+}
 
 // ValidateMetricsType validates a stored metrics type.
 func ValidateMetricsType(v MetricsType) error {
-	for _, valid := range validMetricsTypes {
+	validTypes := ValidMetricsTypes()
+	for _, valid := range validTypes {
 		if valid == v {
 			return nil
 		}
 	}
 	return fmt.Errorf("invalid stored metrics type '%v': should be one of %v",
-		v, validMetricsTypes)
+		v, validTypes)
 }
 
 // UnmarshalYAML unmarshals a stored merics type.
@@ -39,12 +97,102 @@ func (v *MetricsType) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err := unmarshal(&str); err != nil {
 		return err
 	}
-	for _, valid := range validMetricsTypes {
+	validTypes := ValidMetricsTypes()
+	for _, valid := range validTypes {
 		if str == valid.String() {
 			*v = valid
 			return nil
 		}
 	}
 	return fmt.Errorf("invalid MetricsType '%s' valid types are: %v",
-		str, validMetricsTypes)
+		str, validTypes)
+}
+
+// MarshalYAML marshals a stored metrics type by name.
+func (v MetricsType) MarshalYAML() (interface{}, error) {
+	return v.String(), nil
+}
+
+// RequiredMetricsType is a stored metrics type that must be explicitly set,
+// for use in configuration where falling back to the zero value metrics type
+// would silently select a valid looking default.
+type RequiredMetricsType struct {
+	MetricsType
+	set bool
+}
+
+// UnmarshalYAML unmarshals a required stored metrics type.
+func (v *RequiredMetricsType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := v.MetricsType.UnmarshalYAML(unmarshal); err != nil {
+		return err
+	}
+	v.set = true
+	return nil
+}
+
+// Validate returns an error if the metrics type was never set, this must be
+// called after unmarshalling as fields absent from YAML are never unmarshalled.
+func (v RequiredMetricsType) Validate() error {
+	if !v.set {
+		return errMetricsTypeRequired
+	}
+	return ValidateMetricsType(v.MetricsType)
+}
+
+// StoragePolicy pairs a stored metrics type with the resolution and
+// retention of the stored metrics.
+type StoragePolicy struct {
+	MetricsType MetricsType
+	Resolution  time.Duration
+	Retention   time.Duration
+}
+
+// Validate validates the storage policy.
+func (p StoragePolicy) Validate() error {
+	if err := ValidateMetricsType(p.MetricsType); err != nil {
+		return err
+	}
+	if p.Resolution <= 0 {
+		return errStoragePolicyResolutionNotPositive
+	}
+	if p.Retention <= p.Resolution {
+		return errStoragePolicyRetentionTooShort
+	}
+	if p.Retention%p.Resolution != 0 {
+		return errStoragePolicyResolutionNotDivisor
+	}
+	return nil
+}
+
+// Attributes returns the stored metrics attributes of the storage policy.
+func (p StoragePolicy) Attributes() Attributes {
+	return Attributes{
+		MetricsType: p.MetricsType,
+		Resolution:  p.Resolution,
+		Retention:   p.Retention,
+	}
+}
+
+// UnmarshalYAML unmarshals a storage policy from the compact
+// resolution:retention form, e.g. 10s:2d. Since only aggregated metrics
+// have a resolution the policy is of the aggregated metrics type.
+func (p *StoragePolicy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	sp, err := policy.ParseStoragePolicy(str)
+	if err != nil {
+		return fmt.Errorf("invalid StoragePolicy '%s': %v", str, err)
+	}
+	value := StoragePolicy{
+		MetricsType: AggregatedMetricsType,
+		Resolution:  sp.Resolution().Window,
+		Retention:   sp.Retention().Duration(),
+	}
+	if err := value.Validate(); err != nil {
+		return fmt.Errorf("invalid StoragePolicy '%s': %v", str, err)
+	}
+	*p = value
+	return nil
 }
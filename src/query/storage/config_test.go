@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -32,6 +33,7 @@ import (
 
 func TestValidateMetricsType(t *testing.T) {
 	assert.NoError(t, ValidateMetricsType(UnaggregatedMetricsType))
+	assert.NoError(t, ValidateMetricsType(AggregatedMetricsType))
 	assert.Error(t, ValidateMetricsType(MetricsType(math.MaxUint64)))
 }
 
@@ -40,7 +42,7 @@ func TestMetricsTypeUnmarshalYAML(t *testing.T) {
 		Type MetricsType `yaml:"type"`
 	}
 
-	for _, value := range validMetricsTypes {
+	for _, value := range ValidMetricsTypes() {
 		str := fmt.Sprintf("type: %s\n", value.String())
 
 		var cfg config
@@ -52,3 +54,126 @@ func TestMetricsTypeUnmarshalYAML(t *testing.T) {
 	var cfg config
 	require.Error(t, yaml.Unmarshal([]byte("type: not_a_known_type\n"), &cfg))
 }
+
+func TestMetricsTypeUnmarshalYAMLAggregated(t *testing.T) {
+	type config struct {
+		Type MetricsType `yaml:"type"`
+	}
+
+	var cfg config
+	require.NoError(t, yaml.Unmarshal([]byte("type: aggregated\n"), &cfg))
+	assert.Equal(t, AggregatedMetricsType, cfg.Type)
+	assert.Equal(t, "aggregated", cfg.Type.String())
+}
+
+func TestStoragePolicyUnmarshalYAML(t *testing.T) {
+	type config struct {
+		Policy StoragePolicy `yaml:"policy"`
+	}
+
+	var cfg config
+	require.NoError(t, yaml.Unmarshal([]byte("policy: 10s:2d\n"), &cfg))
+	assert.Equal(t, StoragePolicy{
+		MetricsType: AggregatedMetricsType,
+		Resolution:  10 * time.Second,
+		Retention:   48 * time.Hour,
+	}, cfg.Policy)
+
+	for _, str := range []string{
+		"not_a_policy",
+		"10s",
+		"10s:",
+		":2d",
+		"1h:1h",
+		"7m:1h",
+		"2d:10s",
+	} {
+		var cfg config
+		err := yaml.Unmarshal([]byte(fmt.Sprintf("policy: %s\n", str)), &cfg)
+		assert.Error(t, err, "expected error for policy %s", str)
+	}
+}
+
+func TestStoragePolicyValidate(t *testing.T) {
+	valid := StoragePolicy{
+		MetricsType: AggregatedMetricsType,
+		Resolution:  time.Minute,
+		Retention:   24 * time.Hour,
+	}
+	require.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.Resolution = 0
+	require.Equal(t, errStoragePolicyResolutionNotPositive, invalid.Validate())
+
+	invalid = valid
+	invalid.Retention = valid.Resolution
+	require.Equal(t, errStoragePolicyRetentionTooShort, invalid.Validate())
+
+	invalid = valid
+	invalid.Resolution = 7 * time.Minute
+	require.Equal(t, errStoragePolicyResolutionNotDivisor, invalid.Validate())
+
+	invalid = valid
+	invalid.MetricsType = MetricsType(math.MaxUint64)
+	require.Error(t, invalid.Validate())
+}
+
+func TestRegisterMetricsTypeRoundTripYAML(t *testing.T) {
+	type config struct {
+		Type MetricsType `yaml:"type"`
+	}
+
+	custom, err := RegisterMetricsType("custom")
+	require.NoError(t, err)
+	assert.NoError(t, ValidateMetricsType(custom))
+	assert.Equal(t, "custom", custom.String())
+	assert.NotEqual(t, UnaggregatedMetricsType, custom)
+	assert.NotEqual(t, AggregatedMetricsType, custom)
+
+	data, err := yaml.Marshal(config{Type: custom})
+	require.NoError(t, err)
+	assert.Equal(t, "type: custom\n", string(data))
+
+	var cfg config
+	require.NoError(t, yaml.Unmarshal(data, &cfg))
+	assert.Equal(t, custom, cfg.Type)
+
+	_, err = RegisterMetricsType("custom")
+	assert.Error(t, err)
+	_, err = RegisterMetricsType("aggregated")
+	assert.Error(t, err)
+	_, err = RegisterMetricsType("")
+	assert.Equal(t, errMetricsTypeNameEmpty, err)
+}
+
+func TestRequiredMetricsTypeUnmarshalYAML(t *testing.T) {
+	type config struct {
+		Type RequiredMetricsType `yaml:"type"`
+	}
+
+	var cfg config
+	require.NoError(t, yaml.Unmarshal([]byte("type: aggregated\n"), &cfg))
+	require.NoError(t, cfg.Type.Validate())
+	assert.Equal(t, AggregatedMetricsType, cfg.Type.MetricsType)
+
+	// Explicitly setting the zero value metrics type is allowed
+	cfg = config{}
+	require.NoError(t, yaml.Unmarshal([]byte("type: unaggregated\n"), &cfg))
+	require.NoError(t, cfg.Type.Validate())
+	assert.Equal(t, UnaggregatedMetricsType, cfg.Type.MetricsType)
+
+	cfg = config{}
+	require.Error(t, yaml.Unmarshal([]byte("type: not_a_known_type\n"), &cfg))
+}
+
+func TestRequiredMetricsTypeMissing(t *testing.T) {
+	type config struct {
+		Name string              `yaml:"name"`
+		Type RequiredMetricsType `yaml:"type"`
+	}
+
+	var cfg config
+	require.NoError(t, yaml.Unmarshal([]byte("name: foo\n"), &cfg))
+	assert.Equal(t, errMetricsTypeRequired, cfg.Type.Validate())
+}
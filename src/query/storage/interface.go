@@ -23,6 +23,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/m3db/m3/src/query/block"
@@ -142,10 +143,12 @@ const (
 )
 
 var (
-	validMetricsTypes = []MetricsType{
+	validMetricsTypesLock sync.RWMutex
+	validMetricsTypes     = []MetricsType{
 		UnaggregatedMetricsType,
 		AggregatedMetricsType,
 	}
+	registeredMetricsTypeNames = make(map[MetricsType]string)
 )
 
 func (t MetricsType) String() string {
@@ -154,9 +157,15 @@ func (t MetricsType) String() string {
 		return "unaggregated"
 	case AggregatedMetricsType:
 		return "aggregated"
-	default:
+	}
+
+	validMetricsTypesLock.RLock()
+	name, ok := registeredMetricsTypeNames[t]
+	validMetricsTypesLock.RUnlock()
+	if !ok {
 		return "unknown"
 	}
+	return name
 }
 
 // Attributes is a set of stored metrics attributes.
@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var errMetricsTypeRouteStorageNil = errors.New("metrics type route storage must not be nil")
+
+// MetricsTypeRouter routes requests to the storage configured for the
+// stored metrics type of the request.
+type MetricsTypeRouter struct {
+	sync.RWMutex
+	routes map[MetricsType]Storage
+}
+
+// NewMetricsTypeRouter returns a new metrics type router with no routes.
+func NewMetricsTypeRouter() *MetricsTypeRouter {
+	return &MetricsTypeRouter{
+		routes: make(map[MetricsType]Storage),
+	}
+}
+
+// Register routes a valid stored metrics type to a storage, a metrics type
+// can only be routed to a single storage.
+func (r *MetricsTypeRouter) Register(t MetricsType, s Storage) error {
+	if err := ValidateMetricsType(t); err != nil {
+		return err
+	}
+	if s == nil {
+		return errMetricsTypeRouteStorageNil
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.routes[t]; ok {
+		return fmt.Errorf("metrics type '%v' is already routed", t)
+	}
+	r.routes[t] = s
+	return nil
+}
+
+// Route returns the storage routed to for a stored metrics type.
+func (r *MetricsTypeRouter) Route(t MetricsType) (Storage, error) {
+	r.RLock()
+	s, ok := r.routes[t]
+	r.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no storage routed for metrics type '%v'", t)
+	}
+	return s, nil
+}
+
+// Write writes to the storage routed to for the metrics type of the query.
+func (r *MetricsTypeRouter) Write(ctx context.Context, query *WriteQuery) error {
+	s, err := r.Route(query.Attributes.MetricsType)
+	if err != nil {
+		return err
+	}
+	return s.Write(ctx, query)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRoutedStorage struct {
+	Storage
+	writes []*WriteQuery
+}
+
+func (s *testRoutedStorage) Write(_ context.Context, query *WriteQuery) error {
+	s.writes = append(s.writes, query)
+	return nil
+}
+
+func TestMetricsTypeRouterRoutesByMetricsType(t *testing.T) {
+	var (
+		router       = NewMetricsTypeRouter()
+		unaggregated = &testRoutedStorage{}
+		aggregated   = &testRoutedStorage{}
+	)
+	require.NoError(t, router.Register(UnaggregatedMetricsType, unaggregated))
+	require.NoError(t, router.Register(AggregatedMetricsType, aggregated))
+
+	s, err := router.Route(UnaggregatedMetricsType)
+	require.NoError(t, err)
+	assert.True(t, s == unaggregated)
+
+	s, err = router.Route(AggregatedMetricsType)
+	require.NoError(t, err)
+	assert.True(t, s == aggregated)
+
+	unaggregatedWrite := &WriteQuery{
+		Attributes: Attributes{MetricsType: UnaggregatedMetricsType},
+	}
+	aggregatedWrite := &WriteQuery{
+		Attributes: Attributes{MetricsType: AggregatedMetricsType},
+	}
+	require.NoError(t, router.Write(context.Background(), unaggregatedWrite))
+	require.NoError(t, router.Write(context.Background(), aggregatedWrite))
+
+	assert.Equal(t, []*WriteQuery{unaggregatedWrite}, unaggregated.writes)
+	assert.Equal(t, []*WriteQuery{aggregatedWrite}, aggregated.writes)
+}
+
+func TestMetricsTypeRouterUnroutedMetricsType(t *testing.T) {
+	router := NewMetricsTypeRouter()
+	require.NoError(t, router.Register(UnaggregatedMetricsType, &testRoutedStorage{}))
+
+	_, err := router.Route(AggregatedMetricsType)
+	require.Error(t, err)
+
+	err = router.Write(context.Background(), &WriteQuery{
+		Attributes: Attributes{MetricsType: AggregatedMetricsType},
+	})
+	require.Error(t, err)
+}
+
+func TestMetricsTypeRouterRegisterErrors(t *testing.T) {
+	router := NewMetricsTypeRouter()
+
+	assert.Error(t, router.Register(MetricsType(math.MaxUint64), &testRoutedStorage{}))
+	assert.Error(t, router.Register(UnaggregatedMetricsType, nil))
+
+	require.NoError(t, router.Register(UnaggregatedMetricsType, &testRoutedStorage{}))
+	assert.Error(t, router.Register(UnaggregatedMetricsType, &testRoutedStorage{}))
+}